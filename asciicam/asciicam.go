@@ -0,0 +1,192 @@
+// Package asciicam renders images as terminal text, using the render
+// modes, character ramps and filters of the asciicam program:
+//
+//	out, err := asciicam.RenderImage(img, asciicam.WithWidth(100), asciicam.WithMode("braille"))
+//
+// A Pipeline renders a stream of frames with the same options, reusing its
+// buffers from frame to frame:
+//
+//	p, err := asciicam.New(asciicam.WithSize(80, 24), asciicam.WithGreenscreen(background, 0.13))
+//	for frame := range frames {
+//		out, err := p.Render(frame)
+//		...
+//	}
+//
+// The packages below it give access to the individual stages: source for
+// capturing, filter for scaling and effects and render for the renderers.
+package asciicam
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// CellAspect is the height of a terminal cell relative to its width that
+// output sizes are derived with, unless WithCellAspect sets another one.
+const CellAspect = 2
+
+// Option configures a Pipeline or RenderImage.
+type Option func(*options)
+
+type options struct {
+	cols, rows int
+	aspect     float64
+	mode       string
+	charset    string
+	profile    termenv.Profile
+	color      color.RGBA
+	filters    filter.Chain
+	background image.Image
+	threshold  float64
+}
+
+// WithSize sets the output size in terminal cells.
+func WithSize(cols, rows int) Option {
+	return func(o *options) { o.cols, o.rows = cols, rows }
+}
+
+// WithWidth sets the output width in terminal cells, the height follows
+// from the aspect ratio of the image. The default width is 80.
+func WithWidth(cols int) Option {
+	return func(o *options) { o.cols, o.rows = cols, 0 }
+}
+
+// WithCellAspect sets the height of a terminal cell relative to its width,
+// e.g. measured with term.CellSize, that the height is derived with when
+// only the width is given. The default is CellAspect.
+func WithCellAspect(aspect float64) Option {
+	return func(o *options) { o.aspect = aspect }
+}
+
+// WithMode selects a render mode by name, see render.Modes. The default
+// is "ansi".
+func WithMode(name string) Option {
+	return func(o *options) { o.mode = name }
+}
+
+// WithCharset selects the character ramp of the ascii and mono modes by
+// name, see render.Charsets.
+func WithCharset(name string) Option {
+	return func(o *options) { o.charset = name }
+}
+
+// WithProfile sets the color profile of the output. The default is
+// true color, termenv.Ascii leaves out colors.
+func WithProfile(p termenv.Profile) Option {
+	return func(o *options) { o.profile = p }
+}
+
+// WithColor draws all cells in a single color.
+func WithColor(c color.Color) Option {
+	return func(o *options) {
+		o.color = color.RGBAModel.Convert(c).(color.RGBA)
+		o.color.A = 255
+	}
+}
+
+// WithFilters applies filters to the scaled image before rendering, in
+// the given order.
+func WithFilters(filters ...filter.Filter) Option {
+	return func(o *options) { o.filters = append(o.filters, filters...) }
+}
+
+// WithGreenscreen removes the background from frames: pixels whose color
+// is within threshold of the same pixel of background, an image of the
+// empty scene, become transparent. The background is scaled like the
+// frames and applied before the filters.
+func WithGreenscreen(background image.Image, threshold float64) Option {
+	return func(o *options) { o.background, o.threshold = background, threshold }
+}
+
+// Pipeline scales, filters and renders frames with fixed options. It keeps
+// its buffers between frames and is not safe for concurrent use.
+type Pipeline struct {
+	o      options
+	mode   render.Mode
+	r      render.Renderer
+	scaler filter.Scaler
+	chain  filter.Chain
+
+	bgScaler filter.Scaler
+	bg       []filter.Lab
+	bgSize   image.Point // frame size bg was computed for
+}
+
+// New returns a Pipeline with the given options. It fails on unknown
+// modes and charsets and on invalid sizes.
+func New(opts ...Option) (*Pipeline, error) {
+	p := &Pipeline{o: options{cols: 80, aspect: CellAspect, mode: "ansi", charset: render.Charsets[0].Name, profile: termenv.TrueColor}}
+	for _, opt := range opts {
+		opt(&p.o)
+	}
+
+	if p.o.cols <= 0 || p.o.rows < 0 {
+		return nil, errors.New("invalid output size")
+	}
+	if p.o.aspect <= 0 {
+		return nil, errors.New("invalid cell aspect")
+	}
+	mi, err := render.Index(p.o.mode)
+	if err != nil {
+		return nil, err
+	}
+	ci, err := render.CharsetIndex(p.o.charset)
+	if err != nil {
+		return nil, err
+	}
+	p.mode = render.Modes()[mi]
+	p.r = p.mode.New(render.Options{Profile: p.o.profile, Pixels: render.Charsets[ci].Pixels, Color: p.o.color})
+
+	if p.o.background != nil {
+		if p.o.background.Bounds().Empty() {
+			return nil, errors.New("empty background")
+		}
+		p.chain = append(p.chain, nil) // filter.Background, set per frame size
+	}
+	p.chain = append(p.chain, p.o.filters...)
+	return p, nil
+}
+
+// Render renders img as terminal text, one line per row without a
+// trailing newline. Without a height the number of rows follows from the
+// aspect ratio of img.
+func (p *Pipeline) Render(img image.Image) (string, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return "", errors.New("empty image")
+	}
+	cols, rows := p.o.cols, p.o.rows
+	if rows == 0 {
+		rows = max(1, int(math.Round(float64(cols*b.Dy())/(float64(b.Dx())*p.o.aspect))))
+	}
+
+	w, h := uint(cols)*p.mode.CellW, uint(rows)*p.mode.CellH
+	scaled := p.scaler.Scale(img, b, w, h)
+	if p.o.background != nil {
+		if size := (image.Point{int(w), int(h)}); p.bg == nil || size != p.bgSize {
+			bg := p.bgScaler.Scale(p.o.background, p.o.background.Bounds(), w, h)
+			p.bg, p.bgSize = filter.LabPlane(p.bg, bg), size
+		}
+		p.chain[0] = filter.Background{Plane: p.bg, Dist: p.o.threshold}
+	}
+	p.chain.Apply(scaled)
+
+	return strings.TrimSuffix(string(p.r.Render(scaled, cols, rows)), "\n"), nil
+}
+
+// RenderImage renders img as terminal text, one line per row without a
+// trailing newline. It is a shorthand for a Pipeline used once.
+func RenderImage(img image.Image, opts ...Option) (string, error) {
+	p, err := New(opts...)
+	if err != nil {
+		return "", err
+	}
+	return p.Render(img)
+}
@@ -0,0 +1,114 @@
+// Package face finds faces in frames with the pigo detector, a pure Go
+// port of the pico object detection cascades:
+//
+//	d, err := face.NewDetector()
+//	faces := d.Detect(img, img.Bounds())
+//
+// The face cascade in cascade/ comes with pigo and is embedded, see
+// cascade/LICENSE.
+package face
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"slices"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+//go:embed cascade/facefinder
+var cascade []byte
+
+// Face is a detected face.
+type Face struct {
+	// Rect is the square around the face, in the coordinates of the
+	// searched image.
+	Rect image.Rectangle
+	// Score is the detection confidence of the cascade. Faces usually
+	// score above 5, the higher the more certain.
+	Score float32
+}
+
+// Detector finds faces in frames. Frames are searched at a width of at most
+// MaxWidth pixels, larger ones are subsampled first, which keeps detection
+// fast enough for live video.
+type Detector struct {
+	// MinScore is the minimum score of the reported faces.
+	MinScore float32
+	// MinSize is the minimum face size as a share of the searched height.
+	MinSize float64
+	// MaxWidth is the width frames are subsampled to, 0 for no limit.
+	MaxWidth int
+
+	p    *pigo.Pigo
+	gray []uint8
+}
+
+// NewDetector returns a detector with the embedded face cascade and
+// defaults that suit webcam pictures.
+func NewDetector() (*Detector, error) {
+	p, err := pigo.NewPigo().Unpack(cascade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load face cascade: %w", err)
+	}
+	return &Detector{MinScore: 5, MinSize: 0.1, MaxWidth: 320, p: p}, nil
+}
+
+// Detect returns the faces in the r part of img, best scoring first.
+func (d *Detector) Detect(img *image.RGBA, r image.Rectangle) []Face {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return nil
+	}
+
+	// subsample to at most MaxWidth pixels across
+	step := 1
+	if d.MaxWidth > 0 {
+		step = max(1, (r.Dx()+d.MaxWidth-1)/d.MaxWidth)
+	}
+	cols, rows := r.Dx()/step, r.Dy()/step
+	if cols == 0 || rows == 0 {
+		return nil
+	}
+	d.gray = slices.Grow(d.gray[:0], cols*rows)[:cols*rows]
+	for y := range rows {
+		row := img.Pix[img.PixOffset(r.Min.X, r.Min.Y+y*step):]
+		for x := range cols {
+			p := row[4*x*step : 4*x*step+3]
+			d.gray[y*cols+x] = uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000)
+		}
+	}
+
+	dets := d.p.RunCascade(pigo.CascadeParams{
+		MinSize:     max(20, int(d.MinSize*float64(rows))),
+		MaxSize:     min(cols, rows),
+		ShiftFactor: 0.1,
+		ScaleFactor: 1.1,
+		ImageParams: pigo.ImageParams{Pixels: d.gray, Rows: rows, Cols: cols, Dim: cols},
+	}, 0)
+	dets = d.p.ClusterDetections(dets, 0.2)
+
+	var faces []Face
+	for _, det := range dets {
+		if det.Q < d.MinScore {
+			continue
+		}
+		half := det.Scale * step / 2
+		x, y := r.Min.X+det.Col*step, r.Min.Y+det.Row*step
+		faces = append(faces, Face{
+			Rect:  image.Rect(x-half, y-half, x+half, y+half).Intersect(r),
+			Score: det.Q,
+		})
+	}
+	slices.SortFunc(faces, func(a, b Face) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return faces
+}
@@ -0,0 +1,168 @@
+// Package figlet renders large banner text with FIGlet fonts (.flf).
+// The fonts in fonts/ are embedded and selected by name, other FIGlet
+// fonts are loaded from a file:
+//
+//	f, err := figlet.Load("halfblock")
+//	lines := f.Render("BRB")
+//
+// Characters are set next to each other at their full width, the smushing
+// and kerning rules of a font are not applied.
+package figlet
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed fonts/*.flf
+var fonts embed.FS
+
+// deutsch are the codes of the optional characters following the required
+// ones, in this order.
+var deutsch = []rune{196, 214, 220, 228, 246, 252, 223}
+
+// Font is a parsed FIGlet font.
+type Font struct {
+	height int
+	glyphs map[rune][]string // lines of every character, height each
+}
+
+// Fonts returns the names of the embedded fonts, sorted.
+func Fonts() []string {
+	entries, _ := fonts.ReadDir("fonts")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".flf"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the embedded font name, or loads the font file at name if
+// there is none of that name.
+func Load(name string) (*Font, error) {
+	r, err := fonts.Open(path.Join("fonts", name+".flf"))
+	if err != nil {
+		f, ferr := os.Open(name)
+		if ferr != nil {
+			return nil, fmt.Errorf("unknown font %q", name)
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+	defer r.Close()
+	return Parse(r)
+}
+
+// Parse reads a font in the FIGlet 2 format.
+func Parse(r io.Reader) (*Font, error) {
+	s := bufio.NewScanner(r)
+	if !s.Scan() {
+		return nil, fmt.Errorf("empty font")
+	}
+	header := strings.Fields(s.Text())
+	if len(header) < 6 || !strings.HasPrefix(header[0], "flf2a") || len(header[0]) < 6 {
+		return nil, fmt.Errorf("not a FIGlet font")
+	}
+	hardblank, _ := utf8.DecodeRuneInString(header[0][5:])
+	height, err := strconv.Atoi(header[1])
+	if err != nil || height < 1 {
+		return nil, fmt.Errorf("invalid font height %q", header[1])
+	}
+	comments, err := strconv.Atoi(header[5])
+	if err != nil || comments < 0 {
+		return nil, fmt.Errorf("invalid comment line count %q", header[5])
+	}
+	for range comments {
+		s.Scan()
+	}
+
+	f := &Font{height: height, glyphs: make(map[rune][]string)}
+	glyph := func() ([]string, bool) {
+		lines := make([]string, height)
+		for i := range lines {
+			if !s.Scan() {
+				return nil, false
+			}
+			l := strings.TrimRight(s.Text(), " \t\r")
+			if l != "" {
+				// the last character is the end mark, doubled on the last line
+				end, _ := utf8.DecodeLastRuneInString(l)
+				l = strings.TrimRight(l, string(end))
+			}
+			lines[i] = strings.ReplaceAll(l, string(hardblank), " ")
+		}
+		return lines, true
+	}
+
+	// the required characters, then the optional ones
+	for c := rune(32); c < 127; c++ {
+		lines, ok := glyph()
+		if !ok {
+			return nil, fmt.Errorf("font ends before character %d", c)
+		}
+		f.glyphs[c] = lines
+	}
+	for _, c := range deutsch {
+		lines, ok := glyph()
+		if !ok {
+			return f, s.Err()
+		}
+		f.glyphs[c] = lines
+	}
+	for s.Scan() {
+		// code tagged characters start with their code
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		code, err := strconv.ParseInt(fields[0], 0, 32)
+		lines, ok := glyph()
+		if !ok {
+			break
+		}
+		if err == nil && code >= 0 {
+			f.glyphs[rune(code)] = lines
+		}
+	}
+	return f, s.Err()
+}
+
+// Height returns the number of lines of a row of text.
+func (f *Font) Height() int {
+	return f.height
+}
+
+// Render returns the lines of text set in the font, every line of text
+// giving Height lines. Characters missing in the font are left out.
+func (f *Font) Render(text string) []string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		rows := make([]strings.Builder, f.height)
+		for _, c := range line {
+			g, ok := f.glyphs[c]
+			if !ok {
+				continue
+			}
+			w := 0
+			for _, l := range g {
+				w = max(w, utf8.RuneCountInString(l))
+			}
+			for i, l := range g {
+				rows[i].WriteString(l + strings.Repeat(" ", w-utf8.RuneCountInString(l)))
+			}
+		}
+		for i := range rows {
+			out = append(out, rows[i].String())
+		}
+	}
+	return out
+}
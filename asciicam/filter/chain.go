@@ -0,0 +1,188 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Filter changes a frame in place.
+type Filter interface {
+	Apply(img *image.RGBA)
+}
+
+// Func adapts a function to a Filter.
+type Func func(img *image.RGBA)
+
+func (f Func) Apply(img *image.RGBA) {
+	f(img)
+}
+
+// Chain is a Filter that applies its filters in order.
+type Chain []Filter
+
+func (c Chain) Apply(img *image.RGBA) {
+	for _, f := range c {
+		f.Apply(img)
+	}
+}
+
+// Key makes pixels close to Color transparent, see ChromaKey.
+type Key struct {
+	Color colorful.Color
+	Dist  float64
+}
+
+func (k Key) Apply(img *image.RGBA) {
+	ChromaKey(img, k.Color, k.Dist)
+}
+
+// Background makes pixels close to the background transparent, see
+// Greenscreen. Plane has to be scaled like the frames.
+type Background struct {
+	Plane []Lab
+	Dist  float64
+}
+
+func (b Background) Apply(img *image.RGBA) {
+	Greenscreen(img, b.Plane, b.Dist)
+}
+
+// parsers create the filters Parse knows from their argument, which is
+// empty if none was given.
+var parsers = map[string]func(arg string) (Filter, error){
+	"mirror": func(string) (Filter, error) { return Mirror{}, nil },
+	"flip":   func(string) (Filter, error) { return Flip{}, nil },
+	"gray":   func(string) (Filter, error) { return Gray{}, nil },
+	"invert": func(string) (Filter, error) { return Invert{}, nil },
+	"blur": func(arg string) (Filter, error) {
+		r, err := intArg(arg, 1)
+		if err != nil || r < 1 {
+			return nil, fmt.Errorf("invalid blur radius %q", arg)
+		}
+		return Blur{Radius: r}, nil
+	},
+	"contrast": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 1.5)
+		if err != nil || f < 0 {
+			return nil, fmt.Errorf("invalid contrast %q", arg)
+		}
+		return Contrast{Factor: f}, nil
+	},
+	"brightness": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.2)
+		if err != nil || f < -1 || f > 1 {
+			return nil, fmt.Errorf("invalid brightness %q", arg)
+		}
+		return Brightness{Delta: f}, nil
+	},
+	"gamma": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.8)
+		if err != nil || f <= 0 {
+			return nil, fmt.Errorf("invalid gamma %q", arg)
+		}
+		return Gamma{Gamma: f}, nil
+	},
+	"temperature": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.3)
+		if err != nil || f < -1 || f > 1 {
+			return nil, fmt.Errorf("invalid temperature %q", arg)
+		}
+		return ColorBalance{Temperature: f}, nil
+	},
+	"tint": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.3)
+		if err != nil || f < -1 || f > 1 {
+			return nil, fmt.Errorf("invalid tint %q", arg)
+		}
+		return ColorBalance{Tint: f}, nil
+	},
+	"kaleido": func(arg string) (Filter, error) {
+		n, err := intArg(arg, 6)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("invalid kaleido segments %q", arg)
+		}
+		return Kaleido{Segments: n}, nil
+	},
+	"mirror4": func(string) (Filter, error) { return Mirror4{}, nil },
+	"stretch": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.5)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("invalid stretch %q", arg)
+		}
+		return Stretch{Amount: f}, nil
+	},
+	"trails": func(arg string) (Filter, error) {
+		n, err := intArg(arg, 8)
+		if err != nil || n < 1 || n > 60 {
+			return nil, fmt.Errorf("invalid trail length %q", arg)
+		}
+		return &Trails{Frames: n}, nil
+	},
+	"vignette": func(arg string) (Filter, error) {
+		f, err := floatArg(arg, 0.5)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("invalid vignette %q", arg)
+		}
+		return Vignette{Strength: f}, nil
+	},
+}
+
+// Register makes a filter available to Parse under name, replacing a
+// filter of the same name. parse gets the argument after "=", which is
+// empty if none was given.
+func Register(name string, parse func(arg string) (Filter, error)) {
+	parsers[name] = parse
+}
+
+// Names returns the names of the filters Parse knows, sorted.
+func Names() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse builds a chain from a comma separated list of filter names with
+// optional arguments, applied in the given order, like
+// "mirror,blur=2,contrast=1.5".
+func Parse(spec string) (Chain, error) {
+	var c Chain
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(item, "=")
+		parse, ok := parsers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", name)
+		}
+		f, err := parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, f)
+	}
+	return c, nil
+}
+
+func intArg(arg string, def int) (int, error) {
+	if arg == "" {
+		return def, nil
+	}
+	return strconv.Atoi(arg)
+}
+
+func floatArg(arg string, def float64) (float64, error) {
+	if arg == "" {
+		return def, nil
+	}
+	return strconv.ParseFloat(arg, 64)
+}
@@ -0,0 +1,277 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Mirror flips frames horizontally, like looking into a mirror.
+type Mirror struct{}
+
+func (Mirror) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i, j := 0, len(row)-4; i < j; i, j = i+4, j-4 {
+			p, q := row[i:i+4:i+4], row[j:j+4:j+4]
+			p[0], p[1], p[2], p[3], q[0], q[1], q[2], q[3] = q[0], q[1], q[2], q[3], p[0], p[1], p[2], p[3]
+		}
+	}
+}
+
+// Flip turns frames upside down.
+type Flip struct{}
+
+func (Flip) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	n := 4 * b.Dx()
+	tmp := make([]byte, n)
+	for y0, y1 := b.Min.Y, b.Max.Y-1; y0 < y1; y0, y1 = y0+1, y1-1 {
+		p := img.Pix[img.PixOffset(b.Min.X, y0):][:n]
+		q := img.Pix[img.PixOffset(b.Min.X, y1):][:n]
+		copy(tmp, p)
+		copy(p, q)
+		copy(q, tmp)
+	}
+}
+
+// Gray removes the colors of frames, keeping their ITU-R BT.601 luma.
+type Gray struct{}
+
+func (Gray) Apply(img *image.RGBA) {
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		p := img.Pix[i : i+3 : i+3]
+		l := uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000)
+		p[0], p[1], p[2] = l, l, l
+	}
+}
+
+// Invert turns frames into their negative.
+type Invert struct{}
+
+func (Invert) Apply(img *image.RGBA) {
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		img.Pix[i] = 255 - img.Pix[i]
+		img.Pix[i+1] = 255 - img.Pix[i+1]
+		img.Pix[i+2] = 255 - img.Pix[i+2]
+	}
+}
+
+// Contrast scales the distance of every channel from mid gray by Factor.
+type Contrast struct {
+	Factor float64
+}
+
+func (c Contrast) Apply(img *image.RGBA) {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = clamp8((float64(v)-127.5)*c.Factor + 127.5)
+	}
+	applyLUT(img, &lut)
+}
+
+// Brightness adds Delta, from -1 to 1, to every channel.
+type Brightness struct {
+	Delta float64
+}
+
+func (b Brightness) Apply(img *image.RGBA) {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = clamp8(float64(v) + b.Delta*255)
+	}
+	applyLUT(img, &lut)
+}
+
+// Gamma puts every channel through a gamma curve. Gammas below 1 brighten
+// the shadows and spread them over more levels, gammas above 1 do the same
+// for the highlights.
+type Gamma struct {
+	Gamma float64
+}
+
+func (g Gamma) Apply(img *image.RGBA) {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = clamp8(255 * math.Pow(float64(v)/255, g.Gamma))
+	}
+	applyLUT(img, &lut)
+}
+
+// ColorBalance shifts the colors of frames. Temperature, from -1 to 1,
+// warms them up towards orange or cools them down towards blue; Tint, from
+// -1 to 1, pushes them towards green or magenta.
+type ColorBalance struct {
+	Temperature float64
+	Tint        float64
+}
+
+func (cb ColorBalance) Apply(img *image.RGBA) {
+	gains := [3]float64{
+		(1 + 0.3*cb.Temperature) * (1 + 0.15*cb.Tint),
+		1 - 0.3*cb.Tint,
+		(1 - 0.3*cb.Temperature) * (1 + 0.15*cb.Tint),
+	}
+	var luts [3][256]uint8
+	for c := range luts {
+		for v := range luts[c] {
+			luts[c][v] = clamp8(float64(v) * gains[c])
+		}
+	}
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		p := img.Pix[i : i+3 : i+3]
+		p[0], p[1], p[2] = luts[0][p[0]], luts[1][p[1]], luts[2][p[2]]
+	}
+}
+
+// Vignette darkens frames towards the edges by Strength, from 0 to 1: the
+// center keeps its brightness and the corners get black at full strength.
+type Vignette struct {
+	Strength float64
+}
+
+func (v Vignette) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	cx, cy := float64(w)/2, float64(h)/2
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			dy := (float64(y) + 0.5 - cy) / cy
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				dx := (float64(x) + 0.5 - cx) / cx
+				// squared distance from the center, 1 in the corners
+				f := int(256 * (1 - v.Strength*(dx*dx+dy*dy)/2))
+				p := row[4*x : 4*x+3 : 4*x+3]
+				p[0], p[1], p[2] = uint8(int(p[0])*f>>8), uint8(int(p[1])*f>>8), uint8(int(p[2])*f>>8)
+			}
+		}
+	})
+}
+
+// Blur averages every pixel with its neighbors up to Radius pixels away,
+// in two box blur passes.
+type Blur struct {
+	Radius int
+}
+
+func (bl Blur) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return
+	}
+	tmp := make([]byte, 4*w*h)
+
+	// horizontal pass into tmp
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			src := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):][:4*w]
+			boxBlur(tmp[4*w*y:4*w*(y+1)], src, w, 4, bl.Radius)
+		}
+	})
+	// vertical pass back into img
+	parallel.Rows(w, func(x0, x1 int) {
+		col := make([]byte, 4*h)
+		out := make([]byte, 4*h)
+		for x := x0; x < x1; x++ {
+			for y := range h {
+				copy(col[4*y:4*y+4], tmp[4*(w*y+x):])
+			}
+			boxBlur(out, col, h, 4, bl.Radius)
+			for y := range h {
+				copy(img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):][:4], out[4*y:])
+			}
+		}
+	})
+}
+
+// Pixelate replaces every Size×Size block of the Rect part of frames with
+// its average color. An empty Rect pixelates the whole frame.
+type Pixelate struct {
+	Rect image.Rectangle
+	Size int
+}
+
+func (px Pixelate) Apply(img *image.RGBA) {
+	r := px.Rect
+	if r.Empty() {
+		r = img.Bounds()
+	}
+	r = r.Intersect(img.Bounds())
+	n := max(1, px.Size)
+	for y0 := r.Min.Y; y0 < r.Max.Y; y0 += n {
+		y1 := min(y0+n, r.Max.Y)
+		for x0 := r.Min.X; x0 < r.Max.X; x0 += n {
+			x1 := min(x0+n, r.Max.X)
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := img.Pix[img.PixOffset(x0, y):img.PixOffset(x1, y)]
+				for i, v := range row {
+					sum[i%4] += int(v)
+				}
+			}
+			count := (x1 - x0) * (y1 - y0)
+			for y := y0; y < y1; y++ {
+				row := img.Pix[img.PixOffset(x0, y):img.PixOffset(x1, y)]
+				for i := range row {
+					row[i] = uint8(sum[i%4] / count)
+				}
+			}
+		}
+	}
+}
+
+// Fill paints the Rect part of frames in Color.
+type Fill struct {
+	Rect  image.Rectangle
+	Color color.RGBA
+}
+
+func (fl Fill) Apply(img *image.RGBA) {
+	r := fl.Rect.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for i := 0; i+3 < len(row); i += 4 {
+			row[i], row[i+1], row[i+2], row[i+3] = fl.Color.R, fl.Color.G, fl.Color.B, fl.Color.A
+		}
+	}
+}
+
+// boxBlur writes the running average of the n pixels of src, which are
+// stride bytes apart, over a window of r pixels to either side into dst.
+func boxBlur(dst, src []byte, n, stride, r int) {
+	for c := range stride {
+		sum, count := 0, 0
+		for i := range min(r, n) {
+			sum += int(src[i*stride+c])
+			count++
+		}
+		for i := range n {
+			if j := i + r; j < n {
+				sum += int(src[j*stride+c])
+				count++
+			}
+			if j := i - r - 1; j >= 0 {
+				sum -= int(src[j*stride+c])
+				count--
+			}
+			dst[i*stride+c] = uint8((sum + count/2) / count)
+		}
+	}
+}
+
+// applyLUT maps the color channels of every pixel through lut.
+func applyLUT(img *image.RGBA, lut *[256]uint8) {
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		p := img.Pix[i : i+3 : i+3]
+		p[0], p[1], p[2] = lut[p[0]], lut[p[1]], lut[p[2]]
+	}
+}
+
+func clamp8(v float64) uint8 {
+	return uint8(max(0, min(255, v+0.5)))
+}
@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"image"
+	"math"
+	"slices"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Dim scales the color of every pixel in img by f.
+func Dim(img *image.RGBA, f float64) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = uint8(float64(img.Pix[i]) * f)
+		img.Pix[i+1] = uint8(float64(img.Pix[i+1]) * f)
+		img.Pix[i+2] = uint8(float64(img.Pix[i+2]) * f)
+	}
+}
+
+// Lab is a color in CIE L*a*b* space.
+type Lab struct {
+	L, A, B float64
+}
+
+// LabPlane converts every pixel of img to Lab, reusing dst.
+func LabPlane(dst []Lab, img *image.RGBA) []Lab {
+	b := img.Bounds()
+	w := b.Dx()
+	dst = slices.Grow(dst[:0], w*b.Dy())[:w*b.Dy()]
+	parallel.Rows(b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+				c := &dst[y*w+x]
+				c.L, c.A, c.B = pixColor(img.Pix[i : i+4]).Lab()
+			}
+		}
+	})
+	return dst
+}
+
+// distance returns the euclidean distance between c and the Lab color
+// (l, a, b), the same as colorful's DistanceLab.
+func (c Lab) distance(l, a, b float64) float64 {
+	return math.Sqrt(sq(c.L-l) + sq(c.A-a) + sq(c.B-b))
+}
+
+func sq(v float64) float64 {
+	return v * v
+}
+
+// Greenscreen makes every pixel of img transparent whose color is within
+// dist of the same pixel of the background, given as its Lab plane.
+func Greenscreen(img *image.RGBA, bg []Lab, dist float64) {
+	b := img.Bounds()
+	if len(bg) != b.Dx()*b.Dy() {
+		return
+	}
+
+	parallel.Rows(b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < b.Dx(); x++ {
+				i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+				if bg[y*b.Dx()+x].distance(pixColor(img.Pix[i:i+4]).Lab()) < dist {
+					clear(img.Pix[i : i+4])
+				}
+			}
+		}
+	})
+}
+
+// KeyDistances returns the distance of every pixel of img from the same
+// pixel of the background, given as its Lab plane, reusing dst. Greenscreen
+// keys out the pixels closer than its dist, so the distances tell how a
+// frame is keyed at any threshold.
+func KeyDistances(dst []float64, img *image.RGBA, bg []Lab) []float64 {
+	b := img.Bounds()
+	w := b.Dx()
+	dst = slices.Grow(dst[:0], w*b.Dy())[:w*b.Dy()]
+	if len(bg) != len(dst) {
+		return dst
+	}
+	parallel.Rows(b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+				dst[y*w+x] = bg[y*w+x].distance(pixColor(img.Pix[i : i+4]).Lab())
+			}
+		}
+	})
+	return dst
+}
+
+// ChromaKey makes every pixel of img transparent whose color is within
+// dist of key.
+func ChromaKey(img *image.RGBA, key colorful.Color, dist float64) {
+	var k Lab
+	k.L, k.A, k.B = key.Lab()
+	b := img.Bounds()
+	parallel.Rows(b.Dy(), func(y0, y1 int) {
+		for y := b.Min.Y + y0; y < b.Min.Y+y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+			for i := 0; i+3 < len(row); i += 4 {
+				if k.distance(pixColor(row[i:i+4]).Lab()) < dist {
+					clear(row[i : i+4])
+				}
+			}
+		}
+	})
+}
+
+// pixColor returns the color of a single RGBA pixel.
+func pixColor(p []byte) colorful.Color {
+	return colorful.Color{R: float64(p[0]) / 255, G: float64(p[1]) / 255, B: float64(p[2]) / 255}
+}
@@ -0,0 +1,169 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"slices"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Motion finds the moving parts of frames by comparing each frame with the
+// one before. Pixels whose luma changed by more than Threshold move, and a
+// majority vote over their neighbors removes isolated noise.
+type Motion struct {
+	Threshold int
+
+	prev  []uint8 // luma of the previous frame
+	moved []bool  // pixels that moved, before the vote
+	mask  []bool  // pixels that moved
+	size  image.Point
+	level float64
+}
+
+// Detect compares img with the previous frame and returns the share of
+// pixels that moved, from 0 to 1. The first frame and frames of another
+// size than the previous one don't move.
+func (m *Motion) Detect(img *image.RGBA) float64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if b.Size() != m.size {
+		m.size = b.Size()
+		m.prev = slices.Grow(m.prev[:0], w*h)[:w*h]
+		m.moved = slices.Grow(m.moved[:0], w*h)[:w*h]
+		m.mask = slices.Grow(m.mask[:0], w*h)[:w*h]
+		clear(m.mask)
+		m.lumaInto(img)
+		m.level = 0
+		return 0
+	}
+
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				l := luma(row[4*x : 4*x+3])
+				d := int(l) - int(m.prev[y*w+x])
+				m.moved[y*w+x] = d > m.Threshold || -d > m.Threshold
+				m.prev[y*w+x] = l
+			}
+		}
+	})
+
+	// at least 5 of the 3×3 pixels around a pixel have to move
+	counts := make([]int, h)
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := range w {
+				n := 0
+				for yy := max(0, y-1); yy <= min(h-1, y+1); yy++ {
+					for xx := max(0, x-1); xx <= min(w-1, x+1); xx++ {
+						if m.moved[yy*w+xx] {
+							n++
+						}
+					}
+				}
+				m.mask[y*w+x] = n >= 5
+				if n >= 5 {
+					counts[y]++
+				}
+			}
+		}
+	})
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	m.level = float64(total) / float64(w*h)
+	return m.level
+}
+
+// Level returns the share of pixels that moved in the last frame.
+func (m *Motion) Level() float64 {
+	return m.level
+}
+
+// Center returns the center of the pixels that moved in the last frame,
+// relative to the frame size from 0 to 1. ok is false if none moved.
+func (m *Motion) Center() (x, y float64, ok bool) {
+	w := m.size.X
+	var sx, sy, n int
+	for i, moved := range m.mask {
+		if moved {
+			sx += i % w
+			sy += i / w
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return (float64(sx)/float64(n) + 0.5) / float64(w), (float64(sy)/float64(n) + 0.5) / float64(m.size.Y), true
+}
+
+// Tint blends the pixels of img that moved in the last frame with c by
+// amount, from 0 to 1. img has to be of the size of the detected frames.
+func (m *Motion) Tint(img *image.RGBA, c color.RGBA, amount float64) {
+	if img.Bounds().Size() != m.size {
+		return
+	}
+	a := int(amount * 256)
+	m.each(img, func(p []uint8, moved bool, _ bool) {
+		if moved {
+			p[0] = uint8((int(p[0])*(256-a) + int(c.R)*a) >> 8)
+			p[1] = uint8((int(p[1])*(256-a) + int(c.G)*a) >> 8)
+			p[2] = uint8((int(p[2])*(256-a) + int(c.B)*a) >> 8)
+			p[3] = 255
+		}
+	})
+}
+
+// Outline draws the border of the regions of img that moved in the last
+// frame in c. img has to be of the size of the detected frames.
+func (m *Motion) Outline(img *image.RGBA, c color.RGBA) {
+	if img.Bounds().Size() != m.size {
+		return
+	}
+	m.each(img, func(p []uint8, moved bool, edge bool) {
+		if moved && edge {
+			p[0], p[1], p[2], p[3] = c.R, c.G, c.B, 255
+		}
+	})
+}
+
+// each calls fn with every pixel of img, whether it moved and whether it
+// borders on a pixel that didn't.
+func (m *Motion) each(img *image.RGBA, fn func(p []uint8, moved, edge bool)) {
+	b := img.Bounds()
+	w, h := m.size.X, m.size.Y
+	still := func(x, y int) bool {
+		return x < 0 || y < 0 || x >= w || y >= h || !m.mask[y*w+x]
+	}
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				moved := m.mask[y*w+x]
+				edge := moved && (still(x-1, y) || still(x+1, y) || still(x, y-1) || still(x, y+1))
+				fn(row[4*x:4*x+4:4*x+4], moved, edge)
+			}
+		}
+	})
+}
+
+// lumaInto stores the luma of img as the previous frame.
+func (m *Motion) lumaInto(img *image.RGBA) {
+	b := img.Bounds()
+	w := b.Dx()
+	for y := range b.Dy() {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range w {
+			m.prev[y*w+x] = luma(row[4*x : 4*x+3])
+		}
+	}
+}
+
+// luma returns the ITU-R BT.601 luma of an RGB pixel.
+func luma(p []uint8) uint8 {
+	return uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000)
+}
@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"image"
+	"slices"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// NightTint is the coloring of a Night filter.
+type NightTint int
+
+const (
+	NightColor NightTint = iota // keep the colors
+	NightGreen                  // green like a night vision device
+	NightGray                   // gray
+)
+
+// nightTarget is the average luma automatic gain brightens frames to.
+const nightTarget = 110
+
+// Night makes dark frames usable: it averages every pixel over the recent
+// frames, which hides the sensor noise of low light, and brightens the
+// result. Static parts of the picture get clean; moving ones leave a short
+// trail, the longer the more Denoise.
+type Night struct {
+	// Gain multiplies the brightness, 0 picks a gain from the frame
+	// brightness, up to 8.
+	Gain float64
+	// Denoise is the weight of the previous frames in the average, from 0
+	// for no averaging to below 1.
+	Denoise float64
+	Tint    NightTint
+
+	avg  []float32 // running average of the channels
+	size image.Point
+	gain float64 // current automatic gain
+}
+
+func (n *Night) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if b.Size() != n.size {
+		n.size = b.Size()
+		n.avg = slices.Grow(n.avg[:0], 3*w*h)[:3*w*h]
+		for y := range h {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				for c := range 3 {
+					n.avg[3*(y*w+x)+c] = float32(row[4*x+c])
+				}
+			}
+		}
+	}
+
+	// average over time and measure the brightness of the result
+	d := float32(min(max(n.Denoise, 0), 0.99))
+	sums := make([]int, h)
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			avg := n.avg[3*y*w : 3*(y+1)*w]
+			for x := range w {
+				p := row[4*x : 4*x+3 : 4*x+3]
+				for c := range 3 {
+					avg[3*x+c] = avg[3*x+c]*d + float32(p[c])*(1-d)
+					p[c] = uint8(avg[3*x+c] + 0.5)
+				}
+				sums[y] += int(luma(p))
+			}
+		}
+	})
+
+	gain := n.Gain
+	if gain <= 0 {
+		total := 0
+		for _, s := range sums {
+			total += s
+		}
+		want := min(8, max(1, nightTarget/max(1, float64(total)/float64(w*h))))
+		if n.gain == 0 {
+			n.gain = want
+		}
+		// ease into the new gain so the picture doesn't pump
+		n.gain += (want - n.gain) * 0.1
+		gain = n.gain
+	}
+
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = clamp8(float64(v) * gain)
+	}
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				p := row[4*x : 4*x+3 : 4*x+3]
+				p[0], p[1], p[2] = lut[p[0]], lut[p[1]], lut[p[2]]
+				switch n.Tint {
+				case NightGreen:
+					l := luma(p)
+					p[0], p[1], p[2] = l/5, l, l/5
+				case NightGray:
+					l := luma(p)
+					p[0], p[1], p[2] = l, l, l
+				}
+			}
+		}
+	})
+}
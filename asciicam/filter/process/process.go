@@ -0,0 +1,226 @@
+// Package process runs filters as external programs, so effects can be
+// written in any language. Importing it registers the "exec" filter, e.g.
+// "exec=./myfilter", whose argument is the command line split at spaces.
+//
+// The program is started with the first frame and gets the frames on its
+// standard input, each as a 16 byte header followed by the pixels:
+//
+//	"ACFR"             magic
+//	uint32 width       big endian
+//	uint32 height      big endian
+//	uint32 frame       big endian, counting from 0
+//	width*height*3     RGB bytes, row by row
+//
+// It answers every frame on its standard output in the same format and
+// size, before it is sent the next one. It may start answering before it
+// read the whole frame. Alpha is not sent and kept, pixels removed by the
+// greenscreen are black. The program ends when its standard input is
+// closed. Lines it writes to standard error are logged. A program that
+// doesn't answer a frame within the filter's Timeout is killed.
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+)
+
+func init() {
+	filter.Register("exec", func(arg string) (filter.Filter, error) {
+		args := strings.Fields(arg)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("exec filter needs a command, e.g. exec=./myfilter")
+		}
+		return New(args[0], args[1:]...), nil
+	})
+}
+
+// magic starts the header of every frame.
+const magic = "ACFR"
+
+// headerSize is the size of a frame header in bytes.
+const headerSize = 16
+
+// DefaultTimeout is the Timeout of new filters.
+const DefaultTimeout = 5 * time.Second
+
+// Filter is a filter implemented by an external program. It is not safe
+// for concurrent use, every pipeline starts its own.
+type Filter struct {
+	// Timeout is the time the program has to answer a frame, and to end
+	// once its standard input is closed, before it is killed. 0 waits
+	// forever.
+	Timeout time.Duration
+
+	name   string
+	args   []string
+	cmd    *exec.Cmd
+	w      io.WriteCloser
+	r      *bufio.Reader
+	stderr *io.PipeWriter
+	out    []byte // header and pixels of the frame sent
+	in     []byte // header and pixels of the answer
+	frames uint32
+	failed bool // the program failed and is skipped
+}
+
+// New returns a filter running the program name with args. The program is
+// started by the first call to Apply.
+func New(name string, args ...string) *Filter {
+	return &Filter{Timeout: DefaultTimeout, name: name, args: args}
+}
+
+// Apply sends img to the program and replaces its pixels with the answer.
+// If the program fails, the error is logged, the program is stopped and
+// skipped from then on.
+func (f *Filter) Apply(img *image.RGBA) {
+	if f.failed {
+		return
+	}
+	err := f.apply(img)
+	if err != nil {
+		f.failed = true
+		slog.Error("filter program failed, skipping it", "cmd", f.name, "err", err)
+		_ = f.Close()
+	}
+}
+
+func (f *Filter) apply(img *image.RGBA) error {
+	if f.cmd == nil {
+		if err := f.start(); err != nil {
+			return err
+		}
+	}
+
+	b := img.Rect
+	w, h := b.Dx(), b.Dy()
+	n := headerSize + 3*w*h
+	if cap(f.out) < n {
+		f.out, f.in = make([]byte, n), make([]byte, n)
+	}
+	out, in := f.out[:n], f.in[:n]
+	copy(out, magic)
+	binary.BigEndian.PutUint32(out[4:], uint32(w))
+	binary.BigEndian.PutUint32(out[8:], uint32(h))
+	binary.BigEndian.PutUint32(out[12:], f.frames)
+	f.frames++
+
+	px := out[headerSize:]
+	for y := range h {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range w {
+			copy(px[3*(y*w+x):], row[4*x:4*x+3])
+		}
+	}
+
+	// a hung program is killed, which fails the reads and the write
+	var killed atomic.Bool
+	if f.Timeout > 0 {
+		proc := f.cmd.Process
+		timer := time.AfterFunc(f.Timeout, func() {
+			killed.Store(true)
+			_ = proc.Kill()
+		})
+		defer timer.Stop()
+	}
+
+	// the frame is written while the answer is read, programs streaming
+	// their answer would block on a full pipe otherwise
+	written := make(chan error, 1)
+	go func() {
+		_, err := f.w.Write(out)
+		written <- err
+	}()
+	err := f.readAnswer(in, w, h)
+	if werr := <-written; err == nil {
+		err = werr
+	}
+	if killed.Load() {
+		return fmt.Errorf("no answer within %v", f.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	px = in[headerSize:]
+	for y := range h {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range w {
+			copy(row[4*x:4*x+3], px[3*(y*w+x):])
+		}
+	}
+	return nil
+}
+
+// readAnswer reads the answer to a w×h frame into buf.
+func (f *Filter) readAnswer(buf []byte, w, h int) error {
+	// the answer has to match the frame that was sent
+	if _, err := io.ReadFull(f.r, buf[:headerSize]); err != nil {
+		return err
+	}
+	if string(buf[:4]) != magic {
+		return errors.New("invalid frame header")
+	}
+	if rw, rh := binary.BigEndian.Uint32(buf[4:]), binary.BigEndian.Uint32(buf[8:]); rw != uint32(w) || rh != uint32(h) {
+		return fmt.Errorf("got a %d×%d frame for a %d×%d one", rw, rh, w, h)
+	}
+	_, err := io.ReadFull(f.r, buf[headerSize:])
+	return err
+}
+
+// start starts the program.
+func (f *Filter) start() error {
+	cmd := exec.Command(f.name, f.args...)
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	cmd.Stderr = pw
+	cmd.WaitDelay = f.Timeout
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		s := bufio.NewScanner(pr)
+		for s.Scan() {
+			slog.Info("filter program", "cmd", f.name, "msg", string(bytes.TrimSpace(s.Bytes())))
+		}
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	f.cmd, f.w, f.r, f.stderr = cmd, w, bufio.NewReader(r), pw
+	return nil
+}
+
+// Close closes the standard input of the program and waits for it to end,
+// killing it after the Timeout.
+func (f *Filter) Close() error {
+	if f.cmd == nil {
+		return nil
+	}
+	cmd := f.cmd
+	f.cmd = nil
+	_ = f.w.Close()
+	if f.Timeout > 0 {
+		timer := time.AfterFunc(f.Timeout, func() { _ = cmd.Process.Kill() })
+		defer timer.Stop()
+	}
+	err := cmd.Wait()
+	_ = f.stderr.Close()
+	return err
+}
@@ -0,0 +1,51 @@
+package process
+
+import (
+	"image"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestFilterStreamsLargeFrames(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("no cat")
+	}
+	// far more than a pipe buffer, which cat answers while being sent it
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	want := append([]uint8(nil), img.Pix...)
+
+	f := New("cat")
+	f.Timeout = 10 * time.Second
+	defer f.Close()
+	for range 3 {
+		f.Apply(img)
+	}
+	if f.failed {
+		t.Fatal("filter failed")
+	}
+	for i := range want {
+		if img.Pix[i] != want[i] {
+			t.Fatalf("pixel byte %d = %d, want %d", i, img.Pix[i], want[i])
+		}
+	}
+}
+
+func TestFilterKillsHungProgram(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep")
+	}
+	f := New("sleep", "60")
+	f.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	f.Apply(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	if !f.failed {
+		t.Fatal("hung program not skipped")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("took %v to give up", d)
+	}
+}
@@ -0,0 +1,104 @@
+// Package filter crops, scales and keys captured frames before they are
+// rendered.
+package filter
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// CropRect returns the part of bounds that is visible at the given zoom
+// level around the relative center (cx, cy). The center is clamped so the
+// crop window stays inside bounds and the clamped values are returned.
+func CropRect(bounds image.Rectangle, zoom, cx, cy float64) (image.Rectangle, float64, float64) {
+	half := 0.5 / zoom
+	cx = math.Min(math.Max(cx, half), 1-half)
+	cy = math.Min(math.Max(cy, half), 1-half)
+
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	x0 := bounds.Min.X + int((cx-half)*w)
+	y0 := bounds.Min.Y + int((cy-half)*h)
+	x1 := x0 + max(1, int(w/zoom))
+	y1 := y0 + max(1, int(h/zoom))
+
+	return image.Rect(x0, y0, x1, y1).Intersect(bounds), cx, cy
+}
+
+// Scaler resizes frames into a reused destination image. Every
+// destination pixel is the average of the source pixels it covers, which
+// goes straight from the camera resolution to the terminal grid in one
+// pass; when enlarging, source pixels are repeated. The column spans are
+// only recomputed when the source or destination width changes.
+type Scaler struct {
+	dst  *image.RGBA
+	cols []int       // source column span of each destination column, start and end interleaved
+	sw   int         // source width cols were computed for
+	tmp  *image.RGBA // src converted to RGBA if it is of another type
+}
+
+// Image returns the result of the last call to Scale.
+func (s *Scaler) Image() *image.RGBA {
+	return s.dst
+}
+
+// Scale resizes the sr part of src to width×height. The returned image is
+// overwritten by the next call.
+func (s *Scaler) Scale(src image.Image, sr image.Rectangle, width, height uint) *image.RGBA {
+	dr := image.Rect(0, 0, int(width), int(height))
+	if s.dst == nil || s.dst.Rect != dr {
+		s.dst = image.NewRGBA(dr)
+		s.cols = nil
+	}
+	if sr.Empty() || dr.Empty() {
+		return s.dst
+	}
+
+	img, ok := src.(*image.RGBA)
+	if !ok {
+		if s.tmp == nil || s.tmp.Rect != sr {
+			s.tmp = image.NewRGBA(sr)
+		}
+		draw.Draw(s.tmp, sr, src, sr.Min, draw.Src)
+		img = s.tmp
+	}
+
+	w, h := dr.Dx(), dr.Dy()
+	if s.cols == nil || s.sw != sr.Dx() {
+		s.sw = sr.Dx()
+		s.cols = s.cols[:0]
+		for x := range w {
+			x0 := x * sr.Dx() / w
+			s.cols = append(s.cols, x0, max(x0+1, (x+1)*sr.Dx()/w))
+		}
+	}
+
+	parallel.Rows(h, func(r0, r1 int) {
+		for y := r0; y < r1; y++ {
+			y0 := sr.Min.Y + y*sr.Dy()/h
+			y1 := max(y0+1, sr.Min.Y+(y+1)*sr.Dy()/h)
+			out := s.dst.Pix[y*s.dst.Stride : y*s.dst.Stride+4*w]
+			for x := range w {
+				x0, x1 := sr.Min.X+s.cols[2*x], sr.Min.X+s.cols[2*x+1]
+				var r, g, b, a uint32
+				for sy := y0; sy < y1; sy++ {
+					row := img.Pix[img.PixOffset(x0, sy):img.PixOffset(x1, sy)]
+					for i := 0; i < len(row); i += 4 {
+						r += uint32(row[i])
+						g += uint32(row[i+1])
+						b += uint32(row[i+2])
+						a += uint32(row[i+3])
+					}
+				}
+				n := uint32((x1 - x0) * (y1 - y0))
+				out[4*x] = uint8((r + n/2) / n)
+				out[4*x+1] = uint8((g + n/2) / n)
+				out[4*x+2] = uint8((b + n/2) / n)
+				out[4*x+3] = uint8((a + n/2) / n)
+			}
+		}
+	})
+	return s.dst
+}
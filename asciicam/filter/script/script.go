@@ -0,0 +1,185 @@
+// Package script runs filters written in Lua. Importing it registers the
+// "script" filter, e.g. "script=effects/sepia.lua".
+//
+// A script defines a pixel function, a frame function, or both. pixel is
+// called for every pixel with its channels and position and returns the new
+// channels, alpha may be left out to keep it:
+//
+//	function pixel(r, g, b, a, x, y)
+//		local l = (r + g + b) / 3
+//		return l, l * 0.9, l * 0.7
+//	end
+//
+// frame is called once per frame, before the pixel function, with the frame
+// as an object with width(), height(), get(x, y) returning r, g, b, a and
+// set(x, y, r, g, b[, a]). Channels are 0-255 and coordinates start at 0.
+// The global frame_number counts the frames the script has seen.
+//
+// Only the base, table, string and math libraries are available.
+package script
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func init() {
+	filter.Register("script", func(arg string) (filter.Filter, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("script filter needs a file, e.g. script=effect.lua")
+		}
+		return Load(arg)
+	})
+}
+
+// frameType is the name of the metatable of frame objects.
+const frameType = "frame"
+
+// Filter is a filter implemented by a Lua script. It is not safe for
+// concurrent use, every pipeline loads its own.
+type Filter struct {
+	path   string
+	l      *lua.LState
+	pixel  *lua.LFunction
+	frame  *lua.LFunction
+	img    *image.RGBA // frame being filtered
+	frames int
+	failed bool // the script raised an error and is skipped
+}
+
+// Load loads the script at path.
+func Load(path string) (*Filter, error) {
+	l := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		l.Push(l.NewFunction(lib.open))
+		l.Push(lua.LString(lib.name))
+		l.Call(1, 0)
+	}
+
+	f := &Filter{path: path, l: l}
+	mt := l.NewTypeMetatable(frameType)
+	l.SetField(mt, "__index", l.SetFuncs(l.NewTable(), map[string]lua.LGFunction{
+		"width":  func(l *lua.LState) int { l.Push(lua.LNumber(f.img.Rect.Dx())); return 1 },
+		"height": func(l *lua.LState) int { l.Push(lua.LNumber(f.img.Rect.Dy())); return 1 },
+		"get":    f.get,
+		"set":    f.set,
+	}))
+
+	if err := l.DoFile(path); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to load filter script: %w", err)
+	}
+	f.pixel, _ = l.GetGlobal("pixel").(*lua.LFunction)
+	f.frame, _ = l.GetGlobal("frame").(*lua.LFunction)
+	if f.pixel == nil && f.frame == nil {
+		l.Close()
+		return nil, fmt.Errorf("filter script %s defines neither pixel nor frame", path)
+	}
+	return f, nil
+}
+
+// Apply runs the script on img. If the script fails, the error is logged
+// and the script is skipped from then on.
+func (f *Filter) Apply(img *image.RGBA) {
+	if f.failed {
+		return
+	}
+	f.img = img
+	f.l.SetGlobal("frame_number", lua.LNumber(f.frames))
+	f.frames++
+
+	if err := f.apply(); err != nil {
+		f.failed = true
+		slog.Error("filter script failed, skipping it", "script", f.path, "err", err)
+	}
+}
+
+func (f *Filter) apply() error {
+	l := f.l
+	if f.frame != nil {
+		ud := l.NewUserData()
+		ud.Value = f.img
+		l.SetMetatable(ud, l.GetTypeMetatable(frameType))
+		if err := l.CallByParam(lua.P{Fn: f.frame, Protect: true}, ud); err != nil {
+			return err
+		}
+	}
+	if f.pixel == nil {
+		return nil
+	}
+
+	b := f.img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := f.img.Pix[f.img.PixOffset(b.Min.X, y):]
+		for x := range b.Dx() {
+			p := row[x*4 : x*4+4 : x*4+4]
+			err := l.CallByParam(lua.P{Fn: f.pixel, NRet: 4, Protect: true},
+				lua.LNumber(p[0]), lua.LNumber(p[1]), lua.LNumber(p[2]), lua.LNumber(p[3]),
+				lua.LNumber(x), lua.LNumber(y-b.Min.Y))
+			if err != nil {
+				return err
+			}
+			// returned values are at the top of the stack, missing ones are nil
+			for i := range 4 {
+				if v, ok := l.Get(i - 4).(lua.LNumber); ok {
+					p[i] = channel(v)
+				}
+			}
+			l.Pop(4)
+		}
+	}
+	return nil
+}
+
+// get implements frame:get(x, y).
+func (f *Filter) get(l *lua.LState) int {
+	x, y, ok := f.point(l)
+	if !ok {
+		return 0
+	}
+	p := f.img.Pix[f.img.PixOffset(x, y):]
+	for i := range 4 {
+		l.Push(lua.LNumber(p[i]))
+	}
+	return 4
+}
+
+// set implements frame:set(x, y, r, g, b[, a]).
+func (f *Filter) set(l *lua.LState) int {
+	x, y, ok := f.point(l)
+	if !ok {
+		return 0
+	}
+	p := f.img.Pix[f.img.PixOffset(x, y):]
+	for i := range 4 {
+		if v, ok := l.Get(4 + i).(lua.LNumber); ok {
+			p[i] = channel(v)
+		}
+	}
+	return 0
+}
+
+// point returns the frame coordinates of the x, y arguments and whether
+// they are inside the frame.
+func (f *Filter) point(l *lua.LState) (int, int, bool) {
+	x, y := l.CheckInt(2), l.CheckInt(3)
+	pt := f.img.Rect.Min.Add(image.Pt(x, y))
+	return pt.X, pt.Y, pt.In(f.img.Rect)
+}
+
+// channel clamps a script value to a channel value.
+func channel(v lua.LNumber) uint8 {
+	return uint8(max(0, min(255, float64(v)+0.5)))
+}
@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"image"
+	"math"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Trails blends every frame with the Frames frames before it, each
+// weighing less than the one after it, which leaves fading ghosts behind
+// whatever moves. The oldest frame weighs a tenth of the newest.
+type Trails struct {
+	Frames int
+
+	ring [][]uint8 // the recent frames, oldest at next once full
+	next int
+	n    int
+	size image.Point
+}
+
+func (t *Trails) Apply(img *image.RGBA) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if b.Size() != t.size || len(t.ring) != t.Frames+1 {
+		t.size = b.Size()
+		t.ring = make([][]uint8, t.Frames+1)
+		t.next, t.n = 0, 0
+	}
+
+	// keep a copy of the frame
+	cur := t.ring[t.next]
+	if len(cur) != 4*w*h {
+		cur = make([]uint8, 4*w*h)
+		t.ring[t.next] = cur
+	}
+	for y := range h {
+		copy(cur[4*w*y:4*w*(y+1)], img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):])
+	}
+	t.next = (t.next + 1) % len(t.ring)
+	t.n = min(t.n+1, len(t.ring))
+
+	// weights of the stored frames, newest first
+	decay := math.Pow(0.1, 1/float64(max(1, t.Frames)))
+	weights := make([]float64, t.n)
+	frames := make([][]uint8, t.n)
+	total := 0.0
+	for k := range t.n {
+		weights[k] = math.Pow(decay, float64(k))
+		frames[k] = t.ring[(t.next-1-k+2*len(t.ring))%len(t.ring)]
+		total += weights[k]
+	}
+	for k := range weights {
+		weights[k] /= total
+	}
+
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for i := range 4 * w {
+				v := 0.0
+				for k, f := range frames {
+					v += weights[k] * float64(f[4*w*y+i])
+				}
+				row[i] = uint8(v + 0.5)
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package filter
+
+import (
+	"image"
+	"math"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Kaleido turns frames into a kaleidoscope: the wedge of the picture right
+// of the center is mirrored around it Segments times.
+type Kaleido struct {
+	Segments int
+}
+
+func (k Kaleido) Apply(img *image.RGBA) {
+	wedge := 2 * math.Pi / float64(max(1, k.Segments))
+	remap(img, func(x, y, cx, cy float64) (float64, float64) {
+		dx, dy := x-cx, y-cy
+		r := math.Hypot(dx, dy)
+		// fold the angle into the first wedge, every other one mirrored
+		a := math.Mod(math.Atan2(dy, dx)+2*math.Pi, 2*math.Pi)
+		n := math.Floor(a / wedge)
+		a -= n * wedge
+		if int(n)%2 == 1 {
+			a = wedge - a
+		}
+		a -= wedge / 2
+		return cx + r*math.Cos(a), cy + r*math.Sin(a)
+	})
+}
+
+// Mirror4 mirrors the top left quarter of frames into the other three.
+type Mirror4 struct{}
+
+func (Mirror4) Apply(img *image.RGBA) {
+	remap(img, func(x, y, cx, cy float64) (float64, float64) {
+		if x > cx {
+			x = 2*cx - x
+		}
+		if y > cy {
+			y = 2*cy - y
+		}
+		return x, y
+	})
+}
+
+// Stretch is a funhouse mirror: it blows up the center of frames by
+// Amount, from 0 to 1, squeezing the picture around it.
+type Stretch struct {
+	Amount float64
+}
+
+func (s Stretch) Apply(img *image.RGBA) {
+	remap(img, func(x, y, cx, cy float64) (float64, float64) {
+		dx, dy := (x-cx)/cx, (y-cy)/cy
+		r := math.Hypot(dx, dy)
+		if r >= 1 || r == 0 {
+			return x, y
+		}
+		f := math.Pow(r, s.Amount)
+		return cx + dx*f*cx, cy + dy*f*cy
+	})
+}
+
+// remap replaces every pixel of img with the pixel at the position fn
+// returns for it, nearest neighbor. fn gets pixel centers relative to the
+// top left corner and the center of the frame; positions outside the
+// frame are clamped to its edges.
+func remap(img *image.RGBA, fn func(x, y, cx, cy float64) (float64, float64)) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return
+	}
+	src := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		copy(src.Pix[src.PixOffset(b.Min.X, y):src.PixOffset(b.Max.X, y)], img.Pix[img.PixOffset(b.Min.X, y):])
+	}
+	cx, cy := float64(w)/2, float64(h)/2
+
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				sx, sy := fn(float64(x)+0.5, float64(y)+0.5, cx, cy)
+				ix := min(max(int(sx), 0), w-1)
+				iy := min(max(int(sy), 0), h-1)
+				copy(row[4*x:4*x+4], src.Pix[src.PixOffset(b.Min.X+ix, b.Min.Y+iy):])
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"image"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// WhiteBalance removes color casts, such as the orange of light bulbs or
+// the blue of daylight, by scaling the red and blue channels until the
+// reference part of the frame averages to gray. Without a Patch that is the
+// whole frame ("gray world"), with one it is a part of the picture known
+// to be white or gray. The correction follows changes in the lighting
+// over a few frames, so it doesn't flicker.
+type WhiteBalance struct {
+	// Patch is the reference part of the frame, the whole frame if empty.
+	Patch image.Rectangle
+
+	gains [3]float64 // current red, green and blue gains, zero before the first frame
+}
+
+// wbMaxGain limits the correction of a channel, so a picture that is
+// mostly one color keeps it.
+const wbMaxGain = 2
+
+func (wb *WhiteBalance) Apply(img *image.RGBA) {
+	r := img.Bounds()
+	if !wb.Patch.Empty() {
+		r = wb.Patch.Intersect(r)
+	}
+	if r.Empty() {
+		return
+	}
+
+	// average color of the reference, without transparent pixels
+	sums := make([][4]int, r.Dy())
+	parallel.Rows(r.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(r.Min.X, r.Min.Y+y):img.PixOffset(r.Max.X, r.Min.Y+y)]
+			for i := 0; i+3 < len(row); i += 4 {
+				if row[i+3] == 0 {
+					continue
+				}
+				sums[y][0] += int(row[i])
+				sums[y][1] += int(row[i+1])
+				sums[y][2] += int(row[i+2])
+				sums[y][3]++
+			}
+		}
+	})
+	var sum [4]int
+	for _, s := range sums {
+		for c := range s {
+			sum[c] += s[c]
+		}
+	}
+	if sum[3] == 0 || sum[0] == 0 || sum[1] == 0 || sum[2] == 0 {
+		return
+	}
+
+	// scale red and blue to the green average
+	for c := range 3 {
+		want := min(wbMaxGain, max(1.0/wbMaxGain, float64(sum[1])/float64(sum[c])))
+		if wb.gains[c] == 0 {
+			wb.gains[c] = want
+		}
+		wb.gains[c] += (want - wb.gains[c]) * 0.1
+	}
+
+	var luts [3][256]uint8
+	for c := range luts {
+		for v := range luts[c] {
+			luts[c][v] = clamp8(float64(v) * wb.gains[c])
+		}
+	}
+	b := img.Bounds()
+	parallel.Rows(b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):img.PixOffset(b.Max.X, b.Min.Y+y)]
+			for i := 0; i+3 < len(row); i += 4 {
+				row[i], row[i+1], row[i+2] = luts[0][row[i]], luts[1][row[i+1]], luts[2][row[i+2]]
+			}
+		}
+	})
+}
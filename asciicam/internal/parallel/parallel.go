@@ -0,0 +1,64 @@
+// Package parallel splits per-row image work across goroutines.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// rowBuffers recycles the output buffers of Render between frames.
+var rowBuffers = sync.Pool{New: func() any { return new([]byte) }}
+
+// Rows splits rows into contiguous bands and calls fn for each band
+// [y0, y1) concurrently on up to GOMAXPROCS workers. It returns when all
+// bands are done.
+func Rows(rows int, fn func(y0, y1 int)) {
+	workers := max(1, min(runtime.GOMAXPROCS(0), rows))
+	band := (rows + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for y := 0; y < rows; y += band {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(y, min(rows, y+band))
+		}()
+	}
+	wg.Wait()
+}
+
+// Render builds the output of a frame by calling line for each of the
+// given rows; line appends the row to b and returns the extended slice.
+// The rows are split into contiguous chunks that are rendered concurrently
+// by up to GOMAXPROCS workers and joined in order.
+func Render(rows int, line func(b []byte, row int) []byte) []byte {
+	workers := max(1, min(runtime.GOMAXPROCS(0), rows))
+	chunk := (rows + workers - 1) / workers
+
+	parts := make([]*[]byte, workers)
+	var wg sync.WaitGroup
+	for w := range parts {
+		parts[w] = rowBuffers.Get().(*[]byte)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := (*parts[w])[:0]
+			for row := w * chunk; row < min(rows, (w+1)*chunk); row++ {
+				b = line(b, row)
+			}
+			*parts[w] = b
+		}()
+	}
+	wg.Wait()
+
+	size := 0
+	for _, p := range parts {
+		size += len(*p)
+	}
+	b := make([]byte, 0, size)
+	for _, p := range parts {
+		b = append(b, *p...)
+		rowBuffers.Put(p)
+	}
+	return b
+}
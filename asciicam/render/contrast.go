@@ -0,0 +1,100 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// ContrastPixels is the ramp of the contrast mode: few characters far
+// apart in weight, so neighboring levels stay distinct when blurred by a
+// projector, a video call or poor eyesight.
+var ContrastPixels = []rune(" :+#@")
+
+// contrastClip is the share of the darkest and the brightest pixels the
+// contrast mode stretches the brightness past, and contrastSpan the least
+// brightness range it stretches to the full ramp, so flat pictures don't
+// turn their noise into characters.
+const (
+	contrastClip = 0.02
+	contrastSpan = 64
+)
+
+// Contrast renders in two colors only: bold characters of ContrastPixels
+// in bright white, or Color, on black. The brightness of every frame is
+// stretched to the whole ramp first.
+type Contrast struct {
+	Options
+}
+
+func (r Contrast) Render(img image.Image, w, h int) []byte {
+	src := rgba(img)
+	b := src.Bounds()
+	w, h = min(w, b.Dx()), min(h, b.Dy())
+
+	luma := make([]uint8, w*h)
+	var hist [256]int
+	for y := range h {
+		row := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range w {
+			p := row[4*x : 4*x+4 : 4*x+4]
+			l := uint8((299*int32(p[0]) + 587*int32(p[1]) + 114*int32(p[2])) * int32(p[3]) / (1000 * 255))
+			luma[y*w+x] = l
+			hist[l]++
+		}
+	}
+	lo, hi := contrastLevels(&hist, w*h)
+
+	fg := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	if r.Color.A > 0 {
+		fg = r.Color
+	}
+	var style []byte
+	if r.Profile != termenv.Ascii {
+		style = append([]byte(termenv.CSI+"1;"), appendColor(nil, r.Profile, fg, false)...)
+		style = append(style, ';')
+		style = appendColor(style, r.Profile, color.RGBA{0, 0, 0, 0xff}, true)
+		style = append(style, 'm')
+	}
+
+	last := len(ContrastPixels) - 1
+	return parallel.Render(h, func(buf []byte, y int) []byte {
+		buf = append(buf, style...)
+		for x := range w {
+			v := (int(luma[y*w+x]) - lo) * last
+			v = min(last, max(0, (v+(hi-lo)/2)/(hi-lo)))
+			buf = utf8.AppendRune(buf, ContrastPixels[v])
+		}
+		if style != nil {
+			buf = append(buf, sgrReset...)
+		}
+		return append(buf, '\n')
+	})
+}
+
+// contrastLevels returns the brightness the darkest and the brightest
+// contrastClip of the n pixels of hist lie beyond, at least contrastSpan
+// apart.
+func contrastLevels(hist *[256]int, n int) (lo, hi int) {
+	clip := int(float64(n) * contrastClip)
+	for sum := 0; lo < 255; lo++ {
+		if sum += hist[lo]; sum > clip {
+			break
+		}
+	}
+	hi = 255
+	for sum := 0; hi > 0; hi-- {
+		if sum += hist[hi]; sum > clip {
+			break
+		}
+	}
+	if hi-lo < contrastSpan {
+		mid := (lo + hi) / 2
+		lo = min(max(0, mid-contrastSpan/2), 255-contrastSpan)
+		hi = lo + contrastSpan
+	}
+	return lo, hi
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"unicode/utf8"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// edgeGain scales the gradient magnitude before it picks a character, so
+// the softer edges of scaled down frames still show. Magnitudes below
+// edgeFloor, out of 255, are noise and left blank.
+const (
+	edgeGain  = 2
+	edgeFloor = 32
+)
+
+// Edges renders the outlines of frames: a Sobel filter measures how fast
+// the brightness changes at every pixel and the magnitude picks the
+// character of the ramp, flat areas get the darkest one. Cells are colored
+// like the picture, in Color, or with Hue by the direction of the edge.
+// Mostly blank rows make its output cheap to diff and stream.
+type Edges struct {
+	Options
+	Hue bool
+}
+
+func (r Edges) Render(img image.Image, w, h int) []byte {
+	src := rgba(img)
+	b := src.Bounds()
+	w, h = min(w, b.Dx()), min(h, b.Dy())
+
+	luma := make([]int32, w*h)
+	parallel.Rows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := range w {
+				p := row[4*x : 4*x+4 : 4*x+4]
+				luma[y*w+x] = (299*int32(p[0]) + 587*int32(p[1]) + 114*int32(p[2])) * int32(p[3]) / (1000 * 255)
+			}
+		}
+	})
+	at := func(x, y int) int32 {
+		return luma[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+
+	last := len(r.Pixels) - 1
+	return parallel.Render(h, func(buf []byte, y int) []byte {
+		for x := range w {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			mag := math.Hypot(float64(gx), float64(gy))/4*edgeGain - edgeFloor
+			v := min(last, int(math.Ceil(mag*float64(last)/(255-edgeFloor))))
+			if v <= 0 {
+				buf = utf8.AppendRune(buf, r.Pixels[0])
+				continue
+			}
+
+			var fg color.RGBA
+			switch {
+			case r.Color.A > 0:
+				fg = r.Color
+			case r.Hue:
+				fg = hue(math.Atan2(float64(gy), float64(gx)))
+			default:
+				fg = src.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			}
+			buf = appendCell(buf, r.Profile, r.Pixels[v], fg)
+		}
+		return append(buf, '\n')
+	})
+}
+
+// hue returns the fully saturated color of the angle a in radians, red at
+// 0 going through yellow, green, cyan, blue and magenta.
+func hue(a float64) color.RGBA {
+	h := math.Mod(a/(2*math.Pi)+1, 1) * 6
+	f := h - math.Floor(h)
+	up, down := uint8(255*f), uint8(255*(1-f))
+	switch int(h) % 6 {
+	case 0:
+		return color.RGBA{255, up, 0, 255}
+	case 1:
+		return color.RGBA{down, 255, 0, 255}
+	case 2:
+		return color.RGBA{0, 255, up, 255}
+	case 3:
+		return color.RGBA{0, down, 255, 255}
+	case 4:
+		return color.RGBA{up, 0, 255, 255}
+	}
+	return color.RGBA{255, 0, down, 255}
+}
@@ -0,0 +1,206 @@
+package render_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// goldenCam and goldenOut are the camera and terminal sizes of the golden
+// frames.
+var (
+	goldenCam = image.Pt(64, 36)
+	goldenOut = image.Pt(32, 12)
+)
+
+// goldenCase produces the output of one golden file.
+type goldenCase struct {
+	name   string // file name in testdata/golden
+	output func() []byte
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// TestGolden feeds fixed synthetic frames through the converters, filters
+// and renderers and compares the output with the golden files, so changes
+// to the pixel math show up. With -update the golden files are rewritten
+// instead.
+func TestGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "golden")
+	if *update {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range goldenCases() {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, c.name)
+			got := c.output()
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line, ok := firstDiff(got, want); !ok {
+				t.Errorf("%s differs from line %d, rerun with -update if the change is intended", path, line)
+			}
+		})
+	}
+}
+
+// goldenCases returns the golden cases, each fed with the same frames.
+func goldenCases() []goldenCase {
+	yuyv := goldenYUYV(goldenCam.X, goldenCam.Y)
+	rgb := goldenRGB(goldenCam.X, goldenCam.Y)
+	w, h := uint(goldenOut.X), uint(goldenOut.Y)
+
+	frame := image.NewRGBA(image.Rect(0, 0, goldenCam.X, goldenCam.Y))
+	source.YUYVToRGBA(frame, yuyv)
+	// scaled returns frame scaled for a render mode, with filters applied
+	scaled := func(m render.Mode, f filter.Filter) *image.RGBA {
+		var s filter.Scaler
+		img := s.Scale(frame, frame.Rect, w*m.CellW, h*m.CellH)
+		if f != nil {
+			f.Apply(img)
+		}
+		return img
+	}
+	renderMode := func(name string, o render.Options, f filter.Filter) []byte {
+		i, _ := render.Index(name)
+		m := render.Modes()[i]
+		return m.New(o).Render(scaled(m, f), int(w), int(h))
+	}
+	pixels := render.Charsets[0].Pixels
+
+	cases := []goldenCase{
+		{"yuyv-to-rgba.txt", func() []byte { return hexDump(frame.Pix) }},
+		{"rgb-to-rgba.txt", func() []byte {
+			img := image.NewRGBA(frame.Rect)
+			source.RGBToRGBA(img, rgb)
+			return hexDump(img.Pix)
+		}},
+		{"scale.txt", func() []byte { return hexDump(scaled(render.Mode{CellW: 1, CellH: 1}, nil).Pix) }},
+		{"yuyv-fast-path.txt", func() []byte {
+			return render.YUYV(yuyv, goldenCam.X, frame.Rect, w, h, render.Options{Profile: termenv.Ascii, Pixels: pixels})
+		}},
+	}
+	for _, m := range render.Modes() {
+		o := render.Options{Profile: termenv.TrueColor, Pixels: pixels}
+		cases = append(cases, goldenCase{m.Name + ".ans", func() []byte { return renderMode(m.Name, o, nil) }})
+	}
+	for _, p := range []struct {
+		name    string
+		profile termenv.Profile
+	}{{"ansi256", termenv.ANSI256}, {"ansi16", termenv.ANSI}} {
+		o := render.Options{Profile: p.profile, Pixels: pixels}
+		cases = append(cases, goldenCase{"ansi-" + p.name + ".ans", func() []byte { return renderMode("ansi", o, nil) }})
+	}
+	for _, cs := range render.Charsets {
+		o := render.Options{Profile: termenv.Ascii, Pixels: cs.Pixels}
+		cases = append(cases, goldenCase{"charset-" + cs.Name + ".txt", func() []byte { return renderMode("ascii", o, nil) }})
+	}
+
+	o := render.Options{Profile: termenv.TrueColor, Pixels: pixels}
+	ansi, _ := render.Index("ansi")
+	chain, _ := filter.Parse("mirror,blur=1,contrast=1.5,brightness=0.1")
+	cases = append(cases,
+		goldenCase{"filters.ans", func() []byte { return renderMode("ansi", o, chain) }},
+		goldenCase{"chroma-key.ans", func() []byte {
+			// key against a color from the top left of the frame
+			key, _ := colorful.MakeColor(frame.At(4, 4))
+			return renderMode("ansi", o, filter.Key{Color: key, Dist: 0.13})
+		}},
+		goldenCase{"greenscreen.ans", func() []byte {
+			// the background matches the left half of the frame
+			bg := image.NewRGBA(frame.Rect)
+			copy(bg.Pix, frame.Pix)
+			for y := range goldenCam.Y {
+				clear(bg.Pix[bg.PixOffset(goldenCam.X/2, y):bg.PixOffset(goldenCam.X, y)])
+			}
+			m := render.Modes()[ansi]
+			var s filter.Scaler
+			plane := filter.LabPlane(nil, s.Scale(bg, bg.Rect, w*m.CellW, h*m.CellH))
+			return renderMode("ansi", o, filter.Background{Plane: plane, Dist: 0.13})
+		}},
+	)
+	return cases
+}
+
+// goldenYUYV returns a YUYV frame with horizontal luma and vertical chroma
+// gradients.
+func goldenYUYV(w, h int) []byte {
+	b := make([]byte, w*h*2)
+	for y := range h {
+		for x := 0; x < w; x += 2 {
+			i := (y*w + x) * 2
+			b[i] = uint8(16 + 219*x/w)
+			b[i+1] = uint8(255 * y / h)
+			b[i+2] = uint8(16 + 219*(x+1)/w)
+			b[i+3] = uint8(255 - 255*y/h)
+		}
+	}
+	return b
+}
+
+// goldenRGB returns an RGB frame of eight vertical color bars over a gray
+// ramp.
+func goldenRGB(w, h int) []byte {
+	bars := [8][3]uint8{{255, 255, 255}, {255, 255, 0}, {0, 255, 255}, {0, 255, 0}, {255, 0, 255}, {255, 0, 0}, {0, 0, 255}, {0, 0, 0}}
+	b := make([]byte, w*h*3)
+	for y := range h {
+		for x := range w {
+			c := bars[x*8/w]
+			if y >= h*3/4 {
+				v := uint8(255 * x / w)
+				c = [3]uint8{v, v, v}
+			}
+			copy(b[(y*w+x)*3:], c[:])
+		}
+	}
+	return b
+}
+
+// hexDump formats pixel data as hex, one pixel per word and 16 pixels per
+// line, so diffs point at the pixels that changed.
+func hexDump(pix []byte) []byte {
+	var b bytes.Buffer
+	for i := 0; i < len(pix); i += 4 {
+		fmt.Fprintf(&b, "%02x%02x%02x%02x", pix[i], pix[i+1], pix[i+2], pix[i+3])
+		if i/4%16 == 15 {
+			b.WriteByte('\n')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.Bytes()
+}
+
+// firstDiff compares a and b and returns the 1-based number of the first
+// line that differs.
+func firstDiff(a, b []byte) (int, bool) {
+	if bytes.Equal(a, b) {
+		return 0, true
+	}
+	al, bl := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	for i := range min(len(al), len(bl)) {
+		if !bytes.Equal(al[i], bl[i]) {
+			return i + 1, false
+		}
+	}
+	return min(len(al), len(bl)) + 1, false
+}
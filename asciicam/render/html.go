@@ -0,0 +1,151 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// HTML converts text with SGR color sequences into HTML with colored
+// spans, to be shown in a pre element with a black background and light
+// gray text. Other escape sequences are dropped.
+func HTML(s string) string {
+	var b strings.Builder
+
+	var fg, bg string
+	var reverse bool
+	open := false
+	for len(s) > 0 {
+		i := strings.Index(s, "\x1b[")
+		if i != 0 {
+			text := s
+			if i > 0 {
+				text = s[:i]
+			}
+			s = s[len(text):]
+
+			f, g := fg, bg
+			if reverse {
+				f, g = g, f
+				if f == "" {
+					f = "#000"
+				}
+				if g == "" {
+					g = "#ccc"
+				}
+			}
+			if f != "" || g != "" {
+				b.WriteString("<span style=\"")
+				if f != "" {
+					b.WriteString("color:" + f + ";")
+				}
+				if g != "" {
+					b.WriteString("background:" + g + ";")
+				}
+				b.WriteString("\">")
+				open = true
+			}
+			b.WriteString(html.EscapeString(text))
+			if open {
+				b.WriteString("</span>")
+				open = false
+			}
+			continue
+		}
+
+		// parse the CSI sequence, only SGR (m) sequences are interpreted
+		end := strings.IndexFunc(s[2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+		if end < 0 {
+			break
+		}
+		params, final := s[2:2+end], s[2+end]
+		s = s[3+end:]
+		if final != 'm' {
+			continue
+		}
+		fg, bg, reverse = applySGR(params, fg, bg, reverse)
+	}
+
+	return b.String()
+}
+
+// applySGR updates the current colors with the given SGR parameters.
+func applySGR(params, fg, bg string, reverse bool) (string, string, bool) {
+	p := strings.Split(params, ";")
+	for i := 0; i < len(p); i++ {
+		n, _ := strconv.Atoi(p[i])
+		switch {
+		case n == 0:
+			fg, bg, reverse = "", "", false
+		case n == 7:
+			reverse = true
+		case n == 27:
+			reverse = false
+		case n == 39:
+			fg = ""
+		case n == 49:
+			bg = ""
+		case n >= 30 && n <= 37:
+			fg = xtermColor(n - 30)
+		case n >= 40 && n <= 47:
+			bg = xtermColor(n - 40)
+		case n >= 90 && n <= 97:
+			fg = xtermColor(n - 90 + 8)
+		case n >= 100 && n <= 107:
+			bg = xtermColor(n - 100 + 8)
+		case (n == 38 || n == 48) && i+1 < len(p):
+			var c string
+			switch p[i+1] {
+			case "5":
+				if i+2 < len(p) {
+					idx, _ := strconv.Atoi(p[i+2])
+					c = xtermColor(idx)
+				}
+				i += 2
+			case "2":
+				if i+4 < len(p) {
+					r, _ := strconv.Atoi(p[i+2])
+					g, _ := strconv.Atoi(p[i+3])
+					b, _ := strconv.Atoi(p[i+4])
+					c = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+				}
+				i += 4
+			}
+			if n == 38 {
+				fg = c
+			} else {
+				bg = c
+			}
+		}
+	}
+	return fg, bg, reverse
+}
+
+// ansi16 are the xterm default colors for the first 16 palette entries.
+var ansi16 = []string{
+	"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+	"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// xtermColor returns the hex color of an xterm 256 color palette index.
+func xtermColor(i int) string {
+	switch {
+	case i < 0 || i > 255:
+		return ""
+	case i < 16:
+		return ansi16[i]
+	case i < 232:
+		i -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(i/36), level(i/6%6), level(i%6))
+	default:
+		v := 8 + (i-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
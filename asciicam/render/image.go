@@ -0,0 +1,199 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ImageCellW and ImageCellH are the size in pixels of a cell in the
+// pictures Image draws, that of the 7x13 font of basicfont.
+const (
+	ImageCellW = 7
+	ImageCellH = 13
+)
+
+// Image draws text with SGR color sequences onto a picture the way a
+// terminal with the default colors fg and bg would show it, an
+// ImageCellW×ImageCellH cell per character. Block elements, shades and
+// braille patterns are drawn as shapes, so the ansi, blocks and braille
+// output looks right; ASCII comes from basicfont, other characters are
+// drawn as a replacement box. Other escape sequences are dropped, a cursor
+// home starts over in the top left cell.
+func Image(s string, fg, bg color.RGBA) *image.RGBA {
+	cols, rows := 0, 0
+	walkCells(s, func(_ rune, col, row int, _, _ string, _ bool) {
+		cols, rows = max(cols, col+1), max(rows, row+1)
+	})
+	img := image.NewRGBA(image.Rect(0, 0, cols*ImageCellW, rows*ImageCellH))
+	draw.Draw(img, img.Rect, image.NewUniform(bg), image.Point{}, draw.Src)
+
+	d := &font.Drawer{Dst: img, Face: basicfont.Face7x13}
+	walkCells(s, func(r rune, col, row int, f, g string, reverse bool) {
+		cf, cg := hexColor(f, fg), hexColor(g, bg)
+		if reverse {
+			cf, cg = cg, cf
+		}
+		cell := image.Rect(col*ImageCellW, row*ImageCellH, (col+1)*ImageCellW, (row+1)*ImageCellH)
+		drawGlyph(d, img, cell, r, cf, cg)
+	})
+	return img
+}
+
+// walkCells calls cell for every character of s with its position and
+// SGR colors, "" for the default ones.
+func walkCells(s string, cell func(r rune, col, row int, fg, bg string, reverse bool)) {
+	var fg, bg string
+	var reverse bool
+	col, row := 0, 0
+	for len(s) > 0 {
+		i := strings.Index(s, "\x1b[")
+		if i != 0 {
+			text := s
+			if i > 0 {
+				text = s[:i]
+			}
+			s = s[len(text):]
+			for _, r := range text {
+				switch {
+				case r == '\n':
+					col, row = 0, row+1
+				case r == '\r':
+					col = 0
+				case r >= ' ' && r != 0x7f:
+					cell(r, col, row, fg, bg, reverse)
+					col++
+				}
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(s[2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+		if end < 0 {
+			break
+		}
+		params, final := s[2:2+end], s[2+end]
+		s = s[3+end:]
+		switch final {
+		case 'm':
+			fg, bg, reverse = applySGR(params, fg, bg, reverse)
+		case 'H':
+			if params == "" {
+				col, row = 0, 0
+			}
+		}
+	}
+}
+
+// hexColor parses a color of applySGR, def if it is "".
+func hexColor(s string, def color.RGBA) color.RGBA {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return def
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+}
+
+// quadrants are the quadrants U+2596 to U+259F cover, as bits for the
+// upper left, upper right, lower left and lower right one.
+var quadrants = [10]uint8{0b0010, 0b0001, 0b1000, 0b1011, 0b1001, 0b1110, 0b1101, 0b0100, 0b0110, 0b0111}
+
+// drawGlyph draws r into cell.
+func drawGlyph(d *font.Drawer, img *image.RGBA, cell image.Rectangle, r rune, fg, bg color.RGBA) {
+	draw.Draw(img, cell, image.NewUniform(bg), image.Point{}, draw.Src)
+	w, h := cell.Dx(), cell.Dy()
+	x0, y0 := cell.Min.X, cell.Min.Y
+	fill := func(x1, y1, x2, y2 int) {
+		draw.Draw(img, image.Rect(x0+x1, y0+y1, x0+x2, y0+y2), image.NewUniform(fg), image.Point{}, draw.Src)
+	}
+	switch {
+	case r == ' ':
+	case r < 0x7f:
+		d.Src = image.NewUniform(fg)
+		d.Dot = fixed.P(x0, y0+basicfont.Face7x13.Ascent)
+		d.DrawString(string(r))
+	case r == '▀':
+		fill(0, 0, w, h/2)
+	case r >= '▁' && r <= '█':
+		fill(0, h-int(r-'▀')*h/8, w, h)
+	case r >= '▉' && r <= '▏':
+		fill(0, 0, int('▐'-r)*w/8, h)
+	case r == '▐':
+		fill(w/2, 0, w, h)
+	case r >= '░' && r <= '▓':
+		a := int(r-'░'+1) * 64
+		draw.Draw(img, cell, image.NewUniform(blend(fg, bg, a)), image.Point{}, draw.Src)
+	case r == '▔':
+		fill(0, 0, w, h/8)
+	case r == '▕':
+		fill(w-w/8, 0, w, h)
+	case r >= '▖' && r <= '▟':
+		xs, ys := [3]int{0, w / 2, w}, [3]int{0, h / 2, h}
+		for i := range 4 {
+			if quadrants[r-'▖']&(0b1000>>i) != 0 {
+				fill(xs[i%2], ys[i/2], xs[i%2+1], ys[i/2+1])
+			}
+		}
+	case r >= 0x2800 && r <= 0x28ff:
+		for y, row := range brailleDots {
+			for x, bit := range row {
+				if (r-0x2800)&bit != 0 {
+					cx, cy := (2*x+1)*w/4, (2*y+1)*h/8
+					fill(cx-1, cy-1, cx+1, cy+1)
+				}
+			}
+		}
+	case r == '·' || r == '•' || r == '●':
+		radius := 1
+		switch r {
+		case '•':
+			radius = 2
+		case '●':
+			radius = 3
+		}
+		cx, cy := w/2, h/2
+		for y := -radius; y <= radius; y++ {
+			for x := -radius; x <= radius; x++ {
+				if x*x+y*y <= radius*radius {
+					fill(cx+x, cy+y, cx+x+1, cy+y+1)
+				}
+			}
+		}
+	case r == '─' || r == '━':
+		fill(0, h/2, w, h/2+1)
+	case r == '│' || r == '┃':
+		fill(w/2, 0, w/2+1, h)
+	case r == '┌' || r == '┐' || r == '└' || r == '┘':
+		left, top := r == '┐' || r == '┘', r == '└' || r == '┘'
+		if left {
+			fill(0, h/2, w/2+1, h/2+1)
+		} else {
+			fill(w/2, h/2, w, h/2+1)
+		}
+		if top {
+			fill(w/2, 0, w/2+1, h/2+1)
+		} else {
+			fill(w/2, h/2, w/2+1, h)
+		}
+	default:
+		d.Src = image.NewUniform(fg)
+		d.Dot = fixed.P(x0, y0+basicfont.Face7x13.Ascent)
+		d.DrawString("�")
+	}
+}
+
+// blend mixes fg over bg with alpha a from 0 to 256.
+func blend(fg, bg color.RGBA, a int) color.RGBA {
+	mix := func(f, b uint8) uint8 { return uint8((int(f)*a + int(b)*(256-a)) >> 8) }
+	return color.RGBA{mix(fg.R, bg.R), mix(fg.G, bg.G), mix(fg.B, bg.B), 0xff}
+}
@@ -0,0 +1,230 @@
+// Package render converts frames into text for the terminal.
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// Options control how frames are rendered.
+type Options struct {
+	Profile termenv.Profile
+	Pixels  []rune     // character ramp, ordered from dark to bright
+	Color   color.RGBA // single color for all cells, unused if alpha is 0
+}
+
+// Renderer converts a frame into w×h cells of terminal output, one line
+// per row. The frame has to be scaled to the cell size of the mode the
+// renderer was created for.
+type Renderer interface {
+	Render(img image.Image, w, h int) []byte
+}
+
+// Mode is a render mode that can be selected by name.
+type Mode struct {
+	Name         string
+	CellW, CellH uint // image pixels that make up a single terminal cell
+	New          func(o Options) Renderer
+}
+
+var modes []Mode
+
+func init() {
+	Register(Mode{"ascii", 1, 1, func(o Options) Renderer { return ASCII{o} }})
+	Register(Mode{"ansi", 1, 2, func(o Options) Renderer { return ANSI{o} }})
+	Register(Mode{"braille", 2, 4, func(o Options) Renderer { return Braille{o} }})
+	Register(Mode{"mono", 1, 1, func(o Options) Renderer {
+		o.Profile = termenv.Ascii
+		return ASCII{o}
+	}})
+	Register(Mode{"edges", 1, 1, func(o Options) Renderer { return Edges{Options: o} }})
+	Register(Mode{"edges-hue", 1, 1, func(o Options) Renderer { return Edges{Options: o, Hue: true} }})
+	Register(Mode{"contrast", 1, 1, func(o Options) Renderer { return Contrast{o} }})
+	Register(Mode{"sixel", SixelCellW, SixelCellH, func(Options) Renderer { return Sixel{} }})
+}
+
+// Register adds m to the available modes, replacing a mode of the same
+// name.
+func Register(m Mode) {
+	if i, err := Index(m.Name); err == nil {
+		modes[i] = m
+		return
+	}
+	modes = append(modes, m)
+}
+
+// Modes returns the available modes in the order they were registered.
+func Modes() []Mode {
+	return modes
+}
+
+// Index returns the index of the named mode in Modes.
+func Index(name string) (int, error) {
+	for i, m := range modes {
+		if m.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown render mode %q", name)
+}
+
+// rgba returns img as *image.RGBA, converting it if it is of another type.
+func rgba(img image.Image) *image.RGBA {
+	if m, ok := img.(*image.RGBA); ok {
+		return m
+	}
+	m := image.NewRGBA(img.Bounds())
+	draw.Draw(m, m.Rect, img, m.Rect.Min, draw.Src)
+	return m
+}
+
+// Charset is a named character ramp.
+type Charset struct {
+	Name   string
+	Pixels []rune
+}
+
+// Charsets are the available character ramps, ordered from dark to bright.
+var Charsets = []Charset{
+	{"default", []rune(" .,:;i1tfLCG08@")},
+	{"simple", []rune(" .:-=+*#%@")},
+	{"detailed", []rune(" .'`^\",:;Il!i><~+_-?][}{1)(|\\/tfjrxnuvczXYUJCLQ0OZmwqpdbkhao*#MW&8%B@$")},
+	{"blocks", []rune(" ░▒▓█")},
+	{"dots", []rune(" ·•●")},
+	{"binary", []rune(" @")},
+}
+
+// RegisterCharset adds a ramp to Charsets, replacing a charset of the same
+// name. It fails on ramps of less than two characters.
+func RegisterCharset(name string, pixels []rune) error {
+	if len(pixels) < 2 {
+		return fmt.Errorf("charset %q needs at least two characters", name)
+	}
+	if i, err := CharsetIndex(name); err == nil {
+		Charsets[i].Pixels = pixels
+		return nil
+	}
+	Charsets = append(Charsets, Charset{name, pixels})
+	return nil
+}
+
+// CharsetIndex returns the index of the named charset.
+func CharsetIndex(name string) (int, error) {
+	for i, c := range Charsets {
+		if c.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown charset %q", name)
+}
+
+// PixelToASCII returns the character of pixels matching the brightness of
+// pixel.
+func PixelToASCII(pixel color.RGBA, pixels []rune) rune {
+	r := uint(pixel.R)
+	g := uint(pixel.G)
+	b := uint(pixel.B)
+	a := uint(pixel.A)
+
+	intensity := (r + g + b) * a / 255
+	precision := float64(255*3) / float64(len(pixels)-1)
+
+	v := int(math.Floor(float64(intensity)/precision + 0.5))
+	return pixels[min(v, len(pixels)-1)]
+}
+
+// ASCII renders one character of the ramp per pixel.
+type ASCII struct {
+	Options
+}
+
+func (r ASCII) Render(img image.Image, w, h int) []byte {
+	src := rgba(img)
+	return parallel.Render(h, func(b []byte, i int) []byte {
+		for j := 0; j < w; j++ {
+			pixel := src.RGBAAt(j, i)
+			fg := pixel
+			if r.Color.A > 0 {
+				fg = r.Color
+			}
+			b = appendCell(b, r.Profile, PixelToASCII(pixel, r.Pixels), fg)
+		}
+		return append(b, '\n')
+	})
+}
+
+// ANSI renders two pixels per cell with colored upper half blocks.
+type ANSI struct {
+	Options
+}
+
+func (r ANSI) Render(img image.Image, _, _ int) []byte {
+	src := rgba(img)
+	bounds := src.Bounds()
+
+	return parallel.Render((bounds.Max.Y+1)/2, func(b []byte, row int) []byte {
+		y := row * 2
+		for x := 0; x < bounds.Max.X; x++ {
+			b = appendCellBg(b, r.Profile, '▀', src.RGBAAt(x, y), src.RGBAAt(x, y+1))
+		}
+		return append(b, '\n')
+	})
+}
+
+// brailleDots maps a pixel position within a 2x4 cell to its braille dot.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Braille renders 2x4 pixels per cell using braille patterns. A dot is set
+// for every pixel brighter than mid-gray and the cell is colored by the
+// average of its set pixels.
+type Braille struct {
+	Options
+}
+
+func (o Braille) Render(img image.Image, w, h int) []byte {
+	src := rgba(img)
+	return parallel.Render(h, func(buf []byte, cy int) []byte {
+		for cx := 0; cx < w; cx++ {
+			var dots rune
+			var r, g, b, n uint32
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					c := src.RGBAAt(cx*2+dx, cy*4+dy)
+					if c.A == 0 {
+						continue
+					}
+					pr, pg, pb := uint32(c.R), uint32(c.G), uint32(c.B)
+					if (299*pr+587*pg+114*pb)/1000 > 0x7f {
+						dots |= brailleDots[dy][dx]
+						r += pr
+						g += pg
+						b += pb
+						n++
+					}
+				}
+			}
+
+			switch {
+			case o.Color.A > 0:
+				buf = appendCell(buf, o.Profile, 0x2800+dots, o.Color)
+			case n > 0:
+				buf = appendCell(buf, o.Profile, 0x2800+dots, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 255})
+			default:
+				buf = utf8.AppendRune(buf, 0x2800+dots)
+			}
+		}
+		return append(buf, '\n')
+	})
+}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"image/color"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+)
+
+// sgrReset ends a colored cell.
+const sgrReset = "\x1b[0m"
+
+// sgrDecimal holds the decimal representation of every channel value so
+// true color sequences can be assembled without formatting.
+var sgrDecimal [256]string
+
+// sgrIndexed caches the foreground and background parameters of every
+// ANSI 256 palette entry; the first 16 entries use the short ANSI codes.
+var sgrIndexed [2][256]string
+
+func init() {
+	for i := range sgrDecimal {
+		sgrDecimal[i] = strconv.Itoa(i)
+	}
+	for bg := range 2 {
+		for i := range 256 {
+			sgrIndexed[bg][i] = termenv.ANSI256Color(i).Sequence(bg == 1)
+		}
+		for i := range 16 {
+			sgrIndexed[bg][i] = termenv.ANSIColor(i).Sequence(bg == 1)
+		}
+	}
+}
+
+// lutBits is the number of bits per channel the color lookup tables are
+// indexed with.
+const lutBits = 5
+
+// ansi256LUT and ansiLUT map colors quantized to lutBits per channel to
+// palette indices. They are built on first use.
+var (
+	ansi256LUT = sync.OnceValue(func() []uint8 { return buildLUT(termenv.ANSI256) })
+	ansiLUT    = sync.OnceValue(func() []uint8 { return buildLUT(termenv.ANSI) })
+)
+
+// buildLUT converts the center of every quantized color to the palette of
+// profile p.
+func buildLUT(p termenv.Profile) []uint8 {
+	const n = 1 << lutBits
+	const half = 1 << (7 - lutBits)
+	lut := make([]uint8, n*n*n)
+	for i := range lut {
+		c := color.RGBA{
+			R: uint8(i>>(2*lutBits))<<(8-lutBits) + half,
+			G: uint8(i>>lutBits%n)<<(8-lutBits) + half,
+			B: uint8(i%n)<<(8-lutBits) + half,
+			A: 255,
+		}
+		switch pc := p.FromColor(c).(type) {
+		case termenv.ANSI256Color:
+			lut[i] = uint8(pc)
+		case termenv.ANSIColor:
+			lut[i] = uint8(pc)
+		}
+	}
+	return lut
+}
+
+// lutIndex returns the lookup table index of c.
+func lutIndex(c color.RGBA) int {
+	const shift = 8 - lutBits
+	return int(c.R>>shift)<<(2*lutBits) | int(c.G>>shift)<<lutBits | int(c.B>>shift)
+}
+
+// appendColor appends the SGR parameters that select c as foreground or
+// background color in profile p.
+func appendColor(b []byte, p termenv.Profile, c color.RGBA, bg bool) []byte {
+	switch p {
+	case termenv.TrueColor:
+		if bg {
+			b = append(b, "48;2;"...)
+		} else {
+			b = append(b, "38;2;"...)
+		}
+		b = append(b, sgrDecimal[c.R]...)
+		b = append(b, ';')
+		b = append(b, sgrDecimal[c.G]...)
+		b = append(b, ';')
+		return append(b, sgrDecimal[c.B]...)
+	case termenv.ANSI256:
+		return append(b, sgrIndexed[btoi(bg)][ansi256LUT()[lutIndex(c)]]...)
+	case termenv.ANSI:
+		return append(b, sgrIndexed[btoi(bg)][ansiLUT()[lutIndex(c)]]...)
+	default:
+		return b
+	}
+}
+
+// appendCell appends r colored with fg. The cell is left uncolored for
+// profiles without colors.
+func appendCell(b []byte, p termenv.Profile, r rune, fg color.RGBA) []byte {
+	if p == termenv.Ascii {
+		return utf8.AppendRune(b, r)
+	}
+	b = append(b, termenv.CSI...)
+	b = appendColor(b, p, fg, false)
+	b = append(b, 'm')
+	b = utf8.AppendRune(b, r)
+	return append(b, sgrReset...)
+}
+
+// appendCellBg appends r colored with fg on bg.
+func appendCellBg(b []byte, p termenv.Profile, r rune, fg, bg color.RGBA) []byte {
+	if p == termenv.Ascii {
+		return utf8.AppendRune(b, r)
+	}
+	b = append(b, termenv.CSI...)
+	b = appendColor(b, p, fg, false)
+	b = append(b, ';')
+	b = appendColor(b, p, bg, true)
+	b = append(b, 'm')
+	b = utf8.AppendRune(b, r)
+	return append(b, sgrReset...)
+}
+
+// btoi returns 1 for true and 0 for false.
+func btoi(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
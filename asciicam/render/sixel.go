@@ -0,0 +1,184 @@
+package render
+
+import (
+	"image"
+	"strconv"
+	"strings"
+)
+
+// SixelCellW and SixelCellH are the pixel size of a terminal cell assumed
+// by the sixel mode unless SetSixelCell is called. Sixel images are drawn
+// at their pixel size, so the picture only fills the w×h cells on
+// terminals with about this font size.
+const (
+	SixelCellW = 8
+	SixelCellH = 16
+)
+
+// SetSixelCell sets the pixel size of a terminal cell the sixel mode
+// draws at, e.g. the one measured with term.CellSize, so the picture
+// fills its cells on any font size. It replaces the registered sixel mode
+// and must not be called while frames are rendered.
+func SetSixelCell(w, h uint) {
+	Register(Mode{"sixel", w, h, func(Options) Renderer { return Sixel{} }})
+}
+
+// sixelLevels is the number of levels per channel of the sixel palette.
+const sixelLevels = 6
+
+// graphicsStart starts the sequence that draws the sixel image.
+const graphicsStart = "\x1b7\x1b[H"
+
+// Sixel renders frames as sixel graphics with a 6×6×6 color cube palette.
+// Transparent pixels are left to the terminal background.
+//
+// The output consists of h lines of blanks followed by a sequence that
+// draws the image from the top left corner of the screen and returns the
+// cursor, see SplitGraphics. Drawing the image last keeps renderers that
+// erase the lines they write from wiping it, but it also covers text drawn
+// over the blanks. The image leaves out the last row, as drawing into it
+// would scroll the screen.
+type Sixel struct{}
+
+// SplitGraphics splits renderer output into its text lines and a trailing
+// graphics sequence, which has to be written after the text lines. The
+// graphics are empty for output of text renderers.
+func SplitGraphics(out string) (text, graphics string) {
+	i := strings.LastIndex(out, graphicsStart)
+	if i < 0 {
+		return out, ""
+	}
+	return out[:i], out[i:]
+}
+
+// graphicsEnd returns the cursor after the graphics sequence.
+const graphicsEnd = "\x1b8"
+
+// InlineGraphics turns renderer output into output that is drawn where
+// the cursor is, for writing a single picture into the scrollback or a
+// file rather than redrawing the screen. The graphics sequence is
+// stripped of its move to the top left corner and replaces the blanks,
+// and the terminal moves the cursor below the image. Output of text
+// renderers is returned as it is.
+func InlineGraphics(out string) string {
+	_, graphics := SplitGraphics(out)
+	if graphics == "" {
+		return out
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(graphics, graphicsStart), graphicsEnd)
+}
+
+func (Sixel) Render(img image.Image, w, h int) []byte {
+	src := rgba(img)
+	b := src.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if h > 1 {
+		height = (h - 1) * height / h
+	}
+
+	var out []byte
+	for y := range h {
+		if y > 0 {
+			out = append(out, '\n')
+		}
+		for range w {
+			out = append(out, ' ')
+		}
+	}
+	// save the cursor, draw at home, restore the cursor
+	out = append(out, graphicsStart...)
+	out = append(out, "\x1bP0;1;0q\"1;1;"...)
+	out = strconv.AppendInt(out, int64(width), 10)
+	out = append(out, ';')
+	out = strconv.AppendInt(out, int64(height), 10)
+
+	const colors = sixelLevels * sixelLevels * sixelLevels
+	var defined, seen [colors]bool
+	// sixel bits of every color in the current band, by column
+	masks := make([]byte, colors*width)
+	var used []int
+	for y0 := 0; y0 < height; y0 += 6 {
+		used = used[:0]
+		for dy := range min(6, height-y0) {
+			pix := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y0+dy):]
+			for x := range width {
+				p := pix[x*4 : x*4+4]
+				if p[3] == 0 {
+					continue
+				}
+				c := sixelLevel(p[0])*sixelLevels*sixelLevels + sixelLevel(p[1])*sixelLevels + sixelLevel(p[2])
+				if !seen[c] {
+					seen[c] = true
+					used = append(used, c)
+				}
+				masks[c*width+x] |= 1 << dy
+			}
+		}
+
+		for i, c := range used {
+			if i > 0 {
+				// back to the start of the band for the next color
+				out = append(out, '$')
+			}
+			out = append(out, '#')
+			out = strconv.AppendInt(out, int64(c), 10)
+			seen[c] = false
+			if !defined[c] {
+				defined[c] = true
+				out = appendSixelColor(out, c)
+			}
+
+			row := masks[c*width : (c+1)*width]
+			var prev byte
+			n := 0
+			for x, bits := range row {
+				if ch := '?' + bits; ch == prev {
+					n++
+				} else {
+					out = appendSixelRun(out, prev, n)
+					prev, n = ch, 1
+				}
+				row[x] = 0
+			}
+			if prev != '?' {
+				out = appendSixelRun(out, prev, n)
+			}
+		}
+		out = append(out, '-')
+	}
+	out = append(out, "\x1b\\"...)
+	return append(out, graphicsEnd...)
+}
+
+// sixelLevel quantizes a channel value to the palette levels.
+func sixelLevel(v uint8) int {
+	return (int(v)*(sixelLevels-1) + 127) / 255
+}
+
+// appendSixelColor appends the RGB definition of palette entry c, with
+// channels in percent.
+func appendSixelColor(b []byte, c int) []byte {
+	b = append(b, ";2"...)
+	for _, l := range [3]int{c / (sixelLevels * sixelLevels), c / sixelLevels % sixelLevels, c % sixelLevels} {
+		b = append(b, ';')
+		b = strconv.AppendInt(b, int64(l*100/(sixelLevels-1)), 10)
+	}
+	return b
+}
+
+// appendSixelRun appends n repetitions of the sixel ch.
+func appendSixelRun(b []byte, ch byte, n int) []byte {
+	switch {
+	case n == 0:
+		return b
+	case n > 3:
+		b = append(b, '!')
+		b = strconv.AppendInt(b, int64(n), 10)
+		return append(b, ch)
+	default:
+		for range n {
+			b = append(b, ch)
+		}
+		return b
+	}
+}
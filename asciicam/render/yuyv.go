@@ -0,0 +1,58 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/internal/parallel"
+)
+
+// YUYV renders the crop part of a YUYV frame that is stride pixels wide
+// into width×height cells of the character ramp, without converting it to
+// RGBA first. Every cell is the average of the pixels it covers; the
+// chroma planes are skipped when no colors are needed.
+func YUYV(yuyv []byte, stride int, crop image.Rectangle, width, height uint, o Options) []byte {
+	gray := o.Profile == termenv.Ascii || o.Color.A > 0
+	cols, rows := int(width), int(height)
+
+	return parallel.Render(rows, func(b []byte, cy int) []byte {
+		y0 := crop.Min.Y + cy*crop.Dy()/rows
+		y1 := max(y0+1, crop.Min.Y+(cy+1)*crop.Dy()/rows)
+		for cx := 0; cx < cols; cx++ {
+			x0 := crop.Min.X + cx*crop.Dx()/cols
+			x1 := max(x0+1, crop.Min.X+(cx+1)*crop.Dx()/cols)
+
+			var ys, cbs, crs, n int
+			for y := y0; y < y1; y++ {
+				row := yuyv[y*stride*2 : (y+1)*stride*2]
+				for x := x0; x < x1; x++ {
+					// pixel pairs are stored as Y0 Cb Y1 Cr
+					ys += int(row[x*2])
+					if !gray {
+						pair := (x &^ 1) * 2
+						cbs += int(row[pair+1])
+						crs += int(row[pair+3])
+					}
+					n++
+				}
+			}
+
+			var pixel color.RGBA
+			if gray {
+				v := uint8(ys / n)
+				pixel = color.RGBA{v, v, v, 255}
+			} else {
+				r, g, bl := color.YCbCrToRGB(uint8(ys/n), uint8(cbs/n), uint8(crs/n))
+				pixel = color.RGBA{r, g, bl, 255}
+			}
+
+			fg := pixel
+			if o.Color.A > 0 {
+				fg = o.Color
+			}
+			b = appendCell(b, o.Profile, PixelToASCII(pixel, o.Pixels), fg)
+		}
+		return append(b, '\n')
+	})
+}
@@ -0,0 +1,472 @@
+// Service of a running asciicam program, enabled with -grpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: asciicam.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StreamFramesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamFramesRequest) Reset() {
+	*x = StreamFramesRequest{}
+	mi := &file_asciicam_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamFramesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFramesRequest) ProtoMessage() {}
+
+func (x *StreamFramesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asciicam_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFramesRequest.ProtoReflect.Descriptor instead.
+func (*StreamFramesRequest) Descriptor() ([]byte, []int) {
+	return file_asciicam_proto_rawDescGZIP(), []int{0}
+}
+
+// Frame is a rendered frame.
+type Frame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Terminal output of the frame, rows separated by newlines, with the
+	// escape sequences of the render mode and color profile.
+	Text string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// Size in terminal cells.
+	Width  uint32 `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	// Capture time.
+	TimeUnixNano int64 `protobuf:"varint,4,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	// Number of the frame since the program started.
+	Number        uint64 `protobuf:"varint,5,opt,name=number,proto3" json:"number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_asciicam_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_asciicam_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_asciicam_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Frame) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Frame) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Frame) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Frame) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+func (x *Frame) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+type GetSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSettingsRequest) Reset() {
+	*x = GetSettingsRequest{}
+	mi := &file_asciicam_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSettingsRequest) ProtoMessage() {}
+
+func (x *GetSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asciicam_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSettingsRequest.ProtoReflect.Descriptor instead.
+func (*GetSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_asciicam_proto_rawDescGZIP(), []int{2}
+}
+
+// Settings are the runtime settings of asciicam.
+type Settings struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Output size in terminal cells.
+	Width  uint32 `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	// Greenscreen threshold.
+	Threshold float64 `protobuf:"fixed64,3,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// Render mode, one of modes.
+	Mode string `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Character ramp of the ascii and mono modes, one of charsets.
+	Charset string `protobuf:"bytes,5,opt,name=charset,proto3" json:"charset,omitempty"`
+	// Whether the greenscreen is on.
+	Greenscreen bool `protobuf:"varint,6,opt,name=greenscreen,proto3" json:"greenscreen,omitempty"`
+	// Available render modes and charsets.
+	Modes         []string `protobuf:"bytes,7,rep,name=modes,proto3" json:"modes,omitempty"`
+	Charsets      []string `protobuf:"bytes,8,rep,name=charsets,proto3" json:"charsets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_asciicam_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_asciicam_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_asciicam_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Settings) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Settings) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Settings) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Settings) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Settings) GetCharset() string {
+	if x != nil {
+		return x.Charset
+	}
+	return ""
+}
+
+func (x *Settings) GetGreenscreen() bool {
+	if x != nil {
+		return x.Greenscreen
+	}
+	return false
+}
+
+func (x *Settings) GetModes() []string {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
+}
+
+func (x *Settings) GetCharsets() []string {
+	if x != nil {
+		return x.Charsets
+	}
+	return nil
+}
+
+// UpdateSettingsRequest holds the settings to change, unset fields are
+// kept.
+type UpdateSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Width         *uint32                `protobuf:"varint,1,opt,name=width,proto3,oneof" json:"width,omitempty"`
+	Height        *uint32                `protobuf:"varint,2,opt,name=height,proto3,oneof" json:"height,omitempty"`
+	Threshold     *float64               `protobuf:"fixed64,3,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	Mode          *string                `protobuf:"bytes,4,opt,name=mode,proto3,oneof" json:"mode,omitempty"`
+	Charset       *string                `protobuf:"bytes,5,opt,name=charset,proto3,oneof" json:"charset,omitempty"`
+	Greenscreen   *bool                  `protobuf:"varint,6,opt,name=greenscreen,proto3,oneof" json:"greenscreen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateSettingsRequest) Reset() {
+	*x = UpdateSettingsRequest{}
+	mi := &file_asciicam_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSettingsRequest) ProtoMessage() {}
+
+func (x *UpdateSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asciicam_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSettingsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_asciicam_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateSettingsRequest) GetWidth() uint32 {
+	if x != nil && x.Width != nil {
+		return *x.Width
+	}
+	return 0
+}
+
+func (x *UpdateSettingsRequest) GetHeight() uint32 {
+	if x != nil && x.Height != nil {
+		return *x.Height
+	}
+	return 0
+}
+
+func (x *UpdateSettingsRequest) GetThreshold() float64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+func (x *UpdateSettingsRequest) GetMode() string {
+	if x != nil && x.Mode != nil {
+		return *x.Mode
+	}
+	return ""
+}
+
+func (x *UpdateSettingsRequest) GetCharset() string {
+	if x != nil && x.Charset != nil {
+		return *x.Charset
+	}
+	return ""
+}
+
+func (x *UpdateSettingsRequest) GetGreenscreen() bool {
+	if x != nil && x.Greenscreen != nil {
+		return *x.Greenscreen
+	}
+	return false
+}
+
+var File_asciicam_proto protoreflect.FileDescriptor
+
+const file_asciicam_proto_rawDesc = "" +
+	"\n" +
+	"\x0easciicam.proto\x12\vasciicam.v1\"\x15\n" +
+	"\x13StreamFramesRequest\"\x87\x01\n" +
+	"\x05Frame\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\rR\x06height\x12$\n" +
+	"\x0etime_unix_nano\x18\x04 \x01(\x03R\ftimeUnixNano\x12\x16\n" +
+	"\x06number\x18\x05 \x01(\x04R\x06number\"\x14\n" +
+	"\x12GetSettingsRequest\"\xd8\x01\n" +
+	"\bSettings\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\rR\x06height\x12\x1c\n" +
+	"\tthreshold\x18\x03 \x01(\x01R\tthreshold\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12\x18\n" +
+	"\acharset\x18\x05 \x01(\tR\acharset\x12 \n" +
+	"\vgreenscreen\x18\x06 \x01(\bR\vgreenscreen\x12\x14\n" +
+	"\x05modes\x18\a \x03(\tR\x05modes\x12\x1a\n" +
+	"\bcharsets\x18\b \x03(\tR\bcharsets\"\x99\x02\n" +
+	"\x15UpdateSettingsRequest\x12\x19\n" +
+	"\x05width\x18\x01 \x01(\rH\x00R\x05width\x88\x01\x01\x12\x1b\n" +
+	"\x06height\x18\x02 \x01(\rH\x01R\x06height\x88\x01\x01\x12!\n" +
+	"\tthreshold\x18\x03 \x01(\x01H\x02R\tthreshold\x88\x01\x01\x12\x17\n" +
+	"\x04mode\x18\x04 \x01(\tH\x03R\x04mode\x88\x01\x01\x12\x1d\n" +
+	"\acharset\x18\x05 \x01(\tH\x04R\acharset\x88\x01\x01\x12%\n" +
+	"\vgreenscreen\x18\x06 \x01(\bH\x05R\vgreenscreen\x88\x01\x01B\b\n" +
+	"\x06_widthB\t\n" +
+	"\a_heightB\f\n" +
+	"\n" +
+	"_thresholdB\a\n" +
+	"\x05_modeB\n" +
+	"\n" +
+	"\b_charsetB\x0e\n" +
+	"\f_greenscreen2\xe6\x01\n" +
+	"\bAsciicam\x12F\n" +
+	"\fStreamFrames\x12 .asciicam.v1.StreamFramesRequest\x1a\x12.asciicam.v1.Frame0\x01\x12E\n" +
+	"\vGetSettings\x12\x1f.asciicam.v1.GetSettingsRequest\x1a\x15.asciicam.v1.Settings\x12K\n" +
+	"\x0eUpdateSettings\x12\".asciicam.v1.UpdateSettingsRequest\x1a\x15.asciicam.v1.SettingsB7Z5github.com/ownerofglory/go-asciicam-demo/asciicam/rpcb\x06proto3"
+
+var (
+	file_asciicam_proto_rawDescOnce sync.Once
+	file_asciicam_proto_rawDescData []byte
+)
+
+func file_asciicam_proto_rawDescGZIP() []byte {
+	file_asciicam_proto_rawDescOnce.Do(func() {
+		file_asciicam_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_asciicam_proto_rawDesc), len(file_asciicam_proto_rawDesc)))
+	})
+	return file_asciicam_proto_rawDescData
+}
+
+var file_asciicam_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_asciicam_proto_goTypes = []any{
+	(*StreamFramesRequest)(nil),   // 0: asciicam.v1.StreamFramesRequest
+	(*Frame)(nil),                 // 1: asciicam.v1.Frame
+	(*GetSettingsRequest)(nil),    // 2: asciicam.v1.GetSettingsRequest
+	(*Settings)(nil),              // 3: asciicam.v1.Settings
+	(*UpdateSettingsRequest)(nil), // 4: asciicam.v1.UpdateSettingsRequest
+}
+var file_asciicam_proto_depIdxs = []int32{
+	0, // 0: asciicam.v1.Asciicam.StreamFrames:input_type -> asciicam.v1.StreamFramesRequest
+	2, // 1: asciicam.v1.Asciicam.GetSettings:input_type -> asciicam.v1.GetSettingsRequest
+	4, // 2: asciicam.v1.Asciicam.UpdateSettings:input_type -> asciicam.v1.UpdateSettingsRequest
+	1, // 3: asciicam.v1.Asciicam.StreamFrames:output_type -> asciicam.v1.Frame
+	3, // 4: asciicam.v1.Asciicam.GetSettings:output_type -> asciicam.v1.Settings
+	3, // 5: asciicam.v1.Asciicam.UpdateSettings:output_type -> asciicam.v1.Settings
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_asciicam_proto_init() }
+func file_asciicam_proto_init() {
+	if File_asciicam_proto != nil {
+		return
+	}
+	file_asciicam_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_asciicam_proto_rawDesc), len(file_asciicam_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_asciicam_proto_goTypes,
+		DependencyIndexes: file_asciicam_proto_depIdxs,
+		MessageInfos:      file_asciicam_proto_msgTypes,
+	}.Build()
+	File_asciicam_proto = out.File
+	file_asciicam_proto_goTypes = nil
+	file_asciicam_proto_depIdxs = nil
+}
@@ -0,0 +1,219 @@
+// Service of a running asciicam program, enabled with -grpc.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v5.29.3
+// source: asciicam.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Asciicam_StreamFrames_FullMethodName   = "/asciicam.v1.Asciicam/StreamFrames"
+	Asciicam_GetSettings_FullMethodName    = "/asciicam.v1.Asciicam/GetSettings"
+	Asciicam_UpdateSettings_FullMethodName = "/asciicam.v1.Asciicam/UpdateSettings"
+)
+
+// AsciicamClient is the client API for Asciicam service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Asciicam streams the rendered frames of a running asciicam and changes
+// its settings.
+type AsciicamClient interface {
+	// StreamFrames sends the rendered frames until the client cancels or the
+	// program ends. Frames are dropped for clients that can't keep up.
+	StreamFrames(ctx context.Context, in *StreamFramesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Frame], error)
+	// GetSettings returns the current settings.
+	GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*Settings, error)
+	// UpdateSettings changes the settings that are set in the request and
+	// returns the resulting settings.
+	UpdateSettings(ctx context.Context, in *UpdateSettingsRequest, opts ...grpc.CallOption) (*Settings, error)
+}
+
+type asciicamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAsciicamClient(cc grpc.ClientConnInterface) AsciicamClient {
+	return &asciicamClient{cc}
+}
+
+func (c *asciicamClient) StreamFrames(ctx context.Context, in *StreamFramesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Frame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Asciicam_ServiceDesc.Streams[0], Asciicam_StreamFrames_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamFramesRequest, Frame]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Asciicam_StreamFramesClient = grpc.ServerStreamingClient[Frame]
+
+func (c *asciicamClient) GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*Settings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Settings)
+	err := c.cc.Invoke(ctx, Asciicam_GetSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *asciicamClient) UpdateSettings(ctx context.Context, in *UpdateSettingsRequest, opts ...grpc.CallOption) (*Settings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Settings)
+	err := c.cc.Invoke(ctx, Asciicam_UpdateSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AsciicamServer is the server API for Asciicam service.
+// All implementations must embed UnimplementedAsciicamServer
+// for forward compatibility.
+//
+// Asciicam streams the rendered frames of a running asciicam and changes
+// its settings.
+type AsciicamServer interface {
+	// StreamFrames sends the rendered frames until the client cancels or the
+	// program ends. Frames are dropped for clients that can't keep up.
+	StreamFrames(*StreamFramesRequest, grpc.ServerStreamingServer[Frame]) error
+	// GetSettings returns the current settings.
+	GetSettings(context.Context, *GetSettingsRequest) (*Settings, error)
+	// UpdateSettings changes the settings that are set in the request and
+	// returns the resulting settings.
+	UpdateSettings(context.Context, *UpdateSettingsRequest) (*Settings, error)
+	mustEmbedUnimplementedAsciicamServer()
+}
+
+// UnimplementedAsciicamServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAsciicamServer struct{}
+
+func (UnimplementedAsciicamServer) StreamFrames(*StreamFramesRequest, grpc.ServerStreamingServer[Frame]) error {
+	return status.Error(codes.Unimplemented, "method StreamFrames not implemented")
+}
+func (UnimplementedAsciicamServer) GetSettings(context.Context, *GetSettingsRequest) (*Settings, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSettings not implemented")
+}
+func (UnimplementedAsciicamServer) UpdateSettings(context.Context, *UpdateSettingsRequest) (*Settings, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateSettings not implemented")
+}
+func (UnimplementedAsciicamServer) mustEmbedUnimplementedAsciicamServer() {}
+func (UnimplementedAsciicamServer) testEmbeddedByValue()                  {}
+
+// UnsafeAsciicamServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AsciicamServer will
+// result in compilation errors.
+type UnsafeAsciicamServer interface {
+	mustEmbedUnimplementedAsciicamServer()
+}
+
+func RegisterAsciicamServer(s grpc.ServiceRegistrar, srv AsciicamServer) {
+	// If the following call panics, it indicates UnimplementedAsciicamServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Asciicam_ServiceDesc, srv)
+}
+
+func _Asciicam_StreamFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFramesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AsciicamServer).StreamFrames(m, &grpc.GenericServerStream[StreamFramesRequest, Frame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Asciicam_StreamFramesServer = grpc.ServerStreamingServer[Frame]
+
+func _Asciicam_GetSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AsciicamServer).GetSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Asciicam_GetSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AsciicamServer).GetSettings(ctx, req.(*GetSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Asciicam_UpdateSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AsciicamServer).UpdateSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Asciicam_UpdateSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AsciicamServer).UpdateSettings(ctx, req.(*UpdateSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Asciicam_ServiceDesc is the grpc.ServiceDesc for Asciicam service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Asciicam_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "asciicam.v1.Asciicam",
+	HandlerType: (*AsciicamServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSettings",
+			Handler:    _Asciicam_GetSettings_Handler,
+		},
+		{
+			MethodName: "UpdateSettings",
+			Handler:    _Asciicam_UpdateSettings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFrames",
+			Handler:       _Asciicam_StreamFrames_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "asciicam.proto",
+}
@@ -0,0 +1,6 @@
+// Package rpc is the gRPC service a running asciicam program offers with
+// -grpc: the stream of rendered frames and its runtime settings. The
+// service is defined in asciicam.proto, for clients in other languages.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative asciicam.proto
@@ -0,0 +1,30 @@
+package source
+
+import "image"
+
+// YUYVToRGBA converts a YUYV 4:2:2 frame into dst.
+func YUYVToRGBA(dst *image.RGBA, frame []byte) {
+	b := dst.Bounds()
+	w := b.Dx()
+	rows := min(b.Dy(), len(frame)/(w*2))
+	for y := 0; y < rows; y++ {
+		src := frame[y*w*2 : (y+1)*w*2]
+		yuyvToRGBARow(dst.Pix[y*dst.Stride:y*dst.Stride+w*4], src)
+	}
+}
+
+// RGBToRGBA converts a raw RGB888 frame (R,G,B bytes per pixel) into dst.
+func RGBToRGBA(dst *image.RGBA, frame []byte) {
+	w := dst.Bounds().Dx()
+	rows := min(dst.Bounds().Dy(), len(frame)/(w*3))
+	for y := 0; y < rows; y++ {
+		src := frame[y*w*3 : (y+1)*w*3]
+		pix := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for i, j := 0, 0; i < len(src); i, j = i+3, j+4 {
+			pix[j] = src[i]
+			pix[j+1] = src[i+1]
+			pix[j+2] = src[i+2]
+			pix[j+3] = 255
+		}
+	}
+}
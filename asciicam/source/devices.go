@@ -0,0 +1,15 @@
+package source
+
+// Device describes a V4L2 capture device.
+type Device struct {
+	Path    string
+	Name    string
+	Formats []Format
+}
+
+// Format is a pixel format of a device with the frame sizes it supports,
+// as reported by the driver, e.g. "640x480" or "320-1280;2 x 180-720;2".
+type Format struct {
+	Name  string
+	Sizes []string
+}
@@ -0,0 +1,163 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FakeStep is what a read from a Fake source does.
+type FakeStep int
+
+const (
+	FakeFrame   FakeStep = iota // deliver the next frame
+	FakeTimeout                 // deliver no frame, like a camera timing out
+	FakeError                   // fail with ErrFake
+	FakeEOF                     // end the source with io.EOF
+)
+
+// ErrFake is returned by Fake sources for FakeError steps.
+var ErrFake = errors.New("fake capture error")
+
+// Fake is a deterministic source for running the capture loop without a
+// camera. Reads follow Script, which starts over once it is done, and are
+// paced Interval apart.
+type Fake struct {
+	// Script lists the steps of the reads. Empty delivers frames forever.
+	Script []FakeStep
+	// Interval is the time between reads, 0 for no delay.
+	Interval time.Duration
+	// Draw draws frame n into dst. The default is a moving test pattern.
+	Draw func(dst *image.RGBA, n int)
+
+	step   int
+	frames int
+	next   time.Time
+	ended  atomic.Bool // set by a FakeEOF step or Close
+}
+
+// NewFake returns a fake source delivering frames every interval.
+func NewFake(interval time.Duration) *Fake {
+	return &Fake{Interval: interval}
+}
+
+func (s *Fake) ReadFrame(dst *image.RGBA) (bool, error) {
+	if s.ended.Load() {
+		return false, io.EOF
+	}
+	if s.Interval > 0 {
+		now := time.Now()
+		if s.next.IsZero() {
+			s.next = now
+		}
+		time.Sleep(s.next.Sub(now))
+		s.next = s.next.Add(s.Interval)
+	}
+
+	step := FakeFrame
+	if len(s.Script) > 0 {
+		step = s.Script[s.step%len(s.Script)]
+		s.step++
+	}
+	switch step {
+	case FakeTimeout:
+		return false, nil
+	case FakeError:
+		return false, ErrFake
+	case FakeEOF:
+		s.ended.Store(true)
+		return false, io.EOF
+	}
+
+	draw := s.Draw
+	if draw == nil {
+		draw = TestPattern
+	}
+	draw(dst, s.frames)
+	s.frames++
+	return true, nil
+}
+
+// Restart starts the script over.
+func (s *Fake) Restart() error {
+	if s.ended.Load() {
+		return io.EOF
+	}
+	s.step, s.next = 0, time.Time{}
+	return nil
+}
+
+func (s *Fake) Close() error {
+	s.ended.Store(true)
+	return nil
+}
+
+// ParseFakeScript parses a comma separated list of steps for a Fake
+// source, each optionally repeated with *n, e.g. "frame*100,timeout,eof".
+func ParseFakeScript(spec string) ([]FakeStep, error) {
+	names := map[string]FakeStep{"frame": FakeFrame, "timeout": FakeTimeout, "error": FakeError, "eof": FakeEOF}
+	var script []FakeStep
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, count, repeated := strings.Cut(item, "*")
+		step, ok := names[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown fake step %q", name)
+		}
+		n := 1
+		if repeated {
+			var err error
+			if n, err = strconv.Atoi(count); err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid count in fake step %q", item)
+			}
+		}
+		for range n {
+			script = append(script, step)
+		}
+	}
+	return script, nil
+}
+
+// TestPattern draws frame n of a test pattern into dst: diagonal color
+// gradients scrolling to the right with a gray square bouncing across.
+func TestPattern(dst *image.RGBA, n int) {
+	b := dst.Bounds()
+	w, h := b.Dx(), b.Dy()
+	side := max(1, h/4)
+	sx := bounce(n*3, max(0, w-side))
+	sy := bounce(n*2, max(0, h-side))
+	for y := range h {
+		row := dst.Pix[dst.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range w {
+			p := row[x*4 : x*4+4 : x*4+4]
+			if x >= sx && x < sx+side && y >= sy && y < sy+side {
+				p[0], p[1], p[2], p[3] = 160, 160, 160, 255
+				continue
+			}
+			p[0] = uint8((x + n) * 255 / max(1, w))
+			p[1] = uint8(y * 255 / max(1, h))
+			p[2] = uint8((x + y + 2*n) * 255 / max(1, w+h))
+			p[3] = 255
+		}
+	}
+}
+
+// bounce moves back and forth between 0 and size with time t.
+func bounce(t, size int) int {
+	if size == 0 {
+		return 0
+	}
+	t %= 2 * size
+	if t > size {
+		return 2*size - t
+	}
+	return t
+}
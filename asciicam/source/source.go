@@ -0,0 +1,194 @@
+// Package source captures frames from V4L2 webcams and GStreamer
+// pipelines.
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Source delivers captured frames as RGBA images.
+type Source interface {
+	// ReadFrame blocks until the next frame is available and converts it
+	// into dst, which must match the capture size. It returns false without
+	// an error when no frame arrived in time.
+	ReadFrame(dst *image.RGBA) (bool, error)
+	Close() error
+}
+
+// YUYVSource is implemented by sources that can hand out the raw YUYV
+// frame, so it can be rendered without converting it to RGBA first.
+type YUYVSource interface {
+	// ReadYUYV is like ReadFrame but copies the raw frame into dst.
+	ReadYUYV(dst []byte) (bool, error)
+}
+
+// Control is a single adjustable device setting.
+type Control struct {
+	ID             uint32
+	Name           string
+	Min, Max, Step int32
+	Value          int32
+}
+
+// IDs of the controls of the exposure, as V4L2 numbers them.
+const (
+	ControlAutoGain         uint32 = 0x00980912 // 1 while the gain is automatic
+	ControlGain             uint32 = 0x00980913
+	ControlExposureAuto     uint32 = 0x009a0901 // see ExposureManual
+	ControlExposureAbsolute uint32 = 0x009a0902 // exposure time in 100 µs
+	ControlExposurePriority uint32 = 0x009a0903 // 1 if auto exposure may lower the frame rate
+)
+
+// ExposureManual is the value of ControlExposureAuto that turns auto
+// exposure off.
+const ExposureManual = 1
+
+// Controllable is implemented by sources whose device settings can be
+// changed while streaming.
+type Controllable interface {
+	Controls() []Control
+	SetControl(id uint32, value int32) error
+}
+
+// Restarter is implemented by sources that can start over when they stop
+// delivering frames without failing, as some UVC cameras do.
+type Restarter interface {
+	// Restart stops capturing and starts again, reopening the device or
+	// the pipeline.
+	Restart() error
+}
+
+// gstWait is the time ReadFrame waits for a frame from the pipeline before
+// it reports a timeout.
+const gstWait = time.Second
+
+// Gst reads raw RGB888 frames from a gst-launch-1.0 pipeline.
+type Gst struct {
+	ctx           context.Context
+	pipeline      string
+	cmd           *exec.Cmd
+	stdout        io.ReadCloser
+	reader        *bufio.Reader
+	buf           []byte
+	n             int // bytes of the frame in buf read so far
+	width, height uint
+}
+
+// OpenGst starts the pipeline, which must write width×height RGB frames
+// to fdsink fd=1.
+func OpenGst(ctx context.Context, pipeline string, width, height uint) (*Gst, error) {
+	cmd, stdout, err := startGstPipe(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gst{
+		ctx:      ctx,
+		pipeline: pipeline,
+		cmd:      cmd,
+		stdout:   stdout,
+		reader:   bufio.NewReader(stdout),
+		buf:      make([]byte, int(width*height*3)),
+		width:    width,
+		height:   height,
+	}, nil
+}
+
+// ReadFrame returns io.EOF once the pipeline has ended.
+func (s *Gst) ReadFrame(dst *image.RGBA) (bool, error) {
+	// pipes can time out, a frame read in part is finished by the next call
+	if f, ok := s.stdout.(*os.File); ok {
+		_ = f.SetReadDeadline(time.Now().Add(gstWait))
+	}
+	// Read exactly one RGB888 frame from GStreamer stdout
+	n, err := io.ReadFull(s.reader, s.buf[s.n:])
+	s.n += n
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return false, nil
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return false, io.EOF
+	case err != nil:
+		return false, fmt.Errorf("failed to read from gst stdout: %w", err)
+	}
+	s.n = 0
+	RGBToRGBA(dst, s.buf)
+	return true, nil
+}
+
+// Restart stops the pipeline and starts it again.
+func (s *Gst) Restart() error {
+	s.stop()
+	cmd, stdout, err := startGstPipe(s.ctx, s.pipeline)
+	if err != nil {
+		return err
+	}
+	s.cmd, s.stdout, s.n = cmd, stdout, 0
+	s.reader.Reset(stdout)
+	return nil
+}
+
+func (s *Gst) Close() error {
+	s.stop()
+	return nil
+}
+
+// stop kills the pipeline and waits for it to exit.
+func (s *Gst) stop() {
+	_ = s.stdout.Close()
+	if s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+	}
+}
+
+// startGstPipe starts gst-launch-1.0 with the given pipeline and
+// returns the *exec.Cmd and a ReadCloser for its stdout.
+func startGstPipe(ctx context.Context, pipeline string) (*exec.Cmd, io.ReadCloser, error) {
+	// split command: gst-launch-1.0 -e <elements...>
+	args := append([]string{"-e"}, strings.Fields(pipeline)...)
+	cmd := exec.CommandContext(ctx, "gst-launch-1.0", args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd.Stderr = &lineLogger{msg: "gstreamer"}
+
+	if err := cmd.Start(); err != nil {
+		_ = stdout.Close()
+		return nil, nil, err
+	}
+	return cmd, stdout, nil
+}
+
+// lineLogger logs every line written to it as a warning.
+type lineLogger struct {
+	msg string
+	buf []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if line := strings.TrimSpace(string(l.buf[:i])); line != "" {
+			slog.Warn(l.msg, "output", line)
+		}
+		l.buf = l.buf[i+1:]
+	}
+}
@@ -0,0 +1,156 @@
+package source
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+)
+
+// StereoMode is how Stereo combines the pictures of its two cameras.
+type StereoMode int
+
+const (
+	// Anaglyph shows the left picture in red and the right one in cyan,
+	// for red/cyan glasses. The red channel is the luminance of the left
+	// picture, which keeps some color while avoiding the flicker red
+	// objects cause in full color anaglyphs.
+	Anaglyph StereoMode = iota
+	// SideBySide squeezes both pictures into one half of the frame each,
+	// for cross-eyed viewing or stereo viewers.
+	SideBySide
+)
+
+// stereoWait is the time ReadFrame waits for a pair of frames before it
+// reports a timeout.
+const stereoWait = time.Second
+
+// Stereo combines the frames of two cameras of a stereo rig into one.
+// Both cameras are read continuously in their own goroutine, and every
+// frame pairs their newest frames, so the eyes are at most a frame apart.
+type Stereo struct {
+	mode   StereoMode
+	ready  chan struct{} // signaled when an eye has a new frame
+	mu     sync.Mutex    // guards the eyes' frames
+	eyes   [2]*stereoEye
+	closed chan struct{}
+	once   sync.Once
+}
+
+// stereoEye is one camera of a Stereo source.
+type stereoEye struct {
+	src   Source
+	front *image.RGBA // newest frame
+	back  *image.RGBA // frame being read
+	seq   uint64      // number of the newest frame
+	seen  uint64      // number of the frame used last
+	err   error       // why reading stopped
+}
+
+// NewStereo reads width×height frames from left and right and combines
+// them as mode says. It takes over both sources.
+func NewStereo(left, right Source, width, height int, mode StereoMode) *Stereo {
+	s := &Stereo{mode: mode, ready: make(chan struct{}, 1), closed: make(chan struct{})}
+	for i, src := range []Source{left, right} {
+		e := &stereoEye{
+			src:   src,
+			front: image.NewRGBA(image.Rect(0, 0, width, height)),
+			back:  image.NewRGBA(image.Rect(0, 0, width, height)),
+		}
+		s.eyes[i] = e
+		go s.read(e)
+	}
+	return s
+}
+
+// read reads the frames of e until its source fails or is closed.
+func (s *Stereo) read(e *stereoEye) {
+	for {
+		ok, err := e.src.ReadFrame(e.back)
+		if !ok && err == nil {
+			continue
+		}
+		s.mu.Lock()
+		if err != nil {
+			e.err = err
+		} else {
+			e.front, e.back = e.back, e.front
+			e.seq++
+		}
+		s.mu.Unlock()
+		select {
+		case s.ready <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Stereo) ReadFrame(dst *image.RGBA) (bool, error) {
+	timeout := time.NewTimer(stereoWait)
+	defer timeout.Stop()
+	for {
+		s.mu.Lock()
+		left, right := s.eyes[0], s.eyes[1]
+		if err := errors.Join(left.err, right.err); err != nil {
+			s.mu.Unlock()
+			return false, err
+		}
+		if left.seq > left.seen && right.seq > right.seen {
+			left.seen, right.seen = left.seq, right.seq
+			s.combine(dst, left.front, right.front)
+			s.mu.Unlock()
+			return true, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.ready:
+		case <-timeout.C:
+			return false, nil
+		case <-s.closed:
+			return false, errors.New("stereo source closed")
+		}
+	}
+}
+
+// combine draws the pair of frames into dst.
+func (s *Stereo) combine(dst, left, right *image.RGBA) {
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	for y := range h {
+		d := dst.Pix[y*dst.Stride:]
+		l, r := left.Pix[y*left.Stride:], right.Pix[y*right.Stride:]
+		switch s.mode {
+		case Anaglyph:
+			for x := 0; x < 4*w; x += 4 {
+				d[x] = uint8((299*uint32(l[x]) + 587*uint32(l[x+1]) + 114*uint32(l[x+2])) / 1000)
+				d[x+1], d[x+2], d[x+3] = r[x+1], r[x+2], 0xff
+			}
+		case SideBySide:
+			half := w / 2
+			for x := range half {
+				squeeze(d[4*x:], l[8*x:])
+				squeeze(d[4*(half+x):], r[8*x:])
+			}
+			if w%2 == 1 {
+				copy(d[4*(w-1):4*w], r[4*(w-1):4*w])
+			}
+		}
+	}
+}
+
+// squeeze sets the pixel at d to the mean of the two pixels at s.
+func squeeze(d, s []byte) {
+	for c := range 3 {
+		d[c] = uint8((uint16(s[c]) + uint16(s[4+c]) + 1) / 2)
+	}
+	d[3] = 0xff
+}
+
+// Close closes both cameras.
+func (s *Stereo) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return errors.Join(s.eyes[0].src.Close(), s.eyes[1].src.Close())
+}
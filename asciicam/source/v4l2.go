@@ -0,0 +1,197 @@
+//go:build !windows
+
+package source
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackjack/webcam"
+)
+
+// Webcam captures YUYV frames from a V4L2 device.
+type Webcam struct {
+	cam           *webcam.Webcam
+	dev           string
+	width, height uint
+}
+
+// OpenWebcam opens dev, selects a YUYV format of the given size and
+// starts streaming.
+func OpenWebcam(dev string, width, height uint) (*Webcam, error) {
+	cam, err := openCam(dev, width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &Webcam{cam: cam, dev: dev, width: width, height: height}, nil
+}
+
+// openCam opens dev and starts streaming YUYV frames of the given size.
+func openCam(dev string, width, height uint) (*webcam.Webcam, error) {
+	cam, err := webcam.Open(dev)
+	if err != nil {
+		return nil, err
+	}
+
+	// find available yuyv format
+	formats := cam.GetSupportedFormats()
+	for k, v := range formats {
+		slog.Debug("webcam format", "format", k, "name", v)
+		if strings.Contains(v, "YUYV") {
+			f, wSet, hSet, err := cam.SetImageFormat(k, uint32(width), uint32(height))
+			if err != nil {
+				_ = cam.Close()
+				return nil, fmt.Errorf("failed to set image format: %w", err)
+			}
+			slog.Debug("webcam format selected", "format", f, "width", wSet, "height", hSet)
+			break
+		}
+	}
+
+	// start streaming
+	_ = cam.SetBufferCount(1)
+	if err := cam.StartStreaming(); err != nil {
+		_ = cam.Close()
+		return nil, fmt.Errorf("failed to start streaming: %w", err)
+	}
+	return cam, nil
+}
+
+func (s *Webcam) ReadFrame(dst *image.RGBA) (bool, error) {
+	frame, err := s.read()
+	if frame == nil {
+		return false, err
+	}
+	YUYVToRGBA(dst, frame)
+	return true, nil
+}
+
+func (s *Webcam) ReadYUYV(dst []byte) (bool, error) {
+	frame, err := s.read()
+	if frame == nil {
+		return false, err
+	}
+	copy(dst, frame)
+	return true, nil
+}
+
+// read waits for the next frame and returns the device buffer, which is
+// only valid until the next read.
+func (s *Webcam) read() ([]byte, error) {
+	if s.cam == nil {
+		// reopening failed, see Restart
+		time.Sleep(time.Second)
+		return nil, nil
+	}
+	err := s.cam.WaitForFrame(1)
+	switch err.(type) {
+	case nil:
+	case *webcam.Timeout:
+		slog.Warn("webcam timed out", "err", err)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed waiting for frame: %w", err)
+	}
+
+	frame, err := s.cam.ReadFrame()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+	if len(frame) == 0 {
+		return nil, nil
+	}
+	return frame, nil
+}
+
+// Controls returns the device controls sorted by name.
+func (s *Webcam) Controls() []Control {
+	if s.cam == nil {
+		return nil
+	}
+	var controls []Control
+	for id, c := range s.cam.GetControls() {
+		value, err := s.cam.GetControl(id)
+		if err != nil {
+			continue
+		}
+		controls = append(controls, Control{
+			ID:    uint32(id),
+			Name:  c.Name,
+			Min:   c.Min,
+			Max:   c.Max,
+			Step:  max(c.Step, 1),
+			Value: value,
+		})
+	}
+	sort.Slice(controls, func(i, j int) bool { return controls[i].Name < controls[j].Name })
+	return controls
+}
+
+func (s *Webcam) SetControl(id uint32, value int32) error {
+	if s.cam == nil {
+		return errors.New("camera is reconnecting")
+	}
+	return s.cam.SetControl(webcam.ControlID(id), value)
+}
+
+// Restart closes the device and opens it again, which brings back cameras
+// that merely restarting streaming doesn't.
+// Until the device opens again, reads time out.
+func (s *Webcam) Restart() error {
+	_ = s.Close()
+	s.cam = nil
+	cam, err := openCam(s.dev, s.width, s.height)
+	if err != nil {
+		return err
+	}
+	s.cam = cam
+	return nil
+}
+
+func (s *Webcam) Close() error {
+	if s.cam == nil {
+		return nil
+	}
+	_ = s.cam.StopStreaming()
+	return s.cam.Close()
+}
+
+// Devices lists the V4L2 devices /dev/video*. Nodes that can't be opened,
+// like those in use or those that only carry metadata, are left out.
+func Devices() ([]Device, error) {
+	paths, err := filepath.Glob("/dev/video*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var devices []Device
+	for _, path := range paths {
+		cam, err := webcam.Open(path)
+		if err != nil {
+			continue
+		}
+		d := Device{Path: path}
+		d.Name, _ = cam.GetName()
+		for pf, name := range cam.GetSupportedFormats() {
+			f := Format{Name: name}
+			for _, size := range cam.GetSupportedFrameSizes(pf) {
+				f.Sizes = append(f.Sizes, size.GetString())
+			}
+			d.Formats = append(d.Formats, f)
+		}
+		_ = cam.Close()
+		if len(d.Formats) == 0 {
+			continue
+		}
+		sort.Slice(d.Formats, func(i, j int) bool { return d.Formats[i].Name < d.Formats[j].Name })
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
@@ -0,0 +1,31 @@
+package source
+
+import (
+	"errors"
+	"image"
+)
+
+// errNoV4L2 is returned by the webcam functions on Windows, which has no
+// V4L2; cameras are captured through GStreamer there.
+var errNoV4L2 = errors.New("V4L2 webcams are not available on Windows, capture with a GStreamer pipeline instead")
+
+// Webcam captures YUYV frames from a V4L2 device. It can't be opened on
+// Windows.
+type Webcam struct{}
+
+// OpenWebcam fails on Windows, see errNoV4L2.
+func OpenWebcam(dev string, width, height uint) (*Webcam, error) {
+	return nil, errNoV4L2
+}
+
+func (s *Webcam) ReadFrame(dst *image.RGBA) (bool, error) { return false, errNoV4L2 }
+func (s *Webcam) ReadYUYV(dst []byte) (bool, error)       { return false, errNoV4L2 }
+func (s *Webcam) Controls() []Control                     { return nil }
+func (s *Webcam) SetControl(id uint32, value int32) error { return errNoV4L2 }
+func (s *Webcam) Restart() error                          { return errNoV4L2 }
+func (s *Webcam) Close() error                            { return nil }
+
+// Devices lists no devices on Windows.
+func Devices() ([]Device, error) {
+	return nil, nil
+}
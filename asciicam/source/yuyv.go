@@ -0,0 +1,15 @@
+package source
+
+import "image/color"
+
+// yuyvToRGBARowGeneric converts a row of YUYV pixel pairs in src into
+// RGBA pixels in pix, which must hold twice as many bytes as src.
+func yuyvToRGBARowGeneric(pix, src []byte) {
+	for i := 0; i+3 < len(src); i += 4 {
+		cb, cr := src[i+1], src[i+3]
+		p := pix[i*2 : i*2+8]
+		p[0], p[1], p[2] = color.YCbCrToRGB(src[i], cb, cr)
+		p[4], p[5], p[6] = color.YCbCrToRGB(src[i+2], cb, cr)
+		p[3], p[7] = 255, 255
+	}
+}
@@ -0,0 +1,24 @@
+//go:build amd64 && !purego
+
+package source
+
+import "golang.org/x/sys/cpu"
+
+var useAVX2 = cpu.X86.HasAVX2
+
+// yuyvToRGBAAVX2 converts len(src)/16 blocks of eight YUYV pixels. It
+// produces exactly the same values as color.YCbCrToRGB.
+//
+//go:noescape
+func yuyvToRGBAAVX2(pix, src []byte)
+
+// yuyvToRGBARow converts a row of YUYV pixel pairs into RGBA, eight pixels
+// at a time with AVX2 where available.
+func yuyvToRGBARow(pix, src []byte) {
+	if useAVX2 {
+		n := len(src) &^ 15
+		yuyvToRGBAAVX2(pix[:n*2], src[:n])
+		pix, src = pix[n*2:], src[n:]
+	}
+	yuyvToRGBARowGeneric(pix, src)
+}
@@ -0,0 +1,103 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// byte shuffles gathering the eight Y, Cb and Cr values of a block of four
+// YUYV pixel pairs, Cb and Cr are repeated for both pixels of a pair
+DATA yuyvY<>+0(SB)/8, $0x0e0c0a0806040200
+DATA yuyvY<>+8(SB)/8, $0x8080808080808080
+GLOBL yuyvY<>(SB), RODATA|NOPTR, $16
+
+DATA yuyvCb<>+0(SB)/8, $0x0d0d090905050101
+DATA yuyvCb<>+8(SB)/8, $0x8080808080808080
+GLOBL yuyvCb<>(SB), RODATA|NOPTR, $16
+
+DATA yuyvCr<>+0(SB)/8, $0x0f0f0b0b07070303
+DATA yuyvCr<>+8(SB)/8, $0x8080808080808080
+GLOBL yuyvCr<>(SB), RODATA|NOPTR, $16
+
+// constants of color.YCbCrToRGB
+DATA yuyvConst<>+0(SB)/4, $0x10101
+DATA yuyvConst<>+4(SB)/4, $128
+DATA yuyvConst<>+8(SB)/4, $91881
+DATA yuyvConst<>+12(SB)/4, $22554
+DATA yuyvConst<>+16(SB)/4, $46802
+DATA yuyvConst<>+20(SB)/4, $116130
+DATA yuyvConst<>+24(SB)/4, $255
+DATA yuyvConst<>+28(SB)/4, $0xff000000
+GLOBL yuyvConst<>(SB), RODATA|NOPTR, $32
+
+// func yuyvToRGBAAVX2(pix, src []byte)
+TEXT ·yuyvToRGBAAVX2(SB), NOSPLIT, $0-48
+	MOVQ pix_base+0(FP), DI
+	MOVQ src_base+24(FP), SI
+	MOVQ src_len+32(FP), CX
+	SHRQ $4, CX
+	JZ   done
+
+	VPBROADCASTD yuyvConst<>+0(SB), Y4
+	VPBROADCASTD yuyvConst<>+4(SB), Y5
+	VPBROADCASTD yuyvConst<>+8(SB), Y6
+	VPBROADCASTD yuyvConst<>+12(SB), Y7
+	VPBROADCASTD yuyvConst<>+16(SB), Y8
+	VPBROADCASTD yuyvConst<>+20(SB), Y9
+	VPBROADCASTD yuyvConst<>+24(SB), Y10
+	VPBROADCASTD yuyvConst<>+28(SB), Y11
+	VPXOR        Y15, Y15, Y15
+
+loop:
+	VMOVDQU   (SI), X0
+	VPSHUFB   yuyvY<>(SB), X0, X1
+	VPSHUFB   yuyvCb<>(SB), X0, X2
+	VPSHUFB   yuyvCr<>(SB), X0, X3
+	VPMOVZXBD X1, Y1
+	VPMOVZXBD X2, Y2
+	VPMOVZXBD X3, Y3
+
+	// yy1 = y * 0x10101, cb1 = cb - 128, cr1 = cr - 128
+	VPMULLD Y4, Y1, Y1
+	VPSUBD  Y5, Y2, Y2
+	VPSUBD  Y5, Y3, Y3
+
+	// r = yy1 + 91881*cr1
+	VPMULLD Y6, Y3, Y12
+	VPADDD  Y1, Y12, Y12
+
+	// g = yy1 - 22554*cb1 - 46802*cr1
+	VPMULLD Y7, Y2, Y13
+	VPMULLD Y8, Y3, Y14
+	VPSUBD  Y13, Y1, Y0
+	VPSUBD  Y14, Y0, Y0
+
+	// b = yy1 + 116130*cb1
+	VPMULLD Y9, Y2, Y14
+	VPADDD  Y1, Y14, Y14
+
+	// scale down and clamp to [0, 255]
+	VPSRAD  $16, Y12, Y12
+	VPSRAD  $16, Y0, Y0
+	VPSRAD  $16, Y14, Y14
+	VPMAXSD Y15, Y12, Y12
+	VPMAXSD Y15, Y0, Y0
+	VPMAXSD Y15, Y14, Y14
+	VPMINSD Y10, Y12, Y12
+	VPMINSD Y10, Y0, Y0
+	VPMINSD Y10, Y14, Y14
+
+	// pack into opaque RGBA pixels
+	VPSLLD $8, Y0, Y0
+	VPSLLD $16, Y14, Y14
+	VPOR   Y0, Y12, Y12
+	VPOR   Y14, Y12, Y12
+	VPOR   Y11, Y12, Y12
+	VMOVDQU Y12, (DI)
+
+	ADDQ $16, SI
+	ADDQ $32, DI
+	DECQ CX
+	JNZ  loop
+
+	VZEROUPPER
+
+done:
+	RET
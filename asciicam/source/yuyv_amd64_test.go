@@ -0,0 +1,38 @@
+//go:build amd64 && !purego
+
+package source
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestYUYVToRGBAAVX2 compares the AVX2 converter with the generic one on
+// random input, extremes of the channels included.
+func TestYUYVToRGBAAVX2(t *testing.T) {
+	if !useAVX2 {
+		t.Skip("no AVX2")
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	src := make([]byte, 16*4096)
+	for i := range src {
+		switch rng.UintN(8) {
+		case 0:
+			src[i] = 0
+		case 1:
+			src[i] = 255
+		default:
+			src[i] = byte(rng.UintN(256))
+		}
+	}
+	want := make([]byte, 2*len(src))
+	yuyvToRGBARowGeneric(want, src)
+	got := make([]byte, 2*len(src))
+	yuyvToRGBAAVX2(got, src)
+	for i := 0; i < len(src); i += 16 {
+		if !bytes.Equal(got[i*2:i*2+32], want[i*2:i*2+32]) {
+			t.Fatalf("block %x:\ngot  %x\nwant %x", src[i:i+16], got[i*2:i*2+32], want[i*2:i*2+32])
+		}
+	}
+}
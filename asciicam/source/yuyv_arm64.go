@@ -0,0 +1,17 @@
+//go:build arm64 && !purego
+
+package source
+
+// yuyvToRGBANEON converts len(src)/32 blocks of sixteen YUYV pixels. It
+// produces exactly the same values as color.YCbCrToRGB.
+//
+//go:noescape
+func yuyvToRGBANEON(pix, src []byte)
+
+// yuyvToRGBARow converts a row of YUYV pixel pairs into RGBA, sixteen
+// pixels at a time with NEON, which every arm64 CPU has.
+func yuyvToRGBARow(pix, src []byte) {
+	n := len(src) &^ 31
+	yuyvToRGBANEON(pix[:n*2], src[:n])
+	yuyvToRGBARowGeneric(pix[n*2:], src[n:])
+}
@@ -0,0 +1,130 @@
+//go:build arm64 && !purego
+
+#include "textflag.h"
+
+// func yuyvToRGBANEON(pix, src []byte)
+TEXT ·yuyvToRGBANEON(SB), NOSPLIT, $0-48
+	MOVD pix_base+0(FP), R0
+	MOVD src_base+24(FP), R1
+	MOVD src_len+32(FP), R2
+	LSR  $5, R2
+	CBZ  R2, done
+
+	// constants of color.YCbCrToRGB
+	MOVW  $0x10101, R3
+	VDUP  R3, V20.S4
+	MOVW  $128, R3
+	VDUP  R3, V21.S4
+	MOVW  $91881, R3
+	VDUP  R3, V22.S4
+	MOVW  $22554, R3
+	VDUP  R3, V23.S4
+	MOVW  $46802, R3
+	VDUP  R3, V24.S4
+	MOVW  $116130, R3
+	VDUP  R3, V25.S4
+	VMOVI $255, V19.B16
+
+loop:
+	// Y of the even pixels, Cb, Y of the odd pixels and Cr of eight pairs
+	VLD4 (R1), [V0.B8, V1.B8, V2.B8, V3.B8]
+	ADD  $32, R1
+
+	// cb1 = cb - 128 in V4 and V5, cr1 = cr - 128 in V6 and V7
+	VUXTL  V1.B8, V1.H8
+	VUXTL  V1.H4, V4.S4
+	VUXTL2 V1.H8, V5.S4
+	VUXTL  V3.B8, V3.H8
+	VUXTL  V3.H4, V6.S4
+	VUXTL2 V3.H8, V7.S4
+	VSUB   V21.S4, V4.S4, V4.S4
+	VSUB   V21.S4, V5.S4, V5.S4
+	VSUB   V21.S4, V6.S4, V6.S4
+	VSUB   V21.S4, V7.S4, V7.S4
+
+	// 91881*cr1 in V8 and V9
+	VMUL V22.S4, V6.S4, V8.S4
+	VMUL V22.S4, V7.S4, V9.S4
+
+	// 22554*cb1 + 46802*cr1 in V10 and V11
+	VMUL V23.S4, V4.S4, V10.S4
+	VMUL V23.S4, V5.S4, V11.S4
+	VMUL V24.S4, V6.S4, V12.S4
+	VMUL V24.S4, V7.S4, V13.S4
+	VADD V12.S4, V10.S4, V10.S4
+	VADD V13.S4, V11.S4, V11.S4
+
+	// 116130*cb1 in V4 and V5
+	VMUL V25.S4, V4.S4, V4.S4
+	VMUL V25.S4, V5.S4, V5.S4
+
+	// yy1 = y * 0x10101 of the even pixels, then r = yy1 + 91881*cr1,
+	// g = yy1 - 22554*cb1 - 46802*cr1 and b = yy1 + 116130*cb1 scaled
+	// down and clamped to [0, 255] into V26, V27 and V28
+	VUXTL  V0.B8, V0.H8
+	VUXTL  V0.H4, V14.S4
+	VUXTL2 V0.H8, V15.S4
+	VMUL   V20.S4, V14.S4, V14.S4
+	VMUL   V20.S4, V15.S4, V15.S4
+	VADD    V8.S4, V14.S4, V12.S4
+	VADD    V9.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V26.H4
+	VSQXTUN2 V13.S4, V26.H8
+	VUQXTN   V26.H8, V26.B8
+	VSUB    V10.S4, V14.S4, V12.S4
+	VSUB    V11.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V27.H4
+	VSQXTUN2 V13.S4, V27.H8
+	VUQXTN   V27.H8, V27.B8
+	VADD    V4.S4, V14.S4, V12.S4
+	VADD    V5.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V28.H4
+	VSQXTUN2 V13.S4, V28.H8
+	VUQXTN   V28.H8, V28.B8
+
+	// the same for the odd pixels into V29, V30 and V31
+	VUXTL  V2.B8, V2.H8
+	VUXTL  V2.H4, V14.S4
+	VUXTL2 V2.H8, V15.S4
+	VMUL   V20.S4, V14.S4, V14.S4
+	VMUL   V20.S4, V15.S4, V15.S4
+	VADD    V8.S4, V14.S4, V12.S4
+	VADD    V9.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V29.H4
+	VSQXTUN2 V13.S4, V29.H8
+	VUQXTN   V29.H8, V29.B8
+	VSUB    V10.S4, V14.S4, V12.S4
+	VSUB    V11.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V30.H4
+	VSQXTUN2 V13.S4, V30.H8
+	VUQXTN   V30.H8, V30.B8
+	VADD    V4.S4, V14.S4, V12.S4
+	VADD    V5.S4, V15.S4, V13.S4
+	VSSHR   $16, V12.S4, V12.S4
+	VSSHR   $16, V13.S4, V13.S4
+	VSQXTUN  V12.S4, V31.H4
+	VSQXTUN2 V13.S4, V31.H8
+	VUQXTN   V31.H8, V31.B8
+
+	// interleave the even and odd pixels into opaque RGBA pixels
+	VZIP1 V29.B16, V26.B16, V16.B16
+	VZIP1 V30.B16, V27.B16, V17.B16
+	VZIP1 V31.B16, V28.B16, V18.B16
+	VST4  [V16.B16, V17.B16, V18.B16, V19.B16], (R0)
+	ADD   $64, R0
+
+	SUB $1, R2
+	CBNZ R2, loop
+
+done:
+	RET
@@ -0,0 +1,8 @@
+//go:build (!amd64 && !arm64) || purego
+
+package source
+
+// yuyvToRGBARow converts a row of YUYV pixel pairs into RGBA.
+func yuyvToRGBARow(pix, src []byte) {
+	yuyvToRGBARowGeneric(pix, src)
+}
@@ -0,0 +1,27 @@
+package source
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestYUYVToRGBARow compares the row converter of the platform with the
+// generic one on random rows of every length up to a few SIMD blocks, so
+// the tails are covered too.
+func TestYUYVToRGBARow(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for n := 0; n <= 200; n += 4 {
+		src := make([]byte, n)
+		for i := range src {
+			src[i] = byte(rng.UintN(256))
+		}
+		want := make([]byte, 2*n)
+		yuyvToRGBARowGeneric(want, src)
+		got := make([]byte, 2*n)
+		yuyvToRGBARow(got, src)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%d bytes of YUYV %x:\ngot  %x\nwant %x", n, src, got, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package term
+
+import (
+	"errors"
+	"image"
+	"regexp"
+	"strconv"
+)
+
+// errNoCellSize is returned by CellSize when neither the kernel nor the
+// terminal know the pixel size of a cell.
+var errNoCellSize = errors.New("terminal doesn't report its cell size")
+
+// cellSizeReply matches the XTWINOPS reply to a cell size query,
+// CSI 6 ; height ; width t.
+var cellSizeReply = regexp.MustCompile(`\x1b\[6;(\d+);(\d+)t`)
+
+// parseCellSize returns the cell size in the terminal's reply to a cell
+// size query.
+func parseCellSize(reply []byte) (image.Point, bool) {
+	m := cellSizeReply.FindSubmatch(reply)
+	if m == nil {
+		return image.Point{}, false
+	}
+	h, _ := strconv.Atoi(string(m[1]))
+	w, _ := strconv.Atoi(string(m[2]))
+	if w <= 0 || h <= 0 {
+		return image.Point{}, false
+	}
+	return image.Pt(w, h), true
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package term
+
+import (
+	"image"
+	"os"
+)
+
+// CellSize returns the size in pixels of a cell of the terminal out is
+// connected to. Consoles outside of Unix don't report it.
+func CellSize(in, out *os.File) (image.Point, error) {
+	return image.Point{}, errNoCellSize
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package term
+
+import (
+	"image"
+	"os"
+	"regexp"
+	"time"
+
+	"golang.org/x/sys/unix"
+	xterm "golang.org/x/term"
+)
+
+// cellQueryTimeout is how long CellSize waits for the terminal to reply.
+const cellQueryTimeout = 200 * time.Millisecond
+
+// deviceAttrsReply matches the reply to the primary device attributes
+// query, CSI ? ... c, which every terminal answers.
+var deviceAttrsReply = regexp.MustCompile(`\x1b\[\?[\d;]*c`)
+
+// CellSize returns the size in pixels of a cell of the terminal out is
+// connected to. It asks the kernel for the pixel size of the window first
+// and, if that is unknown, asks the terminal with an XTWINOPS query
+// written to out and read from in. The query needs in in raw mode, so it
+// has to happen before the program reads its input.
+func CellSize(in, out *os.File) (image.Point, error) {
+	ws, err := unix.IoctlGetWinsize(int(out.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return image.Point{}, err
+	}
+	if ws.Xpixel > 0 && ws.Ypixel > 0 && ws.Col > 0 && ws.Row > 0 {
+		return image.Pt(int(ws.Xpixel/ws.Col), int(ws.Ypixel/ws.Row)), nil
+	}
+	if !xterm.IsTerminal(int(in.Fd())) {
+		return image.Point{}, errNoCellSize
+	}
+	if cell, ok := queryCellSize(in, out); ok {
+		return cell, nil
+	}
+	return image.Point{}, errNoCellSize
+}
+
+// queryCellSize asks the terminal for its cell size with CSI 16 t. Not all
+// terminals answer it, so the query is followed by a device attributes
+// query that all of them answer: its reply ends the wait early.
+func queryCellSize(in, out *os.File) (image.Point, bool) {
+	fd := int(in.Fd())
+	state, err := xterm.MakeRaw(fd)
+	if err != nil {
+		return image.Point{}, false
+	}
+	defer func() { _ = xterm.Restore(fd, state) }()
+
+	if _, err := out.WriteString("\x1b[16t\x1b[c"); err != nil {
+		return image.Point{}, false
+	}
+	var reply []byte
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(cellQueryTimeout)
+	for !deviceAttrsReply.Match(reply) {
+		left := time.Until(deadline)
+		if left <= 0 {
+			break
+		}
+		// poll rather than read, a read without a reply would block
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, int(left.Milliseconds())+1); err != nil || n == 0 {
+			if err == unix.EINTR {
+				continue
+			}
+			break
+		}
+		n, err := unix.Read(fd, buf)
+		if err != nil || n == 0 {
+			break
+		}
+		reply = append(reply, buf[:n]...)
+	}
+	// keys typed meanwhile are lost, like with every terminal query
+	return parseCellSize(reply)
+}
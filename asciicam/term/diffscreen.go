@@ -0,0 +1,180 @@
+// Package term writes rendered frames to the terminal.
+package term
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rivo/uniseg"
+	xterm "golang.org/x/term"
+)
+
+// cell is a single terminal cell together with the SGR parameters it is
+// drawn with. A wide grapheme is followed by a cell with width 0 for each
+// further column it takes up, so the index of a cell in its row is its
+// column.
+type cell struct {
+	g     string
+	sgr   string
+	width int
+}
+
+// DiffScreen draws frames by comparing them with the previously drawn cell
+// grid and only writing the cells that changed. It replaces the Bubble
+// Tea renderer, which redraws every changed line in full.
+type DiffScreen struct {
+	out   io.Writer
+	in    *os.File
+	mouse bool
+	state *xterm.State // terminal state of in before raw mode
+	prev  [][]cell
+	next  [][]cell
+	buf   []byte
+}
+
+// NewDiffScreen returns a screen that draws to out and puts in into raw
+// mode while started. With mouse set, mouse reporting is enabled.
+func NewDiffScreen(out io.Writer, in *os.File, mouse bool) *DiffScreen {
+	return &DiffScreen{out: out, in: in, mouse: mouse}
+}
+
+// Start switches to the alternate screen and hides the cursor. Bubble Tea
+// leaves the terminal alone without its renderer, so Start also puts the
+// input into raw mode.
+func (s *DiffScreen) Start() error {
+	if xterm.IsTerminal(int(s.in.Fd())) {
+		state, err := xterm.MakeRaw(int(s.in.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to set raw mode: %w", err)
+		}
+		s.state = state
+	}
+
+	seq := ansi.SetAltScreenSaveCursorMode + ansi.HideCursor
+	if s.mouse {
+		seq += ansi.SetButtonEventMouseMode + ansi.SetSgrExtMouseMode
+	}
+	_, err := io.WriteString(s.out, seq)
+	return err
+}
+
+// Stop restores the terminal state changed by Start.
+func (s *DiffScreen) Stop() {
+	seq := ansi.ResetStyle + ansi.ShowCursor + ansi.ResetAltScreenSaveCursorMode
+	if s.mouse {
+		seq = ansi.ResetButtonEventMouseMode + ansi.ResetSgrExtMouseMode + seq
+	}
+	_, _ = io.WriteString(s.out, seq)
+	if s.state != nil {
+		_ = xterm.Restore(int(s.in.Fd()), s.state)
+	}
+}
+
+// Draw writes the changes between view and the previously drawn view in
+// a single write. graphics, like the sixel image of render.SplitGraphics,
+// are written after the changes on every call.
+func (s *DiffScreen) Draw(view, graphics string) {
+	s.next = parseCells(s.next[:0], view)
+
+	full := len(s.next) != len(s.prev)
+	for y := 0; !full && y < len(s.next); y++ {
+		full = len(s.next[y]) != len(s.prev[y])
+	}
+
+	b := s.buf[:0]
+	if full {
+		b = append(b, ansi.ResetStyle+ansi.EraseEntireScreen...)
+	}
+	sgr := ""
+	cx, cy := -1, -1
+	for y, row := range s.next {
+		for x, c := range row {
+			// the columns after a wide grapheme are drawn with it
+			if c.width == 0 || !full && c == s.prev[y][x] {
+				continue
+			}
+			if x != cx || y != cy {
+				b = append(b, "\x1b["...)
+				b = strconv.AppendInt(b, int64(y+1), 10)
+				b = append(b, ';')
+				b = strconv.AppendInt(b, int64(x+1), 10)
+				b = append(b, 'H')
+			}
+			if c.sgr != sgr {
+				b = append(b, ansi.ResetStyle...)
+				if c.sgr != "" {
+					b = append(b, "\x1b["+c.sgr+"m"...)
+				}
+				sgr = c.sgr
+			}
+			b = append(b, c.g...)
+			cx, cy = x+c.width, y
+		}
+	}
+	if sgr != "" {
+		b = append(b, ansi.ResetStyle...)
+	}
+	b = append(b, graphics...)
+	if len(b) > 0 {
+		_, _ = s.out.Write(b)
+	}
+	s.buf = b
+
+	s.prev, s.next = s.next, s.prev
+}
+
+// parseCells splits view into rows of cells, reusing the rows of grid.
+// Only SGR sequences are interpreted, other escape sequences are dropped.
+func parseCells(grid [][]cell, view string) [][]cell {
+	for y, line := range strings.Split(view, "\n") {
+		var row []cell
+		if y < cap(grid) {
+			row = grid[:y+1][y][:0]
+		}
+
+		sgr, state := "", -1
+		for len(line) > 0 {
+			if strings.HasPrefix(line, "\x1b[") {
+				end := strings.IndexFunc(line[2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+				if end < 0 {
+					break
+				}
+				if params := line[2 : 2+end]; line[2+end] == 'm' {
+					switch {
+					case params == "" || params == "0":
+						sgr = ""
+					case sgr == "":
+						sgr = params
+					default:
+						sgr += ";" + params
+					}
+				}
+				line, state = line[3+end:], -1
+				continue
+			}
+			var g string
+			var width int
+			if len(line) == 1 || line[0] < utf8.RuneSelf && line[1] < utf8.RuneSelf {
+				// ASCII, all of the frames of most renderers
+				g, line, width, state = line[:1], line[1:], 1, -1
+			} else {
+				g, line, width, state = uniseg.FirstGraphemeClusterInString(line, state)
+			}
+			// zero width graphemes don't take up a cell of their own
+			if width == 0 {
+				continue
+			}
+			row = append(row, cell{g, sgr, width})
+			for range width - 1 {
+				row = append(row, cell{sgr: sgr})
+			}
+		}
+		grid = append(grid[:y], row)
+	}
+	return grid
+}
@@ -0,0 +1,27 @@
+package term
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffScreenWideGraphemes(t *testing.T) {
+	for _, tc := range []struct {
+		prev, next, want string
+	}{
+		{"a😀bc", "a😀bX", "\x1b[1;5HX"},
+		{"a😀b", "abcd", "\x1b[1;2Hbcd"},
+		{"abcd", "a😀d", "\x1b[1;2H😀"},
+		{"x😀y\n😀ab", "x😀z\n😀aX", "\x1b[1;4Hz\x1b[2;4HX"},
+		{"éab", "éaX", "\x1b[1;3HX"},
+	} {
+		var out bytes.Buffer
+		s := NewDiffScreen(&out, nil, false)
+		s.Draw(tc.prev, "")
+		out.Reset()
+		s.Draw(tc.next, "")
+		if got := out.String(); got != tc.want {
+			t.Errorf("%q after %q: wrote %q, want %q", tc.next, tc.prev, got, tc.want)
+		}
+	}
+}
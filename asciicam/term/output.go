@@ -0,0 +1,45 @@
+package term
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Output is the terminal frames are written to. With Sync set, every
+// write is wrapped in synchronized output sequences (DEC mode 2026).
+// Terminals that support the mode show each frame at once instead of
+// tearing while it is drawn, others ignore it. Bubble Tea and DiffScreen
+// write a whole frame in a single Write, so each frame ends up in one
+// update.
+type Output struct {
+	*os.File
+	Sync    bool
+	OnWrite func(time.Duration) // called with the duration of every write, if set
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := p
+	if o.Sync {
+		o.buf = append(o.buf[:0], ansi.SetSynchronizedOutputMode...)
+		o.buf = append(o.buf, p...)
+		o.buf = append(o.buf, ansi.ResetSynchronizedOutputMode...)
+		b = o.buf
+	}
+	start := time.Now()
+	if _, err := o.File.Write(b); err != nil {
+		return 0, err
+	}
+	if o.OnWrite != nil {
+		o.OnWrite(time.Since(start))
+	}
+	return len(p), nil
+}
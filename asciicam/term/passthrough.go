@@ -0,0 +1,52 @@
+package term
+
+import (
+	"os"
+	"strings"
+)
+
+// Multiplexer is a terminal multiplexer the program runs in. Multiplexers
+// interpret the output themselves and drop the graphics sequences they
+// don't know unless these are wrapped for the outer terminal.
+type Multiplexer int
+
+const (
+	NoMultiplexer Multiplexer = iota
+	Tmux
+	Screen
+)
+
+// DetectMultiplexer returns the multiplexer the program runs in, from the
+// variables tmux and GNU screen set in their windows.
+func DetectMultiplexer() Multiplexer {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return Tmux
+	case os.Getenv("STY") != "":
+		return Screen
+	}
+	return NoMultiplexer
+}
+
+func (m Multiplexer) String() string {
+	switch m {
+	case Tmux:
+		return "tmux"
+	case Screen:
+		return "screen"
+	}
+	return "none"
+}
+
+// Passthrough wraps seq, e.g. a sixel image, so tmux forwards it to the
+// outer terminal unchanged: it becomes the payload of a "tmux;" DCS with
+// its escapes doubled. tmux only forwards it with its allow-passthrough
+// option on. Screen's passthrough ends at the first string terminator, so
+// it can't carry sequences that contain one, like sixel images; seq is
+// returned unchanged for it and outside multiplexers.
+func (m Multiplexer) Passthrough(seq string) string {
+	if m != Tmux || seq == "" {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
@@ -0,0 +1,104 @@
+//go:build js && wasm
+
+// Command asciicam-wasm runs the asciicam renderers in the browser. The
+// demo page in web/ captures the camera with getUserMedia and hands the
+// pixels of every frame to the asciicamRender function this registers.
+package main
+
+import (
+	"fmt"
+	"image"
+	"syscall/js"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// state is kept between frames to reuse the buffers.
+var state struct {
+	frame   *image.RGBA
+	scaler  filter.Scaler
+	filters string // spec chain was parsed from
+	parsed  bool
+	chain   filter.Chain
+}
+
+func main() {
+	var modes, charsets []any
+	for _, m := range render.Modes() {
+		// sixel graphics can't be shown in a web page
+		if m.Name != "sixel" {
+			modes = append(modes, m.Name)
+		}
+	}
+	for _, cs := range render.Charsets {
+		charsets = append(charsets, cs.Name)
+	}
+	js.Global().Set("asciicamModes", js.ValueOf(modes))
+	js.Global().Set("asciicamCharsets", js.ValueOf(charsets))
+	js.Global().Set("asciicamRender", js.FuncOf(renderJS))
+	select {}
+}
+
+// renderJS implements asciicamRender(pixels, width, height, cols, rows,
+// options). pixels are the RGBA bytes of a width×height ImageData, options
+// an object with the mode, charset and filters names and a color flag. It
+// returns the frame as HTML for a pre element, or an Error.
+func renderJS(_ js.Value, args []js.Value) any {
+	if len(args) != 6 {
+		return jsError("asciicamRender takes 6 arguments")
+	}
+	w, h := args[1].Int(), args[2].Int()
+	cols, rows := args[3].Int(), args[4].Int()
+	opts := args[5]
+	if w <= 0 || h <= 0 || cols <= 0 || rows <= 0 || args[0].Get("length").Int() != w*h*4 {
+		return jsError("invalid frame size")
+	}
+
+	if state.frame == nil || state.frame.Rect.Dx() != w || state.frame.Rect.Dy() != h {
+		state.frame = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	js.CopyBytesToGo(state.frame.Pix, args[0])
+
+	out, err := renderFrame(state.frame, cols, rows, opts.Get("mode").String(),
+		opts.Get("charset").String(), opts.Get("filters").String(), opts.Get("color").Truthy())
+	if err != nil {
+		return jsError(err.Error())
+	}
+	return render.HTML(out)
+}
+
+// renderFrame scales img to cols×rows cells of the named mode, applies
+// the filters and renders it.
+func renderFrame(img *image.RGBA, cols, rows int, mode, charset, filters string, color bool) (string, error) {
+	mi, err := render.Index(mode)
+	if err != nil {
+		return "", err
+	}
+	ci, err := render.CharsetIndex(charset)
+	if err != nil {
+		return "", err
+	}
+	if !state.parsed || filters != state.filters {
+		chain, err := filter.Parse(filters)
+		if err != nil {
+			return "", err
+		}
+		state.filters, state.chain, state.parsed = filters, chain, true
+	}
+
+	m := render.Modes()[mi]
+	scaled := state.scaler.Scale(img, img.Rect, uint(cols)*m.CellW, uint(rows)*m.CellH)
+	state.chain.Apply(scaled)
+
+	o := render.Options{Profile: termenv.Ascii, Pixels: render.Charsets[ci].Pixels}
+	if color {
+		o.Profile = termenv.TrueColor
+	}
+	return string(m.New(o).Render(scaled, cols, rows)), nil
+}
+
+func jsError(msg string) js.Value {
+	return js.Global().Get("Error").New(fmt.Sprintf("asciicam: %s", msg))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+const (
+	// maxQualityDrop is the lowest quality level: 1 limits colors to the
+	// 256 color palette, 2 switches to the ascii renderer and 3 drops
+	// colors altogether.
+	maxQualityDrop = 3
+
+	adaptSlowFrames = 10  // frames over budget before lowering the quality
+	adaptFastFrames = 120 // frames under half the budget before raising it
+)
+
+// adaptive lowers the output quality while frames take longer than the
+// frame budget from capture to the model and raises it again once they are
+// fast, so slow terminals get a cheaper picture instead of a lower frame
+// rate.
+type adaptive struct {
+	budget time.Duration // 0 disables adapting
+	level  int
+	slow   int // consecutive frames over budget
+	fast   int // consecutive frames under half the budget
+}
+
+// observe records how long a frame took and reports whether the quality
+// level changed.
+func (a *adaptive) observe(took time.Duration) bool {
+	if a.budget == 0 {
+		return false
+	}
+	switch {
+	case took > a.budget:
+		a.slow++
+		a.fast = 0
+	case took < a.budget/2:
+		a.fast++
+		a.slow = 0
+	default:
+		a.slow, a.fast = 0, 0
+	}
+
+	switch {
+	case a.slow >= adaptSlowFrames && a.level < maxQualityDrop:
+		a.level++
+	case a.fast >= adaptFastFrames && a.level > 0:
+		a.level--
+	default:
+		return false
+	}
+	a.slow, a.fast = 0, 0
+	return true
+}
+
+// apply lowers s to the current quality level.
+func (a *adaptive) apply(s *frameSettings) {
+	if a.level >= 1 && s.profile == termenv.TrueColor {
+		s.profile = termenv.ANSI256
+	}
+	if a.level >= 2 {
+		switch render.Modes()[s.renderer].Name {
+		case "ascii", "mono", "contrast":
+		default:
+			s.renderer, _ = render.Index("ascii")
+		}
+	}
+	if a.level >= 3 {
+		s.profile = termenv.Ascii
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// ambientSmoothing is the time constant of the average brightness -ambient
+// switches by, so passing shadows and flashes don't switch the theme.
+const ambientSmoothing = 3 * time.Second
+
+// ambientTheme is the look -ambient switches to for a dark or a light room.
+type ambientTheme struct {
+	name    string
+	charset string  // ramp, see render.Charsets
+	gamma   float64 // gamma curve applied before the ramp
+}
+
+// ambientThemes are the dark and the light theme of -ambient. Dark rooms
+// leave the picture at the bottom of the ramp, so the dark theme lifts the
+// shadows and uses the ramp with the most steps; bright rooms crowd the top
+// of the ramp, so the light theme pulls the highlights apart.
+var ambientThemes = [2]ambientTheme{
+	{"dark", "detailed", 0.6},
+	{"light", "default", 1.4},
+}
+
+// ambientSwitch switches between the ambientThemes by the smoothed average
+// brightness of the frames. The brightness has to fall below low to switch
+// to the dark theme and rise above high to switch to the light theme, so
+// rooms in between don't make it flap.
+type ambientSwitch struct {
+	on        bool
+	low, high float64   // brightness from 0 to 1
+	level     float64   // smoothed brightness
+	last      time.Time // time of the last frame, zero before the first one
+	theme     int       // index into ambientThemes
+}
+
+// parseAmbientLevels parses the low,high brightness levels of
+// -ambient-levels.
+func parseAmbientLevels(s string) (low, high float64, err error) {
+	l, h, ok := strings.Cut(s, ",")
+	if ok {
+		low, err = strconv.ParseFloat(strings.TrimSpace(l), 64)
+	}
+	if err == nil && ok {
+		high, err = strconv.ParseFloat(strings.TrimSpace(h), 64)
+	}
+	if !ok || err != nil || low < 0 || high > 1 || low > high {
+		return 0, 0, fmt.Errorf("invalid ambient levels %q, want low,high between 0 and 1", s)
+	}
+	return low, high, nil
+}
+
+// observe records the brightness of a frame and reports whether the theme
+// changed.
+func (a *ambientSwitch) observe(brightness float64, now time.Time) bool {
+	if !a.on {
+		return false
+	}
+	if a.last.IsZero() {
+		a.level, a.last = brightness, now
+		theme := 1
+		if brightness < (a.low+a.high)/2 {
+			theme = 0
+		}
+		a.theme = theme
+		return true
+	}
+	dt := now.Sub(a.last).Seconds()
+	a.last = now
+	a.level += (brightness - a.level) * min(1, dt/ambientSmoothing.Seconds())
+
+	switch {
+	case a.theme == 1 && a.level < a.low:
+		a.theme = 0
+	case a.theme == 0 && a.level > a.high:
+		a.theme = 1
+	default:
+		return false
+	}
+	return true
+}
+
+// gamma returns the gamma of the current theme, 1 while switching is off.
+func (a *ambientSwitch) gamma() float64 {
+	if !a.on || a.last.IsZero() {
+		return 1
+	}
+	return ambientThemes[a.theme].gamma
+}
+
+// ambientFrame switches the theme by the brightness of the frame just
+// shown. The switch sets the charset, which can still be changed by hand
+// until the next switch.
+func (m *model) ambientFrame(brightness float64, now time.Time) {
+	if !m.ambient.observe(brightness, now) {
+		return
+	}
+	t := ambientThemes[m.ambient.theme]
+	if i, err := render.CharsetIndex(t.charset); err == nil {
+		m.charset = i
+	}
+	m.setNotice(fmt.Sprintf("ambient: %s theme", t.name))
+}
+
+// meanBrightness returns the average brightness of img from 0 to 1,
+// sampling every fourth pixel of every fourth row.
+func meanBrightness(img *image.RGBA) float64 {
+	b := img.Bounds()
+	var sum, n int
+	for y := b.Min.Y; y < b.Max.Y; y += 4 {
+		row := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x += 4 {
+			p := row[4*x : 4*x+3 : 4*x+3]
+			sum += 299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(sum) / float64(n*1000*255)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/rpc"
+)
+
+// apiSettings are the runtime settings of the HTTP API, see -api. In
+// requests, fields left out keep their values; responses set all of them.
+type apiSettings struct {
+	Width       *uint32  `json:"width,omitempty"`
+	Height      *uint32  `json:"height,omitempty"`
+	Threshold   *float64 `json:"threshold,omitempty"`
+	Mode        *string  `json:"mode,omitempty"`
+	Charset     *string  `json:"charset,omitempty"`
+	Greenscreen *bool    `json:"greenscreen,omitempty"`
+	Mirror      *bool    `json:"mirror,omitempty"`
+	Paused      *bool    `json:"paused,omitempty"`
+	Recording   *bool    `json:"recording,omitempty"`
+	Modes       []string `json:"modes,omitempty"`
+	Charsets    []string `json:"charsets,omitempty"`
+}
+
+// apiSettingsMsg asks the model for its settings after applying update, if
+// not nil. The model answers on reply.
+type apiSettingsMsg struct {
+	update *apiSettings
+	reply  chan apiSettingsReply
+}
+
+type apiSettingsReply struct {
+	settings *apiSettings
+	err      error
+}
+
+// applyAPISettings applies the settings set in u and returns the
+// resulting settings. The settings shared with gRPC are applied all or
+// none; a recording that fails to start leaves the others applied.
+func (m *model) applyAPISettings(u *apiSettings) (*apiSettings, error) {
+	if u != nil {
+		_, err := m.applySettings(&rpc.UpdateSettingsRequest{
+			Width:       u.Width,
+			Height:      u.Height,
+			Threshold:   u.Threshold,
+			Mode:        u.Mode,
+			Charset:     u.Charset,
+			Greenscreen: u.Greenscreen,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if u.Mirror != nil {
+			// on top of the filters rather than in them, so the chain
+			// keeps its programs and state
+			m.mirrorFlip = *u.Mirror != hasMirror(m.filters)
+		}
+		if u.Paused != nil && *u.Paused != m.paused {
+			m.paused, m.back = *u.Paused, 0
+		}
+		if u.Recording != nil && *u.Recording != (m.rec != nil) {
+			if !*u.Recording {
+				m.stopRecording()
+			} else if err := m.startRecording(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s, _ := m.applySettings(nil)
+	mirror, paused, recording := m.mirrored(), m.paused, m.rec != nil
+	return &apiSettings{
+		Width:       &s.Width,
+		Height:      &s.Height,
+		Threshold:   &s.Threshold,
+		Mode:        &s.Mode,
+		Charset:     &s.Charset,
+		Greenscreen: &s.Greenscreen,
+		Mirror:      &mirror,
+		Paused:      &paused,
+		Recording:   &recording,
+		Modes:       s.Modes,
+		Charsets:    s.Charsets,
+	}, nil
+}
+
+// hasMirror reports whether the filter chain spec mirrors the picture,
+// which an even number of mirror filters doesn't.
+func hasMirror(spec string) bool {
+	mirrored := false
+	for item := range strings.SplitSeq(spec, ",") {
+		if strings.TrimSpace(item) == "mirror" {
+			mirrored = !mirrored
+		}
+	}
+	return mirrored
+}
+
+// mirrored reports whether the picture is shown mirrored.
+func (m *model) mirrored() bool {
+	return hasMirror(m.filters) != m.mirrorFlip
+}
+
+// apiHandler serves the HTTP API on top of the running program.
+type apiHandler struct {
+	prog *tea.Program
+	done <-chan struct{} // closed when the program ends
+}
+
+// ServeHTTP serves /api/settings: GET returns the settings, PUT, PATCH
+// and POST apply the ones set in the JSON body and return the result.
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var update *apiSettings
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPatch, http.MethodPost:
+		update = &apiSettings{}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(update); err != nil {
+			apiError(w, http.StatusBadRequest, "invalid settings: "+err.Error())
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH, POST")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	reply := make(chan apiSettingsReply, 1)
+	go h.prog.Send(apiSettingsMsg{update: update, reply: reply})
+	select {
+	case res := <-reply:
+		if res.err != nil {
+			apiError(w, http.StatusBadRequest, res.err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res.settings)
+	case <-r.Context().Done():
+	case <-h.done:
+		apiError(w, http.StatusServiceUnavailable, "asciicam is shutting down")
+	}
+}
+
+// apiError writes msg as a JSON error with the given status code.
+func apiError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// frameHandler serves /api/frame: the newest frame as ANSI text, as an
+// HTML page with ?format=html, or with ?stream=1 every frame from now on
+// as ANSI text, each after a cursor home, for viewing with curl -N.
+type frameHandler struct {
+	frames *frameHub
+	done   <-chan struct{}
+}
+
+func (h *frameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if r.URL.Query().Get("stream") != "" {
+		h.stream(w, r)
+		return
+	}
+	f := h.frames.last()
+	if f == nil {
+		apiError(w, http.StatusServiceUnavailable, "no frame yet")
+		return
+	}
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, ansiToHTML(f.Text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, f.Text+"\n")
+}
+
+// stream writes the frames until the client or the program goes away.
+func (h *frameHandler) stream(w http.ResponseWriter, r *http.Request) {
+	frames, stop := h.frames.subscribe()
+	defer stop()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, ansi.EraseEntireScreen)
+	for {
+		select {
+		case f := <-frames:
+			if _, err := io.WriteString(w, ansi.CursorHomePosition+f.Text); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// serveAPI serves the HTTP API, the frames and the audio, if not nil, on
+// addr until the returned function is called.
+func serveAPI(addr string, prog *tea.Program, frames *frameHub, audio *audioStream) (func(), error) {
+	lis, err := listen(addr, "api")
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.Handle("/{$}", viewerHandler{})
+	mux.Handle("/api/view", &viewHandler{frames: frames, audio: audio, done: done})
+	mux.Handle("/api/settings", &apiHandler{prog: prog, done: done})
+	mux.Handle("/api/frame", &frameHandler{frames: frames, done: done})
+	mux.Handle("/api/audio", &audioHandler{audio: audio, done: done})
+	mux.HandleFunc("/api/version", versionHandler)
+	srv := &http.Server{Handler: withServerHeader(mux), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP API server failed", "err", err)
+		}
+	}()
+	slog.Info("serving HTTP API", "addr", lis.Addr())
+	return func() {
+		close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
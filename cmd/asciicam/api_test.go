@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIRefusesOversize(t *testing.T) {
+	m := &model{width: 80, height: 24}
+	done := make(chan struct{})
+	defer close(done)
+	srv := httptest.NewServer(&apiHandler{prog: runSettings(t, m), done: done})
+	defer srv.Close()
+
+	patch := func(body string) (int, apiSettings) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPatch, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var s apiSettings
+		_ = json.NewDecoder(resp.Body).Decode(&s)
+		return resp.StatusCode, s
+	}
+
+	for _, body := range []string{`{"width":4000000000}`, `{"width":100,"height":100000}`} {
+		if code, _ := patch(body); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", body, code, http.StatusBadRequest)
+		}
+		code, s := patch(`{}`)
+		if code != http.StatusOK {
+			t.Fatalf("status %d getting the settings", code)
+		}
+		if *s.Width != 80 || *s.Height != 24 {
+			t.Errorf("%s: settings changed to %dx%d", body, *s.Width, *s.Height)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// audioBitrate is the Opus bitrate of -api-audio, plenty for speech.
+const audioBitrate = "24k"
+
+// audioStream encodes the microphone audio to Ogg Opus with ffmpeg and
+// hands the pages to the listeners of /api/audio. Like an Icecast server
+// it sends every listener the header pages first and then the pages from
+// when it joined, so players can start mid-stream.
+type audioStream struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu      sync.Mutex
+	headers [][]byte // the pages of the Opus headers
+	subs    map[chan []byte]struct{}
+	err     error // why the encoder stopped
+}
+
+// startAudio starts ffmpeg encoding mono 16 bit audio at micRate.
+func startAudio(ctx context.Context) (*audioStream, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-loglevel", "error",
+		"-f", "s16le", "-ar", strconv.Itoa(micRate), "-ac", "1", "-i", "-",
+		"-c:a", "libopus", "-b:a", audioBitrate, "-application", "voip",
+		// a page per 20ms packet keeps the latency low
+		"-page_duration", "20000", "-flush_packets", "1",
+		"-f", "ogg", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	a := &audioStream{cmd: cmd, stdin: stdin, subs: make(map[chan []byte]struct{})}
+	go func() {
+		err := a.read(bufio.NewReader(stdout))
+		_ = cmd.Wait()
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			err = fmt.Errorf("ffmpeg: %s", lastLine(string(msg)))
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			slog.Warn("audio encoder stopped", "err", err)
+		}
+		a.stop(err)
+	}()
+	return a, nil
+}
+
+// Write passes captured audio to the encoder.
+func (a *audioStream) Write(p []byte) (int, error) {
+	return a.stdin.Write(p)
+}
+
+// close ends the encoder, which ends the streams of the listeners.
+func (a *audioStream) close() {
+	_ = a.stdin.Close()
+}
+
+// read splits the encoder output into pages until it ends. The pages
+// before the first one with a granule position are the headers.
+func (a *audioStream) read(r *bufio.Reader) error {
+	for {
+		page, err := readOggPage(r)
+		if err != nil {
+			return err
+		}
+		granule := binary.LittleEndian.Uint64(page[6:14])
+		a.mu.Lock()
+		if granule == 0 && len(a.headers) < 8 {
+			a.headers = append(a.headers, page)
+		}
+		for ch := range a.subs {
+			select {
+			case ch <- page:
+			default:
+				// slow listeners miss a packet rather than fall behind
+			}
+		}
+		a.mu.Unlock()
+	}
+}
+
+// stop closes the streams of the listeners.
+func (a *audioStream) stop(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err == nil {
+		err = io.EOF
+	}
+	a.err = err
+	for ch := range a.subs {
+		close(ch)
+		delete(a.subs, ch)
+	}
+}
+
+// subscribe returns the header pages and a channel receiving the pages
+// from now on, closed when the encoder stops, and the function that ends
+// the subscription.
+func (a *audioStream) subscribe() ([][]byte, <-chan []byte, func(), error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return nil, nil, nil, a.err
+	}
+	ch := make(chan []byte, 64)
+	a.subs[ch] = struct{}{}
+	return a.headers, ch, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if _, ok := a.subs[ch]; ok {
+			delete(a.subs, ch)
+			close(ch)
+		}
+	}, nil
+}
+
+// readOggPage reads the next page of an Ogg stream.
+func readOggPage(r *bufio.Reader) ([]byte, error) {
+	head := make([]byte, 27)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if string(head[:4]) != "OggS" {
+		return nil, errors.New("invalid Ogg page")
+	}
+	segments := make([]byte, head[26])
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, s := range segments {
+		size += int(s)
+	}
+	page := make([]byte, 0, len(head)+len(segments)+size)
+	page = append(append(page, head...), segments...)
+	page = page[:cap(page)]
+	if _, err := io.ReadFull(r, page[len(head)+len(segments):]); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// oggPackets splits an Ogg page into the packets it carries. partial is
+// the start of a packet the page before left unfinished; the start of a
+// packet continued on the next page is returned as the new partial.
+func oggPackets(page, partial []byte) (packets [][]byte, rest []byte) {
+	segments := page[27 : 27+int(page[26])]
+	data := page[27+len(segments):]
+	if page[5]&1 == 0 {
+		// not a continuation, the rest of the previous page is lost
+		partial = nil
+	}
+	start := 0
+	for _, s := range segments {
+		end := start + int(s)
+		partial = append(partial, data[start:end]...)
+		start = end
+		if s < 255 {
+			packets = append(packets, partial)
+			partial = nil
+		}
+	}
+	return packets, partial
+}
+
+// audioHandler serves /api/audio: the microphone as Ogg Opus, for
+// <audio> elements and players like mpv.
+type audioHandler struct {
+	audio *audioStream // nil without -api-audio
+	done  <-chan struct{}
+}
+
+func (h *audioHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.audio == nil {
+		apiError(w, http.StatusNotFound, "no audio, set -mic and -api-audio")
+		return
+	}
+	headers, pages, stop, err := h.audio.subscribe()
+	if err != nil {
+		apiError(w, http.StatusServiceUnavailable, "audio stopped: "+err.Error())
+		return
+	}
+	defer stop()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "audio/ogg")
+	w.Header().Set("Cache-Control", "no-store")
+	for _, p := range headers {
+		if _, err := w.Write(p); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case p, ok := <-pages:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(p); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+	}
+}
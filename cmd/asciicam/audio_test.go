@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// oggPage builds an Ogg page carrying data split into segments as given,
+// continuing the packet of the page before if continued is set.
+func oggPage(continued bool, segments []byte, data []byte) []byte {
+	page := make([]byte, 27, 27+len(segments)+len(data))
+	copy(page, "OggS")
+	if continued {
+		page[5] = 1
+	}
+	page[26] = byte(len(segments))
+	return append(append(page, segments...), data...)
+}
+
+func TestOggPackets(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, 300)
+
+	// two packets, the second continued on the next page
+	packets, partial := oggPackets(oggPage(false, []byte{3, 255}, append([]byte("abc"), long[:255]...)), nil)
+	if len(packets) != 1 || string(packets[0]) != "abc" {
+		t.Fatalf("first page: packets %q", packets)
+	}
+	packets, partial = oggPackets(oggPage(true, []byte{45, 2}, append(long[255:], "de"...)), partial)
+	if len(packets) != 2 || !bytes.Equal(packets[0], long) || string(packets[1]) != "de" || partial != nil {
+		t.Fatalf("second page: packets %q, partial %q", packets, partial)
+	}
+
+	// a page that isn't a continuation drops an unfinished packet
+	_, partial = oggPackets(oggPage(false, []byte{255}, long[:255]), nil)
+	packets, _ = oggPackets(oggPage(false, []byte{1}, []byte("f")), partial)
+	if len(packets) != 1 || string(packets[0]) != "f" {
+		t.Fatalf("packets %q after a lost continuation", packets)
+	}
+}
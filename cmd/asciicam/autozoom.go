@@ -0,0 +1,72 @@
+package main
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+)
+
+// autoZoomLost is how long auto-zoom waits for a lost face before it
+// zooms out to the whole frame.
+const autoZoomLost = 2 * time.Second
+
+// autoZoom moves the crop window to keep the largest face centered, see
+// -auto-zoom. The zoom and pan follow the face through two stages of
+// exponential smoothing, which irons out the jitter of the detections.
+type autoZoom struct {
+	on      bool
+	size    float64       // face height as a share of the picture height
+	damping time.Duration // time constant of the smoothing
+	target  [3]float64    // smoothed zoom, pan x and pan y to move to
+	seen    time.Time     // last frame with a face
+	last    time.Time     // last frame the crop was moved at
+}
+
+// followFace moves the crop window towards the face, the largest face in
+// the frame captured at now, empty if there was none.
+func (m *model) followFace(fc, bounds image.Rectangle, now time.Time) {
+	a := &m.autoZoom
+	if !a.on || bounds.Empty() {
+		return
+	}
+	// the smoothing starts over after a pause
+	dt := now.Sub(a.last)
+	if a.last.IsZero() || dt > time.Second {
+		a.target = [3]float64{m.zoom, m.panX, m.panY}
+		dt = 0
+	}
+	a.last = now
+
+	want := [3]float64{1, 0.5, 0.5}
+	switch {
+	case !fc.Empty():
+		a.seen = now
+		c := fc.Min.Add(fc.Max).Div(2)
+		want = [3]float64{
+			math.Min(8, math.Max(1, a.size*float64(bounds.Dy())/float64(fc.Dy()))),
+			float64(c.X-bounds.Min.X) / float64(bounds.Dx()),
+			float64(c.Y-bounds.Min.Y) / float64(bounds.Dy()),
+		}
+	case now.Sub(a.seen) < autoZoomLost:
+		want = a.target
+	}
+
+	k := 1 - math.Exp(-dt.Seconds()/max(a.damping, time.Millisecond).Seconds())
+	cur := [3]float64{m.zoom, m.panX, m.panY}
+	for i := range cur {
+		a.target[i] += (want[i] - a.target[i]) * k
+		cur[i] += (a.target[i] - cur[i]) * k
+	}
+	_, m.panX, m.panY = filter.CropRect(image.Rectangle{}, cur[0], cur[1], cur[2])
+	m.zoom = cur[0]
+}
+
+// stopAutoZoom turns auto-zoom off, leaving the crop window where it is.
+func (m *model) stopAutoZoom() {
+	if m.autoZoom.on {
+		m.autoZoom.on = false
+		m.setNotice("auto-zoom: off")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"math/rand"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// benchSizes are the camera resolutions the benchmarks run at.
+var benchSizes = []image.Point{{320, 180}, {640, 360}, {1280, 720}}
+
+// benchFlags are the command line options of the bench command.
+type benchFlags struct {
+	time          *time.Duration
+	width, height *uint
+}
+
+// newBenchFlags declares the options of the bench command.
+func newBenchFlags() (*flag.FlagSet, *benchFlags) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	return fs, &benchFlags{
+		time:   fs.Duration("time", time.Second, "Duration of each benchmark"),
+		width:  fs.Uint("width", 120, "output width"),
+		height: fs.Uint("height", 40, "output height"),
+	}
+}
+
+// runBench runs the frame converters and renderers over synthetic frames
+// and prints the achieved frames per second and allocations per frame.
+func runBench(args []string) error {
+	fs, o := newBenchFlags()
+	d, w, h := o.time, o.width, o.height
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "benchmark\tsize\tframes/s\tallocs/frame\tbytes/frame")
+	report := func(name string, size image.Point, fn func()) {
+		fps, allocs, bytes := measure(*d, fn)
+		fmt.Fprintf(tw, "%s\t%dx%d\t%.1f\t%.1f\t%.0f\n", name, size.X, size.Y, fps, allocs, bytes)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range benchSizes {
+		yuyv := make([]byte, size.X*size.Y*2)
+		rgb := make([]byte, size.X*size.Y*3)
+		rng.Read(yuyv)
+		rng.Read(rgb)
+		img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		source.YUYVToRGBA(img, yuyv)
+
+		report("yuyv to rgba", size, func() { source.YUYVToRGBA(img, yuyv) })
+		report("rgb to rgba", size, func() { source.RGBToRGBA(img, rgb) })
+
+		var scaler filter.Scaler
+		report("scale", size, func() { scaler.Scale(img, img.Rect, *w, *h) })
+		report("chroma key", size, func() {
+			filter.ChromaKey(scaler.Scale(img, img.Rect, *w, *h), colorful.Color{G: 1}, 0.13)
+		})
+
+		o := render.Options{Profile: termenv.TrueColor, Pixels: render.Charsets[0].Pixels}
+		for _, m := range render.Modes() {
+			scaled := scaler.Scale(img, img.Rect, *w*m.CellW, *h*m.CellH)
+			r := m.New(o)
+			report("render "+m.Name, size, func() {
+				r.Render(scaled, int(*w), int(*h))
+			})
+		}
+
+		s := &frameSettings{width: *w, height: *h, profile: termenv.TrueColor}
+		report("yuyv fast path", size, func() { renderYUYV(s, yuyv, size.X, img.Rect) })
+	}
+	return tw.Flush()
+}
+
+// measure calls fn repeatedly for d and returns the calls per second and
+// the heap allocations and bytes per call.
+func measure(d time.Duration, fn func()) (float64, float64, float64) {
+	fn()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	n := 0
+	for time.Since(start) < d {
+		fn()
+		n++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return float64(n) / elapsed.Seconds(),
+		float64(after.Mallocs-before.Mallocs) / float64(n),
+		float64(after.TotalAlloc-before.TotalAlloc) / float64(n)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/figlet"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+const (
+	boothShots     = 4               // pictures on a strip
+	boothCountdown = 3 * time.Second // time to strike a pose
+	boothGap       = time.Second     // time between the pictures
+	boothShow      = 8 * time.Second // time the finished strip is shown
+)
+
+// boothCaptions are the date formats of the caption of the strip, the
+// first one that fits is used.
+var boothCaptions = []string{"2006-01-02 15:04", "2006-01-02", "Jan 2", "2"}
+
+// boothState is the step of the photo booth.
+type boothState int
+
+const (
+	boothOff boothState = iota
+	boothCountingDown
+	boothShooting
+	boothShowing
+)
+
+// photoBooth takes a series of pictures after a countdown and puts them
+// on a photo strip, like the booths at train stations.
+type photoBooth struct {
+	state  boothState
+	next   time.Time     // end of the countdown or time of the next picture
+	shots  []*image.RGBA // copies of the raw frames taken
+	strip  []string      // terminal view of the finished strip
+	until  time.Time     // end of showing the strip
+	digits [3][]string   // countdown digits in the banner font, 1 to 3
+}
+
+// newPhotoBooth renders the countdown digits in font.
+func newPhotoBooth(font *figlet.Font) photoBooth {
+	var b photoBooth
+	for i := range b.digits {
+		b.digits[i] = padBlock(font.Render(strconv.Itoa(i + 1)))
+	}
+	return b
+}
+
+// startBooth begins the countdown before the first picture.
+func (m *model) startBooth() {
+	if m.paused {
+		m.setNotice("resume to use the photo booth")
+		return
+	}
+	m.booth.state = boothCountingDown
+	m.booth.next = time.Now().Add(boothCountdown)
+	m.booth.shots = m.booth.shots[:0]
+}
+
+// boothFrame advances the photo booth with the frame just shown.
+func (m *model) boothFrame(now time.Time) {
+	b := &m.booth
+	switch b.state {
+	case boothCountingDown:
+		if now.Before(b.next) {
+			return
+		}
+		b.state = boothShooting
+		fallthrough
+	case boothShooting:
+		raw := m.rawFrame()
+		if now.Before(b.next) || raw == nil {
+			return
+		}
+		// raw is a ring buffer frame that is reused
+		shot := image.NewRGBA(raw.Rect)
+		copy(shot.Pix, raw.Pix)
+		b.shots = append(b.shots, shot)
+		b.next = now.Add(boothGap)
+		if len(b.shots) < boothShots {
+			return
+		}
+		base, err := m.saveStrip(now)
+		if err != nil {
+			m.setNotice(err.Error())
+			b.state = boothOff
+			return
+		}
+		m.setNotice("saved " + base)
+		m.snapshotSaved(base)
+		b.strip = strings.Split(m.photoStrip(m.boothShotSize(), now), "\n")
+		b.state, b.until = boothShowing, now.Add(boothShow)
+	case boothShowing:
+		if now.After(b.until) {
+			b.state = boothOff
+		}
+	}
+}
+
+// saveStrip writes the photo strip of the pictures taken in full size into
+// the snapshot directory: as text to .ans and .html and drawn to .png.
+func (m *model) saveStrip(now time.Time) (string, error) {
+	if err := os.MkdirAll(m.snapshots, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+	strip := m.photoStrip(image.Pt(int(max(m.width, minWidth)), int(max(m.height, minHeight))), now)
+	img := render.Image(strip, color.RGBA{0xcc, 0xcc, 0xcc, 0xff}, color.RGBA{0, 0, 0, 0xff})
+	base := filepath.Join(m.snapshots, "asciicam-booth-"+now.Format("20060102-150405.000"))
+	if err := writeFrameFiles(base, img, strip); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// boothShotSize returns the size in cells of the pictures of the strip
+// shown on screen, small enough for the strip to fit the output.
+func (m *model) boothShotSize() image.Point {
+	w, h := int(max(m.width, minWidth)), int(max(m.height, minHeight))
+	if m.showStatus {
+		h--
+	}
+	// a row of margin around and between the pictures, and the caption
+	sh := max(1, (h-boothShots-2)/boothShots)
+	return image.Pt(max(1, w*sh/int(max(m.height, minHeight))), sh)
+}
+
+// photoStrip renders the pictures taken in size cells each and stacks
+// them with white margins and a caption with the date below.
+func (m *model) photoStrip(size image.Point, now time.Time) string {
+	white := termenv.Style{}
+	caption := termenv.Style{}
+	if m.profile != termenv.Ascii {
+		white = white.Foreground(m.profile.Color("#ffffff"))
+		caption = caption.Foreground(m.profile.Color("#000000")).Background(m.profile.Color("#ffffff"))
+	}
+	margin := white.Styled(strings.Repeat("█", size.X+2))
+	side := white.Styled("█")
+
+	lines := []string{margin}
+	for _, shot := range m.booth.shots {
+		for _, l := range strings.Split(m.renderShot(shot, size), "\n") {
+			if pad := size.X - ansi.StringWidth(l); pad > 0 {
+				l += strings.Repeat(" ", pad)
+			}
+			lines = append(lines, side+l+side)
+		}
+		lines = append(lines, margin)
+	}
+	var text string
+	for _, layout := range boothCaptions {
+		if text = now.Format(layout); len(text) <= size.X+2 {
+			break
+		}
+	}
+	text = ansi.Truncate(text, size.X+2, "")
+	pad := size.X + 2 - ansi.StringWidth(text)
+	text = strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+	return strings.Join(append(lines, caption.Styled(text)), "\n")
+}
+
+// renderShot renders a picture of the booth in size cells with the
+// current mode, greenscreen and filters. Sixel pictures can't be put on a
+// strip of text, they are drawn in ANSI blocks instead.
+func (m *model) renderShot(img *image.RGBA, size image.Point) string {
+	s := m.frameSettings()
+	s.width, s.height = uint(size.X), uint(size.Y)
+	s.pip, s.histMode, s.split, s.dim, s.fast = false, 0, false, false, false
+	s.detectMotion, s.motion = false, motionOff
+	if render.Modes()[s.renderer].Name == "sixel" {
+		s.renderer, _ = render.Index("ansi")
+	}
+	scaled := scaledFrames.Get().(*filter.Scaler)
+	defer scaledFrames.Put(scaled)
+	m.filter.filter(img, &s, scaled)
+	return renderFrame(&s, scaled.Image())
+}
+
+// boothView draws the countdown, the number of the next picture or the
+// finished strip.
+func (m *model) boothView(lines []string) {
+	b := &m.booth
+	switch b.state {
+	case boothCountingDown:
+		left := int(time.Until(b.next)/time.Second) + 1
+		m.drawCentered(lines, b.digits[min(max(left, 1), len(b.digits))-1])
+	case boothShooting:
+		shot := termenv.String(fmt.Sprintf(" ● %d/%d ", len(b.shots)+1, boothShots)).Reverse().String()
+		m.drawCorner(lines, "top-right", []string{shot})
+	case boothShowing:
+		m.drawCentered(lines, b.strip)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+)
+
+const (
+	// calibSamples is the number of background samples taken.
+	calibSamples = 101
+	// bgSampleIndex is the sample used as the greenscreen background.
+	bgSampleIndex = 40
+	// calibCountdown is the time to step out of the frame.
+	calibCountdown = 3 * time.Second
+)
+
+// calibState is the step of the background calibration flow.
+type calibState int
+
+const (
+	calibOff calibState = iota
+	calibPrompt
+	calibCountingDown
+	calibCapturing
+)
+
+// startCalibration begins the countdown before the background samples
+// are taken.
+func (m *model) startCalibration() {
+	m.calib = calibCountingDown
+	m.calibAt = time.Now().Add(calibCountdown)
+	m.samples = 0
+}
+
+// calibrate advances the calibration flow with a freshly captured frame.
+// After the last sample the new background is used for the greenscreen.
+func (m *model) calibrate(img *image.RGBA) error {
+	switch m.calib {
+	case calibCountingDown:
+		if time.Now().Before(m.calibAt) {
+			return nil
+		}
+		m.calib = calibCapturing
+		fallthrough
+	case calibCapturing:
+		if err := saveSample(m.sample, m.samples, img); err != nil {
+			return err
+		}
+		if m.samples == bgSampleIndex {
+			// img is a reused capture buffer
+			bg := image.NewRGBA(img.Rect)
+			copy(bg.Pix, img.Pix)
+			m.bgSample = bg
+		}
+		m.samples++
+		if m.samples < calibSamples {
+			return nil
+		}
+
+		// switch into greenscreen mode with the fresh background
+		m.calib = calibOff
+		m.screen = true
+		m.keyed = false
+		m.setNotice("background calibrated")
+	}
+	return nil
+}
+
+// calibView draws the current calibration step centered over lines.
+func (m *model) calibView(lines []string) {
+	var text []string
+	switch m.calib {
+	case calibPrompt:
+		text = []string{"Background calibration", "", "Press Enter, then step out of the frame"}
+	case calibCountingDown:
+		left := time.Until(m.calibAt).Seconds()
+		text = []string{"Background calibration", "", fmt.Sprintf("Step out of the frame ... %.0f", max(0, left)+0.5)}
+	case calibCapturing:
+		const barWidth = 30
+		done := m.samples * barWidth / calibSamples
+		text = []string{"Background calibration", "",
+			fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("#", done), strings.Repeat(".", barWidth-done), m.samples, calibSamples)}
+	default:
+		return
+	}
+	drawBox(lines, int(m.width), text)
+}
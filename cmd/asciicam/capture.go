@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image"
+	"sync"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// frameBuf is a capture buffer holding either the converted frame in img
+// or, for the YUYV fast path, the raw frame in yuyv.
+type frameBuf struct {
+	img  *image.RGBA
+	yuyv []byte
+	raw  bool      // the frame is in yuyv
+	read time.Time // when the frame was read from the source
+	sent time.Time // when the frame was passed on by capture
+	// took is the time reading the frame took, dropped the number of
+	// frames newer ones replaced since the frame before, see latestFrame
+	took    time.Duration
+	dropped int
+}
+
+// framePool recycles the capture buffers of one frame size. The pipeline
+// stages take buffers from it and return them once a frame is done, so
+// only as many buffers are allocated as there are frames in flight.
+type framePool struct {
+	pool sync.Pool
+}
+
+// newFramePool returns a pool of width×height buffers, with room for the
+// raw YUYV frame if raw is set.
+func newFramePool(width, height int, raw bool) *framePool {
+	p := &framePool{}
+	p.pool.New = func() any {
+		buf := &frameBuf{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+		if raw {
+			buf.yuyv = make([]byte, width*height*2)
+		}
+		return buf
+	}
+	return p
+}
+
+// Get returns a free buffer, allocating one if none is left.
+func (p *framePool) Get() *frameBuf {
+	return p.pool.Get().(*frameBuf)
+}
+
+// Put returns buf to the pool.
+func (p *framePool) Put(buf *frameBuf) {
+	p.pool.Put(buf)
+}
+
+// latestFrame holds the newest captured frame until the next stage picks
+// it up. A newer frame replaces a waiting one, so a slow renderer skips
+// frames instead of falling behind the camera.
+type latestFrame struct {
+	mu      sync.Mutex
+	buf     *frameBuf
+	ready   chan struct{}
+	free    *framePool
+	dropped int // frames replaced since the last take
+}
+
+// put stores buf as the newest frame and recycles the frame it replaces.
+func (l *latestFrame) put(buf *frameBuf) {
+	l.mu.Lock()
+	if l.buf != nil {
+		l.free.Put(l.buf)
+		l.dropped++
+	}
+	l.buf = buf
+	l.mu.Unlock()
+
+	select {
+	case l.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns the newest frame, nil if there is none.
+func (l *latestFrame) take() *frameBuf {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	buf := l.buf
+	l.buf = nil
+	if buf != nil {
+		buf.dropped, l.dropped = l.dropped, 0
+	}
+	return buf
+}
+
+// capture reads frames from src into buffers from free and passes
+// them to out until the source fails or ends. Only the newest frame is
+// passed on, at most one per interval. Frames are read raw while wantRaw
+// returns true and src supports it. wd restarts src when it stalls. out is
+// closed when capture returns.
+func capture(src source.Source, free *framePool, out chan<- *frameBuf, interval time.Duration, wantRaw func() bool, wd *stallWatchdog) error {
+	rawSrc, _ := src.(source.YUYVSource)
+	latest := &latestFrame{ready: make(chan struct{}, 1), free: free}
+
+	// pass frames on independently of the camera so reading never waits
+	// for the later stages
+	go func() {
+		defer close(out)
+		var next time.Time
+		for range latest.ready {
+			// newer frames keep replacing the waiting one meanwhile
+			time.Sleep(time.Until(next))
+			if buf := latest.take(); buf != nil {
+				buf.sent = time.Now()
+				next = buf.sent.Add(interval)
+				out <- buf
+			}
+		}
+	}()
+	defer close(latest.ready)
+
+	for {
+		buf := free.Get()
+		var ok bool
+		var err error
+		buf.raw = rawSrc != nil && wantRaw()
+		start := time.Now()
+		if buf.raw {
+			ok, err = rawSrc.ReadYUYV(buf.yuyv)
+		} else {
+			ok, err = src.ReadFrame(buf.img)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			free.Put(buf)
+			wd.idle(src, time.Now())
+			continue
+		}
+		buf.read = time.Now()
+		buf.took = buf.read.Sub(start)
+		wd.frame(buf.read)
+		stats.add(stageCapture, buf.took)
+		latest.put(buf)
+	}
+}
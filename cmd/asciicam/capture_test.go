@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// runCapture runs capture on src until it returns and reports the number
+// of frames that came out of it and its error.
+func runCapture(t *testing.T, src source.Source, wd *stallWatchdog) (int, error) {
+	t.Helper()
+	pool := newFramePool(8, 6, false)
+	out := make(chan *frameBuf, 1)
+	frames := make(chan int)
+	go func() {
+		n := 0
+		for buf := range out {
+			pool.Put(buf)
+			n++
+		}
+		frames <- n
+	}()
+
+	done := make(chan error)
+	go func() { done <- capture(src, pool, out, 0, func() bool { return false }, wd) }()
+	select {
+	case err := <-done:
+		return <-frames, err
+	case <-time.After(10 * time.Second):
+		t.Fatal("capture didn't return")
+		return 0, nil
+	}
+}
+
+func TestCaptureEnds(t *testing.T) {
+	for _, tc := range []struct {
+		script string
+		err    error
+	}{
+		{"frame*3,timeout,frame,eof", io.EOF},
+		{"frame,timeout*2,frame*2,error", source.ErrFake},
+	} {
+		script, err := source.ParseFakeScript(tc.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := &source.Fake{Script: script, Interval: time.Millisecond}
+		n, err := runCapture(t, src, &stallWatchdog{send: func(tea.Msg) {}})
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: capture returned %v, want %v", tc.script, err, tc.err)
+		}
+		if n == 0 {
+			t.Errorf("%s: no frames passed on", tc.script)
+		}
+	}
+}
+
+func TestCaptureRestartsStalledSource(t *testing.T) {
+	script, err := source.ParseFakeScript("frame,timeout*1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &source.Fake{Script: script, Interval: time.Millisecond}
+	var msgs []stallMsg
+	wd := &stallWatchdog{timeout: 20 * time.Millisecond, send: func(msg tea.Msg) {
+		msgs = append(msgs, msg.(stallMsg))
+		// the restart starts the script over with a frame; shut down then
+		if len(msgs) == 2 {
+			_ = src.Close()
+		}
+	}}
+	n, err := runCapture(t, src, wd)
+	if err != io.EOF {
+		t.Errorf("capture returned %v, want EOF after Close", err)
+	}
+	want := []stallMsg{{stalled: true, restarted: true}, {}}
+	if !slices.Equal(msgs, want) {
+		t.Errorf("watchdog sent %+v, want %+v", msgs, want)
+	}
+	if n == 0 {
+		t.Error("no frames passed on")
+	}
+}
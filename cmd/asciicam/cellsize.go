@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/term"
+)
+
+// parseCellSize returns the pixel size of a terminal cell given with
+// -cell-size: WxH, or auto to measure it with term.CellSize if probe is
+// set. The size is zero if it stays unknown.
+func parseCellSize(value string, probe bool) (image.Point, error) {
+	if value != "auto" {
+		var cell image.Point
+		if _, err := fmt.Sscanf(value, "%dx%d", &cell.X, &cell.Y); err != nil || cell.X <= 0 || cell.Y <= 0 {
+			return image.Point{}, fmt.Errorf("invalid cell size %q, want WxH or auto", value)
+		}
+		return cell, nil
+	}
+	if !probe {
+		return image.Point{}, nil
+	}
+	cell, err := term.CellSize(os.Stdin, os.Stdout)
+	if err != nil {
+		slog.Debug("cell size unknown", "err", err)
+		return image.Point{}, nil
+	}
+	slog.Debug("measured cell size", "width", cell.X, "height", cell.Y)
+	return cell, nil
+}
+
+// useCellSize draws sixel images at the pixel size of cell and returns
+// its aspect, the height relative to the width. Unknown cells keep the
+// defaults.
+func useCellSize(cell image.Point) float64 {
+	if cell == (image.Point{}) {
+		return asciicam.CellAspect
+	}
+	render.SetSixelCell(uint(cell.X), uint(cell.Y))
+	return float64(cell.Y) / float64(cell.X)
+}
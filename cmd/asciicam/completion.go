@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/figlet"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// commands are the names of the subcommands, for completion.
+var commands = []string{"run", "gen", "serve", "devices", "replay", "convert", "export", "ramp", "bench", "version", "completion", "help"}
+
+// completionScripts hook the shells up to the hidden __complete command,
+// falling back to file names when it has no candidates.
+var completionScripts = map[string]string{
+	"bash": `_asciicam() {
+	local IFS=$'\n'
+	COMPREPLY=($(asciicam __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _asciicam asciicam
+`,
+	"zsh": `#compdef asciicam
+_asciicam() {
+	local -a candidates
+	candidates=("${(@f)$(asciicam __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef _asciicam asciicam
+`,
+	"fish": `function __asciicam_complete
+	set -l candidates (asciicam __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)
+	if test (count $candidates) -gt 0
+		printf '%s\n' $candidates
+	else
+		__fish_complete_path (commandline -ct)
+	end
+end
+complete -c asciicam -f -a '(__asciicam_complete)'
+`,
+}
+
+// runCompletion prints the completion script for a shell.
+func runCompletion(args []string) error {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		return fmt.Errorf("usage: asciicam completion bash|zsh|fish")
+	}
+	fmt.Print(completionScripts[args[0]])
+	return nil
+}
+
+// commandFlags returns the flags of a subcommand, nil if it has none.
+func commandFlags(cmd string) *flag.FlagSet {
+	switch cmd {
+	case "run", "gen", "serve":
+		fs, _ := newRunFlags(cmd)
+		return fs
+	case "replay":
+		fs, _ := newReplayFlags()
+		return fs
+	case "convert":
+		fs, _ := newConvertFlags()
+		return fs
+	case "export":
+		fs, _ := newExportFlags()
+		return fs
+	case "ramp":
+		fs, _ := newRampFlags()
+		return fs
+	case "bench":
+		fs, _ := newBenchFlags()
+		return fs
+	}
+	return nil
+}
+
+// runComplete prints the candidates for the last of the given words, the
+// arguments typed so far, one per line.
+func runComplete(words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, words := words[len(words)-1], words[:len(words)-1]
+
+	cmd := "run"
+	if len(words) > 0 && !strings.HasPrefix(words[0], "-") {
+		cmd = words[0]
+	} else if len(words) == 0 && !strings.HasPrefix(cur, "-") {
+		printMatches(cur, "", commands)
+		return
+	}
+	if cmd == "completion" {
+		printMatches(cur, "", []string{"bash", "fish", "zsh"})
+		return
+	}
+	fs := commandFlags(cmd)
+	if fs == nil {
+		return
+	}
+
+	// value of a flag: "-flag value", "-flag=value", or bash splitting
+	// "-flag=value" into "-flag", "=", "value"
+	name, prefix := "", ""
+	switch n := len(words); {
+	case strings.HasPrefix(cur, "-") && strings.Contains(cur, "="):
+		name, cur, _ = strings.Cut(cur, "=")
+		prefix = name + "="
+	case cur == "=" && n > 0:
+		name, cur = words[n-1], ""
+	case n > 1 && words[n-1] == "=":
+		name = words[n-2]
+	case n > 0 && strings.HasPrefix(words[n-1], "-") && !strings.Contains(words[n-1], "="):
+		if f := fs.Lookup(strings.TrimLeft(words[n-1], "-")); f != nil && !isBoolFlag(f) {
+			name = words[n-1]
+		}
+	}
+	if name = strings.TrimLeft(name, "-"); name != "" {
+		if name == "filters" {
+			// complete the last filter of the list
+			i := strings.LastIndex(cur, ",") + 1
+			prefix, cur = prefix+cur[:i], cur[i:]
+		}
+		printMatches(cur, prefix, flagValues(name, words))
+		return
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+		printMatches(cur, "", names)
+	}
+}
+
+// flagValues returns the candidate values of a run flag. File name flags
+// have none, so the shell completes file names.
+func flagValues(name string, words []string) []string {
+	switch name {
+	case "dev":
+		devs, _ := filepath.Glob("/dev/video*")
+		return devs
+	case "preset":
+		return completionConfig(words).presetNames()
+	case "mode":
+		var names []string
+		for _, m := range render.Modes() {
+			names = append(names, m.Name)
+		}
+		return names
+	case "charset":
+		var names []string
+		for _, cs := range render.Charsets {
+			names = append(names, cs.Name)
+		}
+		for name := range completionConfig(words).Charsets {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		return names
+	case "filters":
+		return filter.Names()
+	case "source":
+		return []string{"webcam", "gst", "fake"}
+	case "record-format":
+		return []string{"cast", "gif", "mp4"}
+	case "fps-pos", "clock-pos", "text-pos", "histogram-pos", "qr-pos", "mic-pos":
+		return corners
+	case "overlay-histogram":
+		return histogramModes
+	case "motion":
+		return motionModes
+	case "effect":
+		return boothEffects
+	case "cb":
+		return uiPaletteNames()
+	case "theme":
+		return exportThemeNames()
+	case "color-profile":
+		return []string{"truecolor", "256", "16", "mono"}
+	case "mic":
+		return micSources
+	case "stereo":
+		return stereoModes
+	case "webhook-kind":
+		return webhookKinds
+	case "webhook-files":
+		return webhookFiles
+	case "white-balance":
+		return []string{"off", "auto"}
+	case "night-tint":
+		return nightTints
+	case "idle-style":
+		return idleStyles
+	case "exposure":
+		return exposureNames()
+	case "privacy":
+		return []string{"off", "faces"}
+	case "privacy-style":
+		return privacyStyles
+	case "banner-pos":
+		return append([]string{"center"}, corners...)
+	case "banner-font":
+		return figlet.Fonts()
+	case "log-level":
+		return []string{"debug", "info", "warn", "error"}
+	}
+	return nil
+}
+
+// completionConfig returns the config file given with -config so far, or
+// the default one.
+func completionConfig(words []string) config {
+	path := defaultConfigPath()
+	for i, w := range words {
+		name, v, ok := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if !strings.HasPrefix(w, "-") || name != "config" {
+			continue
+		}
+		if ok {
+			path = v
+		} else if i+1 < len(words) {
+			path = words[i+1]
+		}
+	}
+	cfg, _ := loadConfig(path)
+	return cfg
+}
+
+// printMatches prints the candidates starting with cur, each with prefix.
+func printMatches(cur, prefix string, candidates []string) {
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) {
+			fmt.Println(prefix + c)
+		}
+	}
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// config is the contents of the config file.
+type config struct {
+	// Keys maps action names to the keys bound to them. An empty list
+	// disables the action.
+	Keys map[string][]string
+	// Options holds defaults for the command line options by flag name,
+	// taken from the top level of the file.
+	Options map[string]any
+	// Presets are named sets of options selected with -preset. They
+	// override Options.
+	Presets map[string]map[string]any
+	// Charsets are additional ramps by name, from dark to bright, e.g.
+	// made for a font with asciicam ramp.
+	Charsets map[string]string
+}
+
+// defaultConfigPath returns ~/.config/asciicam/config.toml or the
+// platform equivalent.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "asciicam", "config.toml")
+}
+
+// loadConfig reads the config file at path. A missing file results in an
+// empty config.
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path == "" {
+		return cfg, nil
+	}
+	var top map[string]toml.Primitive
+	md, err := toml.DecodeFile(path, &top)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	for name, v := range top {
+		switch name {
+		case "keys":
+			err = md.PrimitiveDecode(v, &cfg.Keys)
+		case "presets":
+			err = md.PrimitiveDecode(v, &cfg.Presets)
+		case "charsets":
+			err = md.PrimitiveDecode(v, &cfg.Charsets)
+		default:
+			if cfg.Options == nil {
+				cfg.Options = make(map[string]any)
+			}
+			var opt any
+			err = md.PrimitiveDecode(v, &opt)
+			cfg.Options[name] = opt
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// registerCharsets makes the charsets of the file available to -charset
+// and the charset keys, in the order of their names.
+func (c config) registerCharsets() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Charsets)) {
+		if err := render.RegisterCharset(name, []rune(c.Charsets[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envPrefix starts the names of the environment variables that set
+// options, e.g. ASCIICAM_MAX_FPS for -max-fps.
+const envPrefix = "ASCIICAM_"
+
+// envName returns the environment variable for the flag name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets the flags of set that were not given on the command line
+// to the values of their environment variables.
+func applyEnv(set *flag.FlagSet) error {
+	given := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	var err error
+	set.VisitAll(func(f *flag.Flag) {
+		v, ok := os.LookupEnv(envName(f.Name))
+		if !ok || given[f.Name] || err != nil {
+			return
+		}
+		if e := set.Set(f.Name, v); e != nil {
+			err = fmt.Errorf("invalid value for %s: %w", envName(f.Name), e)
+		}
+	})
+	return err
+}
+
+// builtinPresets are available without a config file. Presets of the
+// same name in the file replace them.
+var builtinPresets = map[string]map[string]any{
+	// a clean picture for video calls
+	"meeting": {"mode": "ansi", "filters": "mirror,contrast=1.2", "max-fps": 30},
+	// green phosphor terminal
+	"retro": {"mode": "ascii", "color": "#33ff33", "charset": "detailed", "filters": "contrast=1.3"},
+	// few, small, diffed frames for slow links
+	"lowbandwidth": {"mode": "mono", "charset": "simple", "width": 80, "height": 24, "max-fps": 10, "diff": true},
+}
+
+// preset returns the options of the named preset.
+func (c config) preset(name string) (map[string]any, bool) {
+	if p, ok := c.Presets[name]; ok {
+		return p, true
+	}
+	p, ok := builtinPresets[name]
+	return p, ok
+}
+
+// presetNames returns the names of the presets of the file and the built
+// in ones, sorted.
+func (c config) presetNames() []string {
+	names := slices.Collect(maps.Keys(builtinPresets))
+	for name := range c.Presets {
+		if _, ok := builtinPresets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// applyOptions sets the flags of set that were not given on the command
+// line or in the environment to the values from the file, with those of
+// the named preset taking precedence.
+func (c config) applyOptions(set *flag.FlagSet, preset string) error {
+	opts := maps.Clone(c.Options)
+	if preset != "" {
+		p, ok := c.preset(preset)
+		if !ok {
+			return fmt.Errorf("unknown preset %q", preset)
+		}
+		if opts == nil {
+			opts = make(map[string]any)
+		}
+		maps.Copy(opts, p)
+	}
+
+	given := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for name, v := range opts {
+		if set.Lookup(name) == nil || name == "config" || name == "preset" {
+			return fmt.Errorf("unknown option %q in config", name)
+		}
+		if given[name] {
+			continue
+		}
+		if err := set.Set(name, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("invalid option %q in config: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setConfigOption sets the top level option name of the config file at
+// path to value, given in TOML, creating the file if needed. The line of
+// the option is replaced or a new one added before the first table, so the
+// rest of the file and its comments stay as they are.
+func setConfigOption(path, name, value string) error {
+	if path == "" {
+		return errors.New("no config file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	set := name + " = " + value
+	end := len(lines) // end of the top level
+	found := false
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "[") {
+			end = i
+			break
+		}
+		if key, _, ok := strings.Cut(l, "="); ok && strings.TrimSpace(key) == name {
+			lines[i] = set
+			found = true
+		}
+	}
+	if !found {
+		// keep the blank line in front of the table
+		at := end
+		for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+		lines = slices.Insert(lines, at, set)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/term"
+	xterm "golang.org/x/term"
+)
+
+// convertFlags are the command line options of the convert command.
+type convertFlags struct {
+	out           *string
+	width, height *uint
+	cellSize      *string
+	mode          *string
+	charset       *string
+	profile       *string
+	filters       *string
+}
+
+// newConvertFlags declares the options of the convert command.
+func newConvertFlags() (*flag.FlagSet, *convertFlags) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: asciicam convert [flags] <image or video>")
+		fs.PrintDefaults()
+	}
+	return fs, &convertFlags{
+		out:      fs.String("o", "", "Output file (.ans, .txt, .html, or .cast for videos), stdout if empty"),
+		width:    fs.Uint("width", 0, "output width, the terminal width or 80 if 0"),
+		height:   fs.Uint("height", 0, "output height, from the aspect ratio if 0"),
+		cellSize: fs.String("cell-size", "auto", "Pixel size of a terminal cell as WxH, for sixel images and the height; auto asks the terminal"),
+		mode:     fs.String("mode", "ansi", "Render mode (ascii, ansi, braille, mono, edges, edges-hue, contrast, sixel)"),
+		charset:  fs.String("charset", "default", "ASCII character ramp (default, simple, detailed, blocks, dots, binary)"),
+		profile:  fs.String("profile", "", "Color profile (truecolor, 256, 16, none), by default the terminal's or truecolor for files"),
+		filters:  fs.String("filters", "", "Comma separated filters applied in order, e.g. mirror,contrast=1.5"),
+	}
+}
+
+// convertFrame is a decoded frame of the input and its time from the start.
+type convertFrame struct {
+	img image.Image
+	at  time.Duration
+}
+
+// runConvert renders an image or video file to stdout or a file, without
+// a camera or the interactive screen. Stills and animated GIFs are decoded
+// directly, other videos with ffmpeg.
+func runConvert(ctx context.Context, args []string) error {
+	fs, o := newConvertFlags()
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	in := fs.Arg(0)
+
+	ext := strings.ToLower(filepath.Ext(*o.out))
+	switch ext {
+	case "", ".ans", ".txt", ".html", ".cast":
+	default:
+		return fmt.Errorf("unsupported output format %q", ext)
+	}
+
+	profile := termenv.TrueColor
+	switch *o.profile {
+	case "":
+		if ext == ".txt" {
+			profile = termenv.Ascii
+		} else if *o.out == "" {
+			profile = termenv.EnvColorProfile()
+			if termenv.EnvNoColor() {
+				*o.mode = noColorMode(*o.mode)
+			}
+		}
+	default:
+		var err error
+		if profile, err = parseProfile(*o.profile); err != nil {
+			return err
+		}
+	}
+	chain, err := filter.Parse(*o.filters)
+	if err != nil {
+		return err
+	}
+
+	cols := int(*o.width)
+	if cols == 0 {
+		cols = 80
+		if w, _, err := xterm.GetSize(int(os.Stdout.Fd())); err == nil && *o.out == "" {
+			cols = w
+		}
+	}
+	// files are shown on other terminals, only stdout is measured
+	cell, err := parseCellSize(*o.cellSize, *o.out == "" && xterm.IsTerminal(int(os.Stdout.Fd())))
+	if err != nil {
+		return err
+	}
+	opts := []asciicam.Option{
+		asciicam.WithCellAspect(useCellSize(cell)),
+		asciicam.WithMode(*o.mode),
+		asciicam.WithCharset(*o.charset),
+		asciicam.WithProfile(profile),
+		asciicam.WithFilters(chain...),
+		asciicam.WithSize(cols, int(*o.height)),
+	}
+	if *o.height == 0 {
+		opts[len(opts)-1] = asciicam.WithWidth(cols)
+	}
+
+	p, err := asciicam.New(opts...)
+	if err != nil {
+		return err
+	}
+	// graphics on stdout have to get through multiplexers
+	mux := term.NoMultiplexer
+	if *o.out == "" {
+		var warning string
+		if mux, warning = graphicsPassthrough(ctx); warning != "" && *o.mode == "sixel" {
+			fmt.Fprintln(os.Stderr, "Warning:", warning)
+		}
+	}
+	// graphics are drawn at the cursor, not the top left corner of the
+	// screen, unless the screen is redrawn for every frame
+	inline := func(out string) string {
+		if _, graphics := render.SplitGraphics(out); graphics == "" {
+			return out
+		}
+		return mux.Passthrough(render.InlineGraphics(out))
+	}
+	frames, err := decodeFrames(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	// stills are written as they are
+	first, ok := <-frames
+	if !ok {
+		return errors.New("no frames in input")
+	}
+	next, more := <-frames
+	if !more {
+		out, err := p.Render(first.img)
+		if err != nil {
+			return err
+		}
+		if ext == ".cast" {
+			return writeConverted(*o.out, ext, out)
+		}
+		return writeConverted(*o.out, ext, inline(out))
+	}
+
+	// videos are played on stdout or recorded
+	if ext != "" && ext != ".cast" {
+		return fmt.Errorf("videos can only be converted to .cast")
+	}
+	var rec *castRecorder
+	play := xterm.IsTerminal(int(os.Stdout.Fd())) && *o.out == ""
+	if play {
+		os.Stdout.WriteString("\x1b[?25l\x1b[2J")
+		defer os.Stdout.WriteString("\x1b[?25h\n")
+	}
+	start := time.Now()
+	for f := range all(first, next, frames) {
+		out, err := p.Render(f.img)
+		if err != nil {
+			return err
+		}
+		if rec == nil && ext == ".cast" {
+			lines := strings.Split(out, "\n")
+			if rec, err = newCastRecorder(*o.out, uint(cols), uint(len(lines))); err != nil {
+				return err
+			}
+			defer rec.Close()
+		}
+		switch {
+		case rec != nil:
+			err = rec.WriteFrame(nil, out, start.Add(f.at))
+		case play:
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Until(start.Add(f.at))):
+			}
+			text, graphics := render.SplitGraphics(out)
+			_, err = os.Stdout.WriteString("\x1b[H" + strings.ReplaceAll(text, "\n", "\r\n") + mux.Passthrough(graphics))
+		default:
+			_, err = os.Stdout.WriteString(inline(out) + "\n")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if rec != nil {
+		return rec.Close()
+	}
+	return nil
+}
+
+// all yields first and next, then the frames of the channel.
+func all(first, next convertFrame, frames <-chan convertFrame) func(func(convertFrame) bool) {
+	return func(yield func(convertFrame) bool) {
+		if !yield(first) || !yield(next) {
+			return
+		}
+		for f := range frames {
+			if !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// writeConverted writes the rendered output of a still to path, or to
+// stdout if path is empty.
+func writeConverted(path, ext, out string) error {
+	if path == "" {
+		_, err := fmt.Println(out)
+		return err
+	}
+	var data string
+	switch ext {
+	case ".html":
+		data = ansiToHTML(out)
+	case ".cast":
+		rec, err := newCastRecorder(path, uint(strings.Index(out+"\n", "\n")), uint(strings.Count(out, "\n")+1))
+		if err != nil {
+			return err
+		}
+		if err := rec.WriteFrame(nil, out, time.Now()); err != nil {
+			_ = rec.Close()
+			return err
+		}
+		return rec.Close()
+	default:
+		data = out + "\n"
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
+
+// decodeFrames decodes the frames of the file at path in the background.
+// The channel is closed after the last frame.
+func decodeFrames(ctx context.Context, path string) (<-chan convertFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	frames := make(chan convertFrame, 1)
+	if anim, err := gif.DecodeAll(f); err == nil {
+		go func() {
+			defer close(frames)
+			decodeGIF(anim, frames)
+		}()
+		return frames, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if img, _, err := image.Decode(f); err == nil {
+		frames <- convertFrame{img: img}
+		close(frames)
+		return frames, nil
+	}
+
+	// anything else is handed to ffmpeg
+	w, h, fps, err := probeVideo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-loglevel", "error", "-i", path, "-f", "rawvideo", "-pix_fmt", "rgba", "-")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	go func() {
+		defer close(frames)
+		defer cmd.Wait()
+		r := bufio.NewReader(stdout)
+		for n := 0; ; n++ {
+			img := image.NewRGBA(image.Rect(0, 0, w, h))
+			if _, err := io.ReadFull(r, img.Pix); err != nil {
+				return
+			}
+			frames <- convertFrame{img: img, at: time.Duration(float64(n) / fps * float64(time.Second))}
+		}
+	}()
+	return frames, nil
+}
+
+// decodeGIF sends the frames of an animated GIF, composed like a browser
+// would show them.
+func decodeGIF(anim *gif.GIF, frames chan<- convertFrame) {
+	canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	var at time.Duration
+	for i, p := range anim.Image {
+		prev := image.NewRGBA(canvas.Rect)
+		copy(prev.Pix, canvas.Pix)
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+
+		frame := image.NewRGBA(canvas.Rect)
+		copy(frame.Pix, canvas.Pix)
+		frames <- convertFrame{img: frame, at: at}
+		at += time.Duration(anim.Delay[i]) * 10 * time.Millisecond
+
+		if i < len(anim.Disposal) {
+			switch anim.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = prev
+			}
+		}
+	}
+}
+
+// probeVideo returns the frame size and rate of the first video stream of
+// the file at path.
+func probeVideo(ctx context.Context, path string) (int, int, float64, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width,height,r_frame_rate", "-of", "csv=p=0", path).Output()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%s is no image and could not be read with ffprobe: %w", path, err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ",")
+	if len(fields) != 3 {
+		return 0, 0, 0, fmt.Errorf("no video stream in %s", path)
+	}
+	w, _ := strconv.Atoi(fields[0])
+	h, _ := strconv.Atoi(fields[1])
+	num, den, _ := strings.Cut(fields[2], "/")
+	n, _ := strconv.ParseFloat(num, 64)
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		d = 1
+	}
+	if w <= 0 || h <= 0 || n <= 0 {
+		return 0, 0, 0, fmt.Errorf("unsupported video stream in %s", path)
+	}
+	return w, h, n / d, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// runDevices lists the capture devices with their formats and frame sizes.
+func runDevices(args []string) error {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	devices, err := source.Devices()
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Fprintln(os.Stderr, "No capture devices found")
+		return nil
+	}
+	for _, d := range devices {
+		fmt.Printf("%s\t%s\n", d.Path, d.Name)
+		for _, f := range d.Formats {
+			fmt.Printf("\t%s: %s\n", f.Name, strings.Join(f.Sizes, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/term"
+)
+
+// diffModel runs a session model and draws it with a DiffScreen instead of
+// the Bubble Tea renderer.
+type diffModel struct {
+	*model
+	scr *term.DiffScreen
+}
+
+func (d diffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, cmd := d.model.Update(msg)
+	text, graphics := d.model.frameView()
+	d.scr.Draw(text, d.escapes(graphics))
+	return d, cmd
+}
+
+func (d diffModel) View() string {
+	return ""
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// exportTheme is the default foreground and background color of the
+// exported terminal.
+type exportTheme struct {
+	name   string
+	fg, bg color.RGBA
+}
+
+// exportThemes are the values of export -theme.
+var exportThemes = []exportTheme{
+	{"dark", color.RGBA{0xcc, 0xcc, 0xcc, 0xff}, color.RGBA{0, 0, 0, 0xff}},
+	{"light", color.RGBA{0x33, 0x33, 0x33, 0xff}, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	{"solarized", color.RGBA{0x83, 0x94, 0x96, 0xff}, color.RGBA{0x00, 0x2b, 0x36, 0xff}},
+	{"green", color.RGBA{0x33, 0xff, 0x66, 0xff}, color.RGBA{0x00, 0x11, 0x00, 0xff}},
+}
+
+// exportThemeNames returns the names of the exportThemes.
+func exportThemeNames() []string {
+	names := make([]string, len(exportThemes))
+	for i, t := range exportThemes {
+		names[i] = t.name
+	}
+	return names
+}
+
+// exportFlags are the command line options of the export command.
+type exportFlags struct {
+	width, height *uint
+	mode          *string
+	charset       *string
+	profile       *string
+	filters       *string
+	theme         *string
+	fps           *float64
+}
+
+// newExportFlags declares the options of the export command.
+func newExportFlags() (*flag.FlagSet, *exportFlags) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: asciicam export [flags] <recording> <out.gif|out.mp4|out.cast>")
+		fs.PrintDefaults()
+	}
+	return fs, &exportFlags{
+		width:   fs.Uint("width", 100, "output width"),
+		height:  fs.Uint("height", 0, "output height, from the aspect ratio if 0"),
+		mode:    fs.String("mode", "ansi", "Render mode (ascii, ansi, braille, mono, edges, edges-hue, contrast)"),
+		charset: fs.String("charset", "default", "ASCII character ramp (default, simple, detailed, blocks, dots, binary)"),
+		profile: fs.String("profile", "truecolor", "Color profile (truecolor, 256, 16, none)"),
+		filters: fs.String("filters", "", "Comma separated filters applied in order, e.g. mirror,contrast=1.5"),
+		theme:   fs.String("theme", "dark", "Default colors of the terminal: "+strings.Join(exportThemeNames(), ", ")),
+		fps:     fs.Float64("fps", 25, "Frame rate of mp4 output"),
+	}
+}
+
+// castOnlyFlags are the export flags that set how frames are rendered,
+// which casts already are.
+var castOnlyFlags = []string{"width", "height", "mode", "charset", "profile", "filters"}
+
+// runExport renders a recording to a GIF, an mp4 video or an asciicast
+// offline. Raw recordings, the gif and mp4 ones of -record-format or any
+// other video, are rendered with the given mode, charset, size and
+// filters. Casts keep their rendering; only their colors follow the theme.
+func runExport(ctx context.Context, args []string) error {
+	fs, o := newExportFlags()
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+
+	ext := strings.ToLower(filepath.Ext(out))
+	switch ext {
+	case ".gif", ".mp4", ".cast":
+	default:
+		return fmt.Errorf("unsupported output format %q", ext)
+	}
+	i := slices.IndexFunc(exportThemes, func(t exportTheme) bool { return t.name == *o.theme })
+	if i < 0 {
+		return fmt.Errorf("invalid theme %q", *o.theme)
+	}
+	theme := exportThemes[i]
+	if *o.fps <= 0 {
+		return fmt.Errorf("invalid FPS %v", *o.fps)
+	}
+
+	var frames <-chan castFrame
+	if strings.EqualFold(filepath.Ext(in), ".cast") {
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if slices.Contains(castOnlyFlags, f.Name) {
+				err = fmt.Errorf("casts are already rendered, -%s can't change them", f.Name)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if frames, err = readCast(in); err != nil {
+			return err
+		}
+	} else {
+		if *o.mode == "sixel" {
+			return errors.New("sixel output can't be exported")
+		}
+		profile, err := parseProfile(*o.profile)
+		if err != nil {
+			return err
+		}
+		chain, err := filter.Parse(*o.filters)
+		if err != nil {
+			return err
+		}
+		size := asciicam.WithSize(int(*o.width), int(*o.height))
+		if *o.height == 0 {
+			size = asciicam.WithWidth(int(*o.width))
+		}
+		p, err := asciicam.New(
+			asciicam.WithMode(*o.mode),
+			asciicam.WithCharset(*o.charset),
+			asciicam.WithProfile(profile),
+			asciicam.WithFilters(chain...),
+			size,
+		)
+		if err != nil {
+			return err
+		}
+		decoded, err := decodeFrames(ctx, in)
+		if err != nil {
+			return err
+		}
+		frames = renderFrames(p, decoded)
+	}
+
+	var w exportWriter
+	switch ext {
+	case ".gif":
+		w = &gifExport{path: out, theme: theme}
+	case ".mp4":
+		w = &mp4Export{path: out, theme: theme, fps: *o.fps}
+	case ".cast":
+		w = &castExport{path: out}
+	}
+	n := 0
+	for f := range frames {
+		if f.err != nil {
+			return f.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := w.write(f); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return errors.New("no frames in input")
+	}
+	return w.close()
+}
+
+// castFrame is a rendered frame and its time from the start of the
+// recording. err ends the frames.
+type castFrame struct {
+	text string
+	at   time.Duration
+	err  error
+}
+
+// readCast reads the output events of an asciicast v2 recording asciicam
+// made, each of which draws a whole frame.
+func readCast(path string) (<-chan castFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16<<20) // one event holds a whole frame
+	var header struct {
+		Version int `json:"version"`
+	}
+	if !sc.Scan() {
+		_ = f.Close()
+		return nil, errors.New("empty recording")
+	}
+	if err := json.Unmarshal(sc.Bytes(), &header); err != nil || header.Version != 2 {
+		_ = f.Close()
+		return nil, errors.New("not an asciicast v2 recording")
+	}
+
+	frames := make(chan castFrame, 1)
+	go func() {
+		defer close(frames)
+		defer f.Close()
+		for sc.Scan() {
+			var event [3]any
+			if err := json.Unmarshal(sc.Bytes(), &event); err != nil {
+				frames <- castFrame{err: fmt.Errorf("invalid event: %w", err)}
+				return
+			}
+			t, _ := event[0].(float64)
+			kind, _ := event[1].(string)
+			data, _ := event[2].(string)
+			if kind == "o" {
+				frames <- castFrame{text: data, at: time.Duration(t * float64(time.Second))}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			frames <- castFrame{err: err}
+		}
+	}()
+	return frames, nil
+}
+
+// renderFrames renders the decoded frames with p.
+func renderFrames(p *asciicam.Pipeline, decoded <-chan convertFrame) <-chan castFrame {
+	frames := make(chan castFrame, 1)
+	go func() {
+		defer close(frames)
+		for f := range decoded {
+			text, err := p.Render(f.img)
+			frames <- castFrame{text: text, at: f.at, err: err}
+			if err != nil {
+				// let the decoder finish
+				for range decoded {
+				}
+				return
+			}
+		}
+	}()
+	return frames
+}
+
+// exportWriter writes the frames of an export.
+type exportWriter interface {
+	write(f castFrame) error
+	close() error
+}
+
+// gifExport collects the rasterized frames and encodes them as an
+// animated GIF when closed.
+type gifExport struct {
+	path  string
+	theme exportTheme
+	anim  gif.GIF
+	last  time.Duration
+}
+
+func (e *gifExport) write(f castFrame) error {
+	// delay of the previous frame in 100ths of a second
+	if n := len(e.anim.Delay); n > 0 {
+		e.anim.Delay[n-1] = max(2, int((f.at-e.last)/(10*time.Millisecond)))
+	}
+	e.last = f.at
+
+	raster := render.Image(f.text, e.theme.fg, e.theme.bg)
+	// text has few colors and sharp edges, dithering would blur them
+	img := image.NewPaletted(raster.Rect, palette.Plan9)
+	draw.Draw(img, img.Rect, raster, image.Point{}, draw.Src)
+	e.anim.Image = append(e.anim.Image, img)
+	e.anim.Delay = append(e.anim.Delay, 4)
+	return nil
+}
+
+func (e *gifExport) close() error {
+	f, err := os.Create(e.path)
+	if err != nil {
+		return err
+	}
+	// frames differ in size when the recording was resized
+	for _, img := range e.anim.Image {
+		e.anim.Config.Width = max(e.anim.Config.Width, img.Rect.Dx())
+		e.anim.Config.Height = max(e.anim.Config.Height, img.Rect.Dy())
+	}
+	if err := gif.EncodeAll(f, &e.anim); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return f.Close()
+}
+
+// mp4Export pipes the rasterized frames into ffmpeg at a constant frame
+// rate, repeating frames to keep their timing. ffmpeg is started with the
+// first frame, which sets the video size.
+type mp4Export struct {
+	path  string
+	theme exportTheme
+	fps   float64
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	size  image.Rectangle
+	prev  *image.RGBA // the frame shown until the next one
+	n     int         // frames written
+}
+
+func (e *mp4Export) write(f castFrame) error {
+	img := render.Image(f.text, e.theme.fg, e.theme.bg)
+	if e.cmd == nil {
+		e.size = img.Rect
+		e.cmd = exec.Command("ffmpeg", "-loglevel", "error", "-y",
+			"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", e.size.Dx(), e.size.Dy()),
+			"-r", fmt.Sprint(e.fps), "-i", "-",
+			// x264 needs even sizes
+			"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2",
+			"-c:v", "libx264", "-pix_fmt", "yuv420p", e.path)
+		e.cmd.Stderr = os.Stderr
+		stdin, err := e.cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		if err := e.cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start ffmpeg: %w", err)
+		}
+		e.stdin = stdin
+	}
+	if img.Rect != e.size {
+		// the video keeps the size of the first frame
+		fitted := image.NewRGBA(e.size)
+		draw.Draw(fitted, fitted.Rect, image.NewUniform(e.theme.bg), image.Point{}, draw.Src)
+		draw.Draw(fitted, fitted.Rect, img, image.Point{}, draw.Src)
+		img = fitted
+	}
+	if err := e.fill(f.at); err != nil {
+		return err
+	}
+	e.prev = img
+	return nil
+}
+
+// fill writes the previous frame until the video reaches at.
+func (e *mp4Export) fill(at time.Duration) error {
+	for e.prev != nil && time.Duration(float64(e.n)/e.fps*float64(time.Second)) < at {
+		if _, err := e.stdin.Write(e.prev.Pix); err != nil {
+			return err
+		}
+		e.n++
+	}
+	return nil
+}
+
+func (e *mp4Export) close() error {
+	if e.cmd == nil {
+		return nil
+	}
+	// the last frame is shown for one frame time
+	err := e.fill(time.Duration(float64(e.n+1) / e.fps * float64(time.Second)))
+	_ = e.stdin.Close()
+	return errors.Join(err, e.cmd.Wait())
+}
+
+// castExport writes the rendered frames as an asciicast.
+type castExport struct {
+	path  string
+	rec   *castRecorder
+	start time.Time
+}
+
+func (e *castExport) write(f castFrame) error {
+	if e.rec == nil {
+		lines := strings.Split(f.text, "\n")
+		var err error
+		if e.rec, err = newCastRecorder(e.path, uint(ansi.StringWidth(lines[0])), uint(len(lines))); err != nil {
+			return err
+		}
+		e.start = time.Now()
+	}
+	return e.rec.WriteFrame(nil, f.text, e.start.Add(f.at))
+}
+
+func (e *castExport) close() error {
+	return e.rec.Close()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// exposureSettle is the time auto exposure gets to find the brightness
+// before -exposure-lock freezes it.
+const exposureSettle = 2 * time.Second
+
+// exposurePreset is a manual exposure of -exposure.
+type exposurePreset struct {
+	name     string
+	exposure int32   // exposure time in 100 µs, clamped to the camera's range
+	gain     float64 // share of the camera's gain range
+}
+
+// exposurePresets are the values of -exposure but auto. Indoors and in
+// front of a screen the exposure stays at a frame time of 30 fps, so the
+// frame rate doesn't drop, and the gain makes up for the dimmer light.
+var exposurePresets = []exposurePreset{
+	{"indoor", 333, 0.3},
+	{"daylight", 40, 0},
+	{"screen-lit", 333, 0.7},
+}
+
+// exposureNames returns the values of -exposure.
+func exposureNames() []string {
+	names := []string{"auto"}
+	for _, p := range exposurePresets {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// parseExposure returns the preset of an -exposure value, nil for auto.
+func parseExposure(name string) (*exposurePreset, error) {
+	if name == "auto" {
+		return nil, nil
+	}
+	for i, p := range exposurePresets {
+		if p.name == name {
+			return &exposurePresets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid exposure %q", name)
+}
+
+// exposureLock freezes the exposure and gain of the camera. Auto exposure
+// adjusts to every change of the picture, which makes the brightness and
+// with it the characters of the ramp pulse from frame to frame.
+type exposureLock struct {
+	pending bool            // lock once the time has come
+	at      time.Time       // time to lock, zero until the first frame
+	preset  *exposurePreset // nil to freeze what auto exposure found
+	saved   []source.Control
+	locked  bool
+}
+
+// exposureFrame locks the exposure requested on the command line once the
+// camera had time to settle.
+func (m *model) exposureFrame(now time.Time) {
+	e := &m.exposure
+	if !e.pending {
+		return
+	}
+	if e.at.IsZero() {
+		e.at = now
+		if e.preset == nil {
+			e.at = now.Add(exposureSettle)
+		}
+	}
+	if now.Before(e.at) {
+		return
+	}
+	e.pending = false
+	m.lockExposure()
+}
+
+// lockExposure turns auto exposure and gain off and sets them to the
+// preset, or keeps the values auto exposure is at.
+func (m *model) lockExposure() {
+	e := &m.exposure
+	if m.controls == nil {
+		m.setNotice("exposure lock needs camera controls")
+		return
+	}
+	saved, err := setExposure(m.controls, e.preset)
+	if err != nil {
+		restoreControls(m.controls, saved)
+		m.setNotice("exposure lock: " + err.Error())
+		return
+	}
+	e.saved, e.locked = saved, true
+	if e.preset != nil {
+		m.setNotice("exposure: " + e.preset.name)
+	} else {
+		m.setNotice("exposure locked")
+	}
+}
+
+// unlockExposure gives the exposure back to the camera.
+func (m *model) unlockExposure() {
+	e := &m.exposure
+	if m.controls == nil || !e.locked {
+		return
+	}
+	restoreControls(m.controls, e.saved)
+	e.saved, e.locked = nil, false
+}
+
+// toggleExposure locks or unlocks the exposure.
+func (m *model) toggleExposure() {
+	if m.exposure.locked {
+		m.unlockExposure()
+		m.setNotice("exposure: auto")
+		return
+	}
+	m.exposure.pending = false
+	m.lockExposure()
+}
+
+// setExposure switches the exposure and gain of c to manual at the values
+// of p, or at their current values for a nil p, and returns the controls
+// it changed with their values before, to restore them later. Controls the
+// camera doesn't have are left out, but it has to have an exposure time.
+func setExposure(c source.Controllable, p *exposurePreset) ([]source.Control, error) {
+	controls := c.Controls()
+	find := func(id uint32) *source.Control {
+		i := slices.IndexFunc(controls, func(c source.Control) bool { return c.ID == id })
+		if i < 0 {
+			return nil
+		}
+		return &controls[i]
+	}
+	exposure := find(source.ControlExposureAbsolute)
+	if exposure == nil {
+		return nil, errors.New("camera has no manual exposure")
+	}
+
+	var saved []source.Control
+	set := func(ctl *source.Control, value int32) error {
+		if ctl == nil || ctl.Value == value {
+			return nil
+		}
+		if err := c.SetControl(ctl.ID, value); err != nil {
+			return fmt.Errorf("%s: %w", ctl.Name, err)
+		}
+		saved = append(saved, *ctl)
+		return nil
+	}
+	// the frame rate stays put, then the automatics are turned off before
+	// their values are set
+	if err := set(find(source.ControlExposurePriority), 0); err != nil {
+		return saved, err
+	}
+	if err := set(find(source.ControlExposureAuto), source.ExposureManual); err != nil {
+		return saved, err
+	}
+	if err := set(find(source.ControlAutoGain), 0); err != nil {
+		return saved, err
+	}
+	if p == nil {
+		// some cameras go back to their last manual values, write the
+		// ones auto exposure was at
+		for _, ctl := range []*source.Control{exposure, find(source.ControlGain)} {
+			if ctl == nil {
+				continue
+			}
+			if err := c.SetControl(ctl.ID, ctl.Value); err != nil {
+				return saved, fmt.Errorf("%s: %w", ctl.Name, err)
+			}
+		}
+		return saved, nil
+	}
+	if err := set(exposure, min(max(p.exposure, exposure.Min), exposure.Max)); err != nil {
+		return saved, err
+	}
+	if gain := find(source.ControlGain); gain != nil {
+		value := gain.Min + int32(p.gain*float64(gain.Max-gain.Min))
+		value -= (value - gain.Min) % gain.Step
+		if err := set(gain, value); err != nil {
+			return saved, err
+		}
+	}
+	return saved, nil
+}
+
+// restoreControls sets the saved controls back in reverse order, so the
+// values are restored before the automatics that overwrite them.
+func restoreControls(c source.Controllable, saved []source.Control) {
+	for i := len(saved) - 1; i >= 0; i-- {
+		_ = c.SetControl(saved[i].ID, saved[i].Value)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// drawFaces draws a box around each detected face, with its score in the
+// top border.
+func (m *model) drawFaces(lines []string) {
+	_, _, bottom := m.cornerAt(lines, "top-left", 0, 0)
+	style := termenv.Style{}.Foreground(m.profile.Color(m.palette.face))
+	for _, f := range m.faces {
+		c := m.cells(f.Rect)
+		if c.Dx() < 3 || c.Dy() < 2 {
+			continue
+		}
+		inner := c.Dx() - 2
+		label := fmt.Sprintf("%.0f", f.Score)
+		if len(label) > inner {
+			label = ""
+		}
+		top := "┌" + label + strings.Repeat("─", inner-len(label)) + "┐"
+		end := "└" + strings.Repeat("─", inner) + "┘"
+		for y := c.Min.Y; y < min(c.Max.Y, bottom); y++ {
+			switch y {
+			case c.Min.Y:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled(top))
+			case c.Max.Y - 1:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled(end))
+			default:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled("│"))
+				lines[y] = overlay(lines[y], c.Max.X-1, style.Styled("│"))
+			}
+		}
+	}
+}
+
+// cells returns the cells showing the r part of the raw frame.
+func (m *model) cells(r image.Rectangle) image.Rectangle {
+	r = r.Intersect(m.crop)
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	w, h := int(max(m.width, minWidth)), int(max(m.height, minHeight))
+	x0 := 0
+	if m.showsSplit() {
+		// marks go on the processed right half
+		left, right := splitWidths(uint(w))
+		x0, w = int(left)+1, int(right)
+	}
+	cw, ch := m.crop.Dx(), m.crop.Dy()
+	return image.Rect(
+		x0+(r.Min.X-m.crop.Min.X)*w/cw, (r.Min.Y-m.crop.Min.Y)*h/ch,
+		x0+((r.Max.X-m.crop.Min.X)*w+cw-1)/cw, ((r.Max.Y-m.crop.Min.Y)*h+ch-1)/ch,
+	)
+}
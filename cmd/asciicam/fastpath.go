@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// fastPath reports whether frames can be rendered straight from the raw
+// YUYV frame with the given renderer. Only the character ramp renderers
+// qualify and only while no other feature needs the converted frame.
+func (m *model) fastPath(renderer int) bool {
+	switch render.Modes()[renderer].Name {
+	case "ascii", "mono":
+	default:
+		return false
+	}
+	return !m.paused && !m.screen && m.calib == calibOff && m.histMode == 0 &&
+		!m.detectsMotion() && !m.showFaces && !m.autoZoom.on && !m.follow.on && !m.hideFaces && !m.night.on && !m.ambient.on && !m.whiteBalance && m.filters == "" && !m.mirrorFlip && !m.showPiP && !m.split && !m.showHelp && m.rec == nil
+}
+
+// renderYUYV renders the crop part of a raw YUYV frame that is stride
+// pixels wide with the character ramp renderer of s.
+func renderYUYV(s *frameSettings, yuyv []byte, stride int, crop image.Rectangle) string {
+	o := s.renderOptions()
+	if render.Modes()[s.renderer].Name == "mono" {
+		o.Profile = termenv.Ascii
+	}
+	return string(bytes.TrimSuffix(render.YUYV(yuyv, stride, crop, s.width, s.height, o), []byte("\n")))
+}
+
+// rawFrame returns the frame on screen, converting it first if it was
+// rendered on the fast path.
+func (m *model) rawFrame() *image.RGBA {
+	if m.raw == nil {
+		m.raw = m.ring.At(m.back)
+	}
+	return m.raw
+}
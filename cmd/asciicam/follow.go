@@ -0,0 +1,108 @@
+package main
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+)
+
+// followWidth is the width the whole frame is scaled to for the motion
+// -follow-motion pans to. Only the rough position of the motion counts.
+const followWidth = 80
+
+// motionSpot is the motion in the whole frame, outside the crop window too.
+type motionSpot struct {
+	level float64 // share of moving pixels
+	x, y  float64 // center of the moving pixels relative to the frame
+}
+
+// detectSpot finds the motion in the whole of img, scaled down to
+// followWidth, so the crop window moving doesn't count as motion itself.
+func (f *frameFilter) detectSpot(img *image.RGBA, threshold int) motionSpot {
+	b := img.Bounds()
+	if b.Empty() {
+		return motionSpot{}
+	}
+	w := min(followWidth, uint(b.Dx()))
+	h := max(1, w*uint(b.Dy())/uint(b.Dx()))
+	f.follow.Threshold = threshold
+	level := f.follow.Detect(f.followScale.Scale(img, b, w, h))
+	x, y, ok := f.follow.Center()
+	if !ok {
+		return motionSpot{}
+	}
+	return motionSpot{level, x, y}
+}
+
+// motionFollow pans the crop window towards the parts of the frame that
+// keep moving, see -follow-motion, like a pan/tilt camera following the
+// action. As with auto-zoom, the pan goes through two stages of exponential
+// smoothing, so a single movement barely moves the window and only motion
+// that lasts pulls it over.
+type motionFollow struct {
+	on      bool
+	zoom    float64       // zoom while following
+	level   float64       // share of the frame that has to move
+	damping time.Duration // time constant of the smoothing
+	target  [2]float64    // smoothed pan x and pan y to move to
+	last    time.Time     // last frame the crop was moved at
+}
+
+// followMotion moves the crop window towards spot, the motion in the frame
+// captured at now. Without motion the window stays where it is.
+func (m *model) followMotion(spot motionSpot, now time.Time) {
+	f := &m.follow
+	if !f.on {
+		return
+	}
+	// the smoothing starts over after a pause
+	dt := now.Sub(f.last)
+	if f.last.IsZero() || dt > time.Second {
+		f.target = [2]float64{m.panX, m.panY}
+		dt = 0
+	}
+	f.last = now
+
+	want := f.target
+	if spot.level >= f.level {
+		want = [2]float64{spot.x, spot.y}
+	}
+	// targets the window can't reach would keep pulling it after the
+	// motion is back in reach
+	_, want[0], want[1] = filter.CropRect(image.Rectangle{}, f.zoom, want[0], want[1])
+
+	k := 1 - math.Exp(-dt.Seconds()/max(f.damping, time.Millisecond).Seconds())
+	cur := [2]float64{m.panX, m.panY}
+	for i := range cur {
+		f.target[i] += (want[i] - f.target[i]) * k
+		cur[i] += (f.target[i] - cur[i]) * k
+	}
+	m.zoom += (f.zoom - m.zoom) * k
+	_, m.panX, m.panY = filter.CropRect(image.Rectangle{}, m.zoom, cur[0], cur[1])
+}
+
+// toggleFollow starts or stops following motion. Following starts from the
+// current crop window and zooms in to the follow zoom; stopping it shows
+// the whole frame again.
+func (m *model) toggleFollow() {
+	if m.follow.on {
+		m.stopFollow()
+		m.zoom, m.panX, m.panY = 1, 0.5, 0.5
+		return
+	}
+	if m.autoZoom.on {
+		m.stopAutoZoom()
+	}
+	m.follow.on, m.follow.last = true, time.Time{}
+	m.setNotice("follow motion: on")
+}
+
+// stopFollow stops following motion, leaving the crop window where it is.
+func (m *model) stopFollow() {
+	if m.follow.on {
+		m.follow.on = false
+		m.setNotice("follow motion: off")
+	}
+}
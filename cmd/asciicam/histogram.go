@@ -0,0 +1,75 @@
+package main
+
+import (
+	"image"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// histogramModes are the names of the histogram modes, indexed by
+// model.histMode.
+var histogramModes = []string{"off", "luma", "rgb"}
+
+// histogramBins is the number of columns of a rendered histogram.
+const histogramBins = 32
+
+// histogram counts luminance and per channel values of img, sampling
+// every step-th pixel in both directions.
+type histogram struct {
+	luma, r, g, b [histogramBins]int
+}
+
+func computeHistogram(img *image.RGBA, bounds image.Rectangle, step int) histogram {
+	var h histogram
+	bounds = bounds.Intersect(img.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			i := img.PixOffset(x, y)
+			r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+			// ITU-R BT.601 luma
+			l := (299*r + 587*g + 114*b) / 1000
+
+			h.luma[l*histogramBins/256]++
+			h.r[r*histogramBins/256]++
+			h.g[g*histogramBins/256]++
+			h.b[b*histogramBins/256]++
+		}
+	}
+	return h
+}
+
+// barBlocks are the partial block characters from 1/8 to 8/8 height.
+var barBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// renderBars draws counts as a bar chart of the given height in cells.
+func renderBars(counts []int, height int, p termenv.Profile, fg string) []string {
+	peak := 1
+	for _, c := range counts {
+		peak = max(peak, c)
+	}
+
+	lines := make([]string, height)
+	for row := 0; row < height; row++ {
+		var b strings.Builder
+		for _, c := range counts {
+			// eighths of a cell filled above the bottom of this row
+			level := c*height*8/peak - (height-1-row)*8
+			b.WriteRune(barBlocks[min(max(level, 0), 8)])
+		}
+		lines[row] = termenv.String(b.String()).Foreground(p.Color(fg)).Background(p.Color("#000000")).String()
+	}
+	return lines
+}
+
+// renderHistogram draws the luminance histogram, followed by the red,
+// green and blue channels in the colors of pal if rgb is set.
+func renderHistogram(h histogram, rgb bool, p termenv.Profile, pal *uiPalette) []string {
+	if !rgb {
+		return renderBars(h.luma[:], 6, p, "#ffffff")
+	}
+	lines := renderBars(h.luma[:], 3, p, "#ffffff")
+	lines = append(lines, renderBars(h.r[:], 3, p, pal.hist[0])...)
+	lines = append(lines, renderBars(h.g[:], 3, p, pal.hist[1])...)
+	return append(lines, renderBars(h.b[:], 3, p, pal.hist[2])...)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// idleMove is how often the clock of the screensaver moves, so it doesn't
+// burn into the screen either.
+const idleMove = 10 * time.Second
+
+// idleStyles are the values of -idle-style.
+var idleStyles = []string{"clock", "dim", "blank"}
+
+// idleSaver replaces the picture with a screensaver once nothing moved in
+// front of the camera for a while, to spare the screens of terminals that
+// show asciicam all day. Motion and keys wake it at once.
+type idleSaver struct {
+	after  time.Duration // time without motion before it starts, 0 to disable
+	level  float64       // share of moving pixels that counts as motion
+	style  string        // see idleStyles
+	active bool
+	moved  time.Time // last motion or key
+}
+
+// idleFrame starts or ends the screensaver by the motion of the frame just
+// shown.
+func (m *model) idleFrame(now time.Time) {
+	s := &m.idle
+	if s.after == 0 {
+		return
+	}
+	if s.moved.IsZero() || m.motionLevel >= s.level {
+		s.wake(now)
+		return
+	}
+	if !s.active && now.Sub(s.moved) >= s.after {
+		s.active = true
+	}
+}
+
+// wake ends the screensaver and starts the idle time over. It reports
+// whether the screensaver was on.
+func (s *idleSaver) wake(now time.Time) bool {
+	was := s.active
+	s.active, s.moved = false, now
+	return was
+}
+
+// dims reports whether the picture is dimmed by the screensaver.
+func (s *idleSaver) dims() bool {
+	return s.active && s.style == "dim"
+}
+
+// covers reports whether the screensaver replaces the picture.
+func (s *idleSaver) covers() bool {
+	return s.active && s.style != "dim"
+}
+
+// idleView returns the blank screen of the screensaver, with the clock in
+// a dim gray at a place that changes every idleMove.
+func (m *model) idleView() string {
+	w, h := int(max(m.width, minWidth)), int(max(m.height, minHeight))
+	blank := strings.Repeat(" ", w)
+	lines := make([]string, h)
+	for i := range lines {
+		lines[i] = blank
+	}
+	if m.idle.style == "clock" {
+		now := time.Now()
+		clock := now.Format(m.clockFormat)
+		if cw := ansi.StringWidth(clock); cw <= w {
+			r := rand.New(rand.NewPCG(uint64(now.Unix()/int64(idleMove/time.Second)), 0))
+			x, y := r.IntN(w-cw+1), r.IntN(h)
+			if m.profile != termenv.Ascii {
+				clock = termenv.Style{}.Foreground(m.profile.Color("#606060")).Styled(clock)
+			}
+			lines[y] = overlay(lines[y], x, clock)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
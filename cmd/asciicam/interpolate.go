@@ -0,0 +1,113 @@
+package main
+
+import "time"
+
+// interpolator smooths the output of slow sources, see -interpolate. For
+// every captured frame it passes on a crossfade from the frame shown last
+// to the new one, one step per render interval, spread over the time the
+// source takes per frame. The picture thus moves smoothly but reaches a
+// captured frame only when the next one is due, one capture interval late.
+type interpolator struct {
+	interval time.Duration // time between output frames
+	pool     *framePool
+	period   time.Duration // mean time between captured frames
+	arrived  time.Time     // when the last captured frame arrived
+	from     *frameBuf     // start of the current crossfade
+	shown    *frameBuf     // copy of the frame passed on last
+}
+
+// run passes the frames from in on to out, adding crossfades between
+// them, until in is closed. out is closed when run returns.
+func (ip *interpolator) run(in <-chan *frameBuf, out chan<- *frameBuf) {
+	defer close(out)
+	var next *frameBuf // arrived during the last crossfade
+	for {
+		cur := next
+		next = nil
+		if cur == nil {
+			var ok bool
+			if cur, ok = <-in; !ok {
+				return
+			}
+		}
+		ip.measure(time.Now())
+
+		steps := int(ip.period / ip.interval)
+		if ip.shown == nil || ip.shown.raw != cur.raw || steps < 2 {
+			ip.show(cur, out)
+			continue
+		}
+		ip.from, ip.shown = ip.shown, ip.from
+
+		start := time.Now()
+		for step := 1; step <= steps; step++ {
+			if step > 1 {
+				timer := time.NewTimer(time.Until(start.Add(time.Duration(step-1) * ip.interval)))
+				select {
+				case <-timer.C:
+				case buf, ok := <-in:
+					timer.Stop()
+					// start over from the frame shown now
+					ip.pool.Put(cur)
+					if !ok {
+						return
+					}
+					next = buf
+				}
+				if next != nil {
+					break
+				}
+			}
+			if step == steps {
+				ip.show(cur, out)
+				break
+			}
+			buf := ip.pool.Get()
+			blendFrames(buf, ip.from, cur, float64(step)/float64(steps))
+			ip.show(buf, out)
+		}
+	}
+}
+
+// measure updates the mean time between captured frames with one that
+// arrived at now.
+func (ip *interpolator) measure(now time.Time) {
+	if !ip.arrived.IsZero() {
+		d := now.Sub(ip.arrived)
+		if ip.period == 0 {
+			ip.period = d
+		} else {
+			ip.period = (4*ip.period + d) / 5
+		}
+	}
+	ip.arrived = now
+}
+
+// show passes buf on and keeps a copy of it to crossfade from.
+func (ip *interpolator) show(buf *frameBuf, out chan<- *frameBuf) {
+	if ip.shown == nil {
+		ip.shown, ip.from = ip.pool.Get(), ip.pool.Get()
+	}
+	ip.shown.raw, ip.shown.read = buf.raw, buf.read
+	copy(ip.shown.img.Pix, buf.img.Pix)
+	copy(ip.shown.yuyv, buf.yuyv)
+	buf.sent = time.Now()
+	out <- buf
+}
+
+// blendFrames sets dst to the mix of a and b with the share t of b. Both
+// YUYV and RGBA frames mix byte by byte. The capture time is interpolated
+// too, so recordings keep their timing.
+func blendFrames(dst, a, b *frameBuf, t float64) {
+	dst.raw = b.raw
+	dst.read = a.read.Add(time.Duration(t * float64(b.read.Sub(a.read))))
+	dst.took, dst.dropped = 0, 0
+	src, from, to := b.img.Pix, a.img.Pix, dst.img.Pix
+	if b.raw {
+		src, from, to = b.yuyv, a.yuyv, dst.yuyv
+	}
+	w := uint32(t * 256)
+	for i := range to {
+		to[i] = uint8((uint32(from[i])*(256-w) + uint32(src[i])*w) >> 8)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// action is a command that can be bound to keys.
+type action struct {
+	name string
+	help string
+	keys []string // default bindings
+}
+
+// actions lists all bindable commands in the order shown in the help
+// overlay.
+var actions = []action{
+	{"threshold-down", "lower greenscreen threshold", []string{"["}},
+	{"threshold-up", "raise greenscreen threshold", []string{"]"}},
+	{"charset-next", "next charset", []string{"c"}},
+	{"charset-prev", "previous charset", []string{"C"}},
+	{"mode-next", "next render mode", []string{"v"}},
+	{"mode-prev", "previous render mode", []string{"V"}},
+	{"zoom-in", "zoom in", []string{"+", "="}},
+	{"zoom-out", "zoom out", []string{"-"}},
+	{"pan-left", "pan left", []string{"left"}},
+	{"pan-right", "pan right", []string{"right"}},
+	{"pan-up", "pan up", []string{"up"}},
+	{"pan-down", "pan down", []string{"down"}},
+	{"auto-zoom", "toggle auto-zoom on faces", []string{"a"}},
+	{"follow-motion", "toggle panning to follow motion", []string{"t"}},
+	{"camera-menu", "camera controls", []string{"m"}},
+	{"snapshot", "save snapshot", []string{"p"}},
+	{"booth", "photo booth: countdown, four pictures, photo strip", []string{"P"}},
+	{"webhook", "save snapshot and post it to the webhook", []string{"w"}},
+	{"ocr", "copy the text read with -ocr", []string{"o"}},
+	{"record", "start / stop recording", []string{"r"}},
+	{"pause", "pause / resume", []string{" "}},
+	{"step-back", "step back while paused", []string{","}},
+	{"step-forward", "step forward while paused", []string{"."}},
+	{"calibrate", "calibrate background", []string{"g"}},
+	{"calibrate-wizard", "greenscreen wizard: sweep and pick the threshold", []string{"G"}},
+	{"fps", "toggle FPS", []string{"f"}},
+	{"inset", "toggle raw preview inset", []string{"i"}},
+	{"split", "toggle raw and processed side by side", []string{"x"}},
+	{"histogram", "histogram: off, luma, rgb", []string{"h"}},
+	{"status", "toggle status bar", []string{"s"}},
+	{"banner", "toggle banner", []string{"b"}},
+	{"night", "toggle low-light boost", []string{"n"}},
+	{"exposure-lock", "lock / unlock camera exposure", []string{"e"}},
+	{"help", "show this help", []string{"?"}},
+	{"quit", "quit", []string{"q"}},
+}
+
+// keymap maps keys to action names.
+type keymap map[string]string
+
+// newKeymap builds the effective bindings from the defaults and the
+// overrides from the config file. Keys bound by an override are taken from
+// the actions they default to; two overrides binding the same key and
+// overrides of ctrl+c, which always quits, are errors.
+func newKeymap(overrides map[string][]string) (keymap, error) {
+	known := make(map[string]bool, len(actions))
+	for _, a := range actions {
+		known[a.name] = true
+	}
+	for name := range overrides {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+	}
+
+	// defaults first, so the keys of overrides take precedence over them
+	km := keymap{}
+	for _, a := range actions {
+		if _, ok := overrides[a.name]; ok {
+			continue
+		}
+		for _, k := range a.keys {
+			km[keyName(k)] = a.name
+		}
+	}
+	bound := make(map[string]string) // key to the action overriding it
+	for _, name := range slices.Sorted(maps.Keys(overrides)) {
+		for _, k := range overrides[name] {
+			k = keyName(k)
+			if k == "ctrl+c" {
+				return nil, fmt.Errorf("ctrl+c always quits, it can't be bound to %q", name)
+			}
+			if other, ok := bound[k]; ok && other != name {
+				return nil, fmt.Errorf("key %q bound to both %q and %q", k, other, name)
+			}
+			bound[k] = name
+			km[k] = name
+		}
+	}
+	km["ctrl+c"] = "quit"
+	return km, nil
+}
+
+// keyName returns the key of a binding, which names the space bar "space".
+func keyName(k string) string {
+	if k == "space" {
+		return " "
+	}
+	return k
+}
+
+// keysFor returns the display names of the keys bound to the action.
+func (km keymap) keysFor(name string) []string {
+	var keys []string
+	for k, a := range km {
+		if a != name || k == "ctrl+c" {
+			continue
+		}
+		if k == " " {
+			k = "space"
+		}
+		keys = append(keys, k)
+	}
+	// single characters first, then named keys
+	sort.Slice(keys, func(i, j int) bool {
+		if (len(keys[i]) == 1) != (len(keys[j]) == 1) {
+			return len(keys[i]) == 1
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// helpLines lists the effective bindings for the help overlay, skipping
+// disabled actions.
+func (km keymap) helpLines() []string {
+	var lines []string
+	for _, a := range actions {
+		keys := km.keysFor(a.name)
+		if len(keys) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-8s %s", strings.Join(keys, " "), a.help))
+	}
+	return lines
+}
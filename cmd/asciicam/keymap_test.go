@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestNewKeymapOverridesTakeDefaultKeys(t *testing.T) {
+	km, err := newKeymap(map[string][]string{"pan-left": {"h", "left"}, "pause": {"space"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{"h": "pan-left", "left": "pan-left", " ": "pause", "ctrl+c": "quit", "q": "quit"} {
+		if km[k] != want {
+			t.Errorf("key %q = %q, want %q", k, km[k], want)
+		}
+	}
+}
+
+func TestNewKeymapErrors(t *testing.T) {
+	for _, overrides := range []map[string][]string{
+		{"no-such-action": {"x"}},
+		{"pan-left": {"h"}, "pan-right": {"h"}},
+		{"snapshot": {"ctrl+c"}},
+	} {
+		if _, err := newKeymap(overrides); err == nil {
+			t.Errorf("newKeymap(%v) succeeded", overrides)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// heldLogLines is the number of log lines heldLog keeps, the last ones.
+const heldLogLines = 1000
+
+// heldLog keeps log output while the terminal shows the video, writing it
+// would corrupt the screen. Only the last heldLogLines records are kept, a
+// long session would fill the memory otherwise.
+type heldLog struct {
+	mu      sync.Mutex
+	lines   [][]byte // a record per write, as slog writes them
+	dropped int
+}
+
+func (h *heldLog) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.lines) == heldLogLines {
+		h.lines = h.lines[1:]
+		h.dropped++
+	}
+	h.lines = append(h.lines, bytes.Clone(p))
+	return len(p), nil
+}
+
+// flush writes the held output to w.
+func (h *heldLog) flush(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.dropped > 0 {
+		fmt.Fprintf(w, "… %d earlier lines dropped\n", h.dropped)
+	}
+	for _, l := range h.lines {
+		_, _ = w.Write(l)
+	}
+	h.lines, h.dropped = nil, 0
+}
+
+// setupLogging makes the default logger write records of at least level
+// to the file at path. Without a path the last records are held back
+// until the returned function is called after the terminal has been
+// restored, and then written to stderr; with hold false they go to stderr
+// right away.
+func setupLogging(level, path string, hold bool) (func(), error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	var w io.Writer
+	done := func() {}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = f
+		done = func() { _ = f.Close() }
+	} else if !hold {
+		w = os.Stderr
+	} else {
+		held := &heldLog{}
+		w = held
+		done = func() { held.flush(os.Stderr) }
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})))
+	return done, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metaBacklog is the number of lines waiting for a slow reader of -meta-out
+// before newer lines are dropped for it.
+const metaBacklog = 64
+
+// frameMeta is the JSON line -meta-out writes for every frame.
+type frameMeta struct {
+	Frame     uint64     `json:"frame"`
+	Time      time.Time  `json:"time"`       // capture time
+	CaptureMS float64    `json:"capture_ms"` // reading the frame from the source
+	FilterMS  float64    `json:"filter_ms"`  // converting, scaling and filtering
+	RenderMS  float64    `json:"render_ms"`  // rendering the terminal output
+	LatencyMS float64    `json:"latency_ms"` // from capture until handed to the UI
+	FPS       float64    `json:"fps"`
+	Dropped   int        `json:"dropped"` // frames skipped since the frame before
+	Motion    float64    `json:"motion"`  // share of moving pixels
+	Faces     []metaFace `json:"faces,omitempty"`
+}
+
+// metaFace is a detected face of frameMeta, in frame pixels.
+type metaFace struct {
+	X     int     `json:"x"`
+	Y     int     `json:"y"`
+	W     int     `json:"w"`
+	H     int     `json:"h"`
+	Score float32 `json:"score"`
+}
+
+// metaOut writes the frameMeta lines of -meta-out to a file or to the
+// clients connected to a socket. Writing happens in the background, so a
+// slow disk or client never holds up the frames; lines that don't fit the
+// backlog are dropped instead.
+type metaOut struct {
+	number uint64
+
+	// file
+	lines chan []byte
+	done  chan struct{} // closed once the lines are written
+
+	// socket
+	lis    net.Listener
+	mu     sync.Mutex
+	conns  map[chan []byte]net.Conn
+	closed bool
+}
+
+// openMeta opens the destination of -meta-out: a file, which may be a
+// named pipe, or with unix:<path> or tcp:<addr> a socket that clients
+// connect to, or with systemd[:name] one passed by systemd.
+func openMeta(dest string) (*metaOut, error) {
+	var lis net.Listener
+	var err error
+	switch {
+	case strings.HasPrefix(dest, "unix:"):
+		path := strings.TrimPrefix(dest, "unix:")
+		// a socket left behind by a crashed run
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		lis, err = net.Listen("unix", path)
+	case strings.HasPrefix(dest, "tcp:"):
+		lis, err = listen(strings.TrimPrefix(dest, "tcp:"), "meta")
+	case dest == "systemd" || strings.HasPrefix(dest, "systemd:"):
+		lis, err = listen(dest, "meta")
+	default:
+		return openMetaFile(dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	o := &metaOut{lis: lis, conns: make(map[chan []byte]net.Conn)}
+	go o.serve()
+	slog.Info("serving frame metadata", "addr", lis.Addr())
+	return o, nil
+}
+
+// openMetaFile creates the file at path and writes the lines to it.
+func openMetaFile(path string) (*metaOut, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	o := &metaOut{lines: make(chan []byte, metaBacklog), done: make(chan struct{})}
+	go func() {
+		defer close(o.done)
+		var werr error
+		for line := range o.lines {
+			if werr != nil {
+				continue
+			}
+			if _, werr = f.Write(line); werr != nil {
+				slog.Error("failed to write frame metadata", "err", werr)
+			}
+		}
+		if err := f.Close(); err != nil && werr == nil {
+			slog.Error("failed to write frame metadata", "err", err)
+		}
+	}()
+	return o, nil
+}
+
+// serve accepts clients until the listener is closed. Every client gets
+// the lines from the time it connected on.
+func (o *metaOut) serve() {
+	for {
+		c, err := o.lis.Accept()
+		if err != nil {
+			return
+		}
+		ch := make(chan []byte, metaBacklog)
+		o.mu.Lock()
+		if o.closed {
+			o.mu.Unlock()
+			_ = c.Close()
+			return
+		}
+		o.conns[ch] = c
+		o.mu.Unlock()
+		slog.Debug("frame metadata client connected", "addr", c.RemoteAddr())
+
+		go func() {
+			defer c.Close()
+			for line := range ch {
+				if _, err := c.Write(line); err != nil {
+					o.mu.Lock()
+					delete(o.conns, ch)
+					o.mu.Unlock()
+					slog.Debug("frame metadata client gone", "err", err)
+					return
+				}
+			}
+		}()
+	}
+}
+
+// send passes a line on, dropping it for readers whose backlog is full.
+func (o *metaOut) send(line []byte) {
+	if o.lines != nil {
+		select {
+		case o.lines <- line:
+		default:
+		}
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for ch := range o.conns {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// close writes the remaining lines to the file, or disconnects the
+// clients and stops listening.
+func (o *metaOut) close() {
+	if o.lines != nil {
+		close(o.lines)
+		<-o.done
+		return
+	}
+	_ = o.lis.Close()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	for ch, c := range o.conns {
+		close(ch)
+		_ = c.Close()
+	}
+	o.conns = nil
+}
+
+// writeMeta writes the metadata of the frame just shown.
+func (m *model) writeMeta(msg frameMsg) {
+	buf := msg.buf
+	meta := frameMeta{
+		Frame:     m.meta.number,
+		Time:      buf.read,
+		CaptureMS: millis(buf.took),
+		FilterMS:  millis(msg.filterTook),
+		RenderMS:  millis(msg.renderTook),
+		LatencyMS: millis(time.Since(buf.read)),
+		FPS:       math.Round(m.averageFPS()*10) / 10,
+		Dropped:   buf.dropped,
+		Motion:    msg.motion,
+	}
+	m.meta.number++
+	for _, f := range msg.faces {
+		r := f.Rect
+		meta.Faces = append(meta.Faces, metaFace{r.Min.X, r.Min.Y, r.Dx(), r.Dy(), f.Score})
+	}
+	line, err := json.Marshal(meta)
+	if err != nil {
+		slog.Error("failed to encode frame metadata", "err", err)
+		return
+	}
+	m.meta.send(append(line, '\n'))
+}
+
+// millis returns d in milliseconds, rounded to microseconds.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// micSources are the values of -mic.
+var micSources = []string{"off", "alsa", "pulse", "gst"}
+
+const (
+	micRate     = 16000                   // sample rate of the captured audio
+	micChunk    = micRate / 20            // samples per level, 50ms
+	micFloor    = -60                     // lowest level of the meter in dBFS
+	micFall     = 20                      // dB per second the meter falls by
+	micPeakHold = 1500 * time.Millisecond // time the peak mark stays
+	micWidth    = 10                      // cells of the meter
+)
+
+// micMsg carries the level of the last chunk of audio, or the error that
+// stopped the capture.
+type micMsg struct {
+	rms, peak float64 // dBFS
+	err       error
+}
+
+// micMeter is the state of the VU meter.
+type micMeter struct {
+	level  float64 // dBFS, falling slowly after loud chunks
+	peak   float64 // dBFS of the held peak
+	peakAt time.Time
+	last   time.Time
+	err    error // why the capture stopped, nil while it runs
+}
+
+// micCommand returns the command capturing mono 16 bit little endian audio
+// at micRate to its stdout. device is the ALSA device, the PulseAudio
+// source or the GStreamer source elements, the default one if empty.
+func micCommand(ctx context.Context, kind, device string) (*exec.Cmd, error) {
+	switch kind {
+	case "alsa":
+		args := []string{"-q", "-t", "raw", "-f", "S16_LE", "-c", "1", "-r", fmt.Sprint(micRate)}
+		if device != "" {
+			args = append(args, "-D", device)
+		}
+		return exec.CommandContext(ctx, "arecord", args...), nil
+	case "pulse":
+		args := []string{"--raw", "--format=s16le", "--channels=1", fmt.Sprintf("--rate=%d", micRate)}
+		if device != "" {
+			args = append(args, "--device="+device)
+		}
+		return exec.CommandContext(ctx, "parec", args...), nil
+	case "gst":
+		if device == "" {
+			device = "autoaudiosrc"
+		}
+		pipeline := fmt.Sprintf("%s ! audioconvert ! audioresample ! audio/x-raw,format=S16LE,channels=1,rate=%d ! fdsink fd=1", device, micRate)
+		return exec.CommandContext(ctx, "gst-launch-1.0", append([]string{"-q"}, strings.Fields(pipeline)...)...), nil
+	}
+	return nil, fmt.Errorf("invalid microphone source %q", kind)
+}
+
+// runMic captures audio with cmd and sends the level of every chunk to
+// prog until the capture ends. The audio is also written to audio, if not
+// nil, until that fails.
+func runMic(cmd *exec.Cmd, prog *tea.Program, audio *audioStream) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		prog.Send(micMsg{err: fmt.Errorf("failed to start %s: %w", cmd.Path, err)})
+		return
+	}
+
+	buf := make([]byte, 2*micChunk)
+	for {
+		if _, err = io.ReadFull(stdout, buf); err != nil {
+			break
+		}
+		if audio != nil {
+			if _, err := audio.Write(buf); err != nil {
+				audio = nil
+			}
+		}
+		var sum, peak float64
+		for i := 0; i < len(buf); i += 2 {
+			s := float64(int16(binary.LittleEndian.Uint16(buf[i:]))) / 32768
+			sum += s * s
+			peak = max(peak, math.Abs(s))
+		}
+		prog.Send(micMsg{rms: dbfs(math.Sqrt(sum / micChunk)), peak: dbfs(peak)})
+	}
+	_ = cmd.Wait()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		err = fmt.Errorf("%s: %s", cmd.Path, lastLine(msg))
+	}
+	prog.Send(micMsg{err: err})
+}
+
+// dbfs converts an amplitude from 0 to 1 to decibels below full scale,
+// no lower than micFloor.
+func dbfs(v float64) float64 {
+	if v <= 0 {
+		return micFloor
+	}
+	return max(micFloor, 20*math.Log10(v))
+}
+
+// lastLine returns the last line of s.
+func lastLine(s string) string {
+	return s[strings.LastIndexByte(s, '\n')+1:]
+}
+
+// update applies the level of a chunk captured at now. The meter jumps up
+// and falls back by micFall dB per second, like a VU meter.
+func (v *micMeter) update(msg micMsg, now time.Time) {
+	if msg.err != nil {
+		v.err = msg.err
+		v.level, v.peak = micFloor, micFloor
+		return
+	}
+	fall := micFall * now.Sub(v.last).Seconds()
+	v.level = max(msg.rms, v.level-fall, micFloor)
+	v.last = now
+	if msg.peak >= v.peak || now.Sub(v.peakAt) > micPeakHold {
+		v.peak, v.peakAt = msg.peak, now
+	}
+}
+
+// view renders the meter, e.g. "mic ██████▌  | -12 dB", with the held peak
+// as a bar. It shows "mic off" once the capture has stopped.
+func (v *micMeter) view() string {
+	if v.err != nil {
+		return "mic off"
+	}
+	cells := []rune(strings.Repeat(" ", micWidth))
+	fill := (v.level - micFloor) / -micFloor * micWidth
+	for i := range cells {
+		switch {
+		case float64(i+1) <= fill:
+			cells[i] = '█'
+		case float64(i) < fill:
+			cells[i] = '▌'
+		}
+	}
+	if p := int((v.peak - micFloor) / -micFloor * micWidth); v.peak > micFloor && p < micWidth && cells[p] == ' ' {
+		cells[p] = '|'
+	}
+	return fmt.Sprintf("mic %s %3.0f dB", string(cells), v.level)
+}
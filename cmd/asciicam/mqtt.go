@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// mqttSchemes are the broker URL schemes paho connects to.
+var mqttSchemes = []string{"tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss"}
+
+// mqttPublisher publishes the events of the session to an MQTT broker,
+// see -mqtt. All topics start with topic:
+//
+//	<topic>/availability  "online" or "offline", retained
+//	<topic>/motion        motion starting and stopping, retained
+//	<topic>/snapshot      snapshots taken
+//	<topic>/status        heartbeats with the frame rate and state
+//
+// Messages are sent without waiting for the broker, so a slow or lost
+// connection doesn't hold up the frames; paho reconnects on its own.
+type mqttPublisher struct {
+	client    mqtt.Client
+	topic     string
+	level     float64       // share of moving pixels that is motion, 0 for no motion events
+	cooldown  time.Duration // time without motion before it stops
+	heartbeat time.Duration // time between heartbeats, 0 for none
+	started   time.Time
+
+	moving bool
+	moved  time.Time // last frame at or above level
+}
+
+// newMQTTPublisher connects to the broker, retrying in the background
+// until it is reachable. User name and password are taken from the URL.
+func newMQTTPublisher(broker, topic string) (*mqttPublisher, error) {
+	u, err := url.Parse(broker)
+	if err != nil || !slices.Contains(mqttSchemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid MQTT broker %q, want e.g. tcp://localhost:1883", broker)
+	}
+	p := &mqttPublisher{topic: topic, started: time.Now()}
+
+	host, _ := os.Hostname()
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(fmt.Sprintf("asciicam-%s-%d", host, os.Getpid())).
+		SetWill(topic+"/availability", "offline", 1, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			slog.Info("connected to MQTT broker", "broker", broker)
+			c.Publish(topic+"/availability", 1, true, "online")
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("lost MQTT connection", "err", err)
+		})
+	if pw, ok := u.User.Password(); ok {
+		opts.SetUsername(u.User.Username()).SetPassword(pw)
+	} else if u.User != nil {
+		opts.SetUsername(u.User.Username())
+	}
+	p.client = mqtt.NewClient(opts)
+	p.client.Connect()
+	return p, nil
+}
+
+// publish sends v as JSON to the subtopic sub.
+func (p *mqttPublisher) publish(sub string, retained bool, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to encode MQTT message", "topic", sub, "err", err)
+		return
+	}
+	t := p.client.Publish(p.topic+"/"+sub, 0, retained, b)
+	go func() {
+		if t.Wait(); t.Error() != nil {
+			slog.Warn("failed to publish MQTT message", "topic", sub, "err", t.Error())
+		}
+	}()
+}
+
+// close marks the session offline and disconnects.
+func (p *mqttPublisher) close() {
+	if p.client.IsConnectionOpen() {
+		p.client.Publish(p.topic+"/availability", 1, true, "offline").WaitTimeout(time.Second)
+	}
+	p.client.Disconnect(250)
+}
+
+// motion publishes motion starting when the share of moving pixels
+// reaches the motion level, and stopping once it stayed below it for the
+// cooldown, after the frame captured at now.
+func (p *mqttPublisher) motion(level float64, now time.Time) {
+	if p.level <= 0 {
+		return
+	}
+	if level >= p.level {
+		p.moved = now
+	}
+	if moving := now.Sub(p.moved) < p.cooldown || level >= p.level; moving != p.moving {
+		p.moving = moving
+		p.publish("motion", true, map[string]any{"motion": moving, "level": level, "time": now})
+	}
+}
+
+// snapshot publishes the files of a snapshot, base is their common prefix.
+func (p *mqttPublisher) snapshot(base string) {
+	p.publish("snapshot", false, map[string]any{
+		"png":  base + ".png",
+		"ans":  base + ".ans",
+		"html": base + ".html",
+		"time": time.Now(),
+	})
+}
+
+// mqttHeartbeatMsg asks the model to publish a heartbeat.
+type mqttHeartbeatMsg struct{}
+
+// nextHeartbeat schedules the next heartbeat, if there are heartbeats.
+func (p *mqttPublisher) nextHeartbeat() tea.Cmd {
+	if p == nil || p.heartbeat <= 0 {
+		return nil
+	}
+	return tea.Tick(p.heartbeat, func(time.Time) tea.Msg { return mqttHeartbeatMsg{} })
+}
+
+// sendHeartbeat publishes the health of the session.
+func (m *model) sendHeartbeat() {
+	fps := m.averageFPS()
+	if len(m.fps) == 0 || time.Since(m.lastFrame) > 2*time.Second {
+		fps = 0 // frames stopped coming
+	}
+	m.mqtt.publish("status", false, map[string]any{
+		"fps":       fps,
+		"source":    m.source,
+		"mode":      render.Modes()[m.renderer].Name,
+		"paused":    m.paused,
+		"recording": m.rec != nil,
+		"motion":    m.motionLevel,
+		"uptime":    time.Since(m.mqtt.started).Round(time.Second).Seconds(),
+		"time":      time.Now(),
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// ocrTimeout is the longest time a single OCR pass may take.
+const ocrTimeout = 30 * time.Second
+
+// ocrMsg carries the text read from a frame, or the error that stopped
+// reading, see -ocr.
+type ocrMsg struct {
+	text string
+	err  error
+}
+
+// ocrReader reads the text held up to the camera with the tesseract
+// command. Like the scanner, it gets a copy of the newest frame whenever
+// the last pass is done and the interval has passed.
+type ocrReader struct {
+	lang     string
+	interval time.Duration
+	lines    int // lines of text shown
+	frames   chan *image.RGBA
+	send     func(tea.Msg)
+	busy     bool
+	last     time.Time
+	text     string // text read last
+	err      error  // why reading stopped
+}
+
+// run reads the frames handed to it until the channel is closed.
+func (r *ocrReader) run(ctx context.Context) {
+	for img := range r.frames {
+		text, err := r.read(ctx, img)
+		r.send(ocrMsg{text: text, err: err})
+	}
+}
+
+// read runs tesseract on img and returns the lines of text it found.
+func (r *ocrReader) read(ctx context.Context, img *image.RGBA) (string, error) {
+	var in bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&in, img); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ocrTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "tesseract", "stdin", "stdout", "-l", r.lang)
+	cmd.Stdin = &in
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("tesseract: %s", lastLine(msg))
+		}
+		return "", err
+	}
+
+	var lines []string
+	for l := range strings.Lines(string(out)) {
+		if l = strings.Join(strings.Fields(cleanChat(l)), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ocrRead takes over the text read from a frame. Reading stops for good
+// when tesseract isn't installed.
+func (m *model) ocrRead(msg ocrMsg) {
+	r := m.ocr
+	r.busy = false
+	switch {
+	case errors.Is(msg.err, exec.ErrNotFound):
+		r.err = msg.err
+		slog.Warn("OCR stopped, tesseract is not installed")
+		m.setNotice("OCR needs tesseract")
+	case msg.err != nil:
+		slog.Warn("OCR failed", "err", msg.err)
+	default:
+		if msg.text != r.text && msg.text != "" {
+			slog.Info("read text", "text", msg.text)
+		}
+		r.text = msg.text
+	}
+}
+
+// ocrFrame hands the current frame to the OCR reader if it is idle and due.
+func (m *model) ocrFrame(now time.Time) {
+	r := m.ocr
+	if r.busy || r.err != nil || now.Sub(r.last) < r.interval {
+		return
+	}
+	raw := m.rawFrame()
+	if raw == nil {
+		return
+	}
+	img := image.NewRGBA(raw.Rect)
+	copy(img.Pix, raw.Pix)
+	r.busy, r.last = true, now
+	r.frames <- img
+}
+
+// drawOCR draws the last lines of the text read at the bottom, on a dark
+// strip across the picture.
+func (m *model) drawOCR(lines []string) {
+	if m.ocr.text == "" {
+		return
+	}
+	text := strings.Split(m.ocr.text, "\n")
+	text = text[max(0, len(text)-m.ocr.lines):]
+	w := int(m.width)
+	_, _, bottom := m.cornerAt(lines, "bottom-left", w, len(text))
+	top := max(0, bottom-len(text))
+	style := termenv.Style{}
+	if m.profile != termenv.Ascii {
+		style = style.Foreground(m.profile.Color("#ffffff")).Background(m.profile.Color("#000000"))
+	}
+	for i, l := range text[len(text)-(bottom-top):] {
+		l = ansi.Truncate(l, w, "…")
+		lines[top+i] = overlay(lines[top+i], 0, style.Styled(l+strings.Repeat(" ", w-ansi.StringWidth(l))))
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+)
+
+// textOverlay is a line of text drawn over the output, like a watermark.
+// It replaces the cells it covers, so it stays legible in every mode and
+// charset.
+type textOverlay struct {
+	text  string
+	pos   string          // corner, see corners
+	fg    *colorful.Color // text color, nil for the terminal's
+	bg    *colorful.Color // background color, nil for none
+	alpha float64         // opacity of bg over the cells below
+}
+
+// newTextOverlay returns an overlay of text in the corner pos, with colors
+// given in hex, empty for none.
+func newTextOverlay(text, pos, fg, bg string, alpha float64) (*textOverlay, error) {
+	if !slices.Contains(corners, pos) {
+		return nil, fmt.Errorf("invalid text position %q", pos)
+	}
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("invalid text background opacity %v", alpha)
+	}
+	o := &textOverlay{text: text, pos: pos, alpha: alpha}
+	if fg != "" {
+		c, err := colorful.Hex(fg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text color: %v", err)
+		}
+		o.fg = &c
+	}
+	if bg != "" {
+		c, err := colorful.Hex(bg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text background color: %v", err)
+		}
+		o.bg = &c
+	}
+	return o, nil
+}
+
+// draw overlays the text onto lines.
+func (o *textOverlay) draw(m *model, lines []string) {
+	text := " " + o.text + " "
+	w := utf8.RuneCountInString(text)
+	top, left, bottom := m.cornerAt(lines, o.pos, w, 1)
+	if top >= bottom {
+		return
+	}
+
+	var below []color.Color
+	if o.bg != nil && o.alpha < 1 {
+		below = cellColors(ansi.TruncateLeft(lines[top], left, ""), w)
+	}
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		s := termenv.Style{}
+		if o.fg != nil {
+			s = s.Foreground(m.profile.FromColor(*o.fg))
+		}
+		if o.bg != nil {
+			bg := *o.bg
+			if i < len(below) && below[i] != nil {
+				c, _ := colorful.MakeColor(below[i])
+				bg = c.BlendRgb(*o.bg, o.alpha)
+			}
+			s = s.Background(m.profile.FromColor(bg))
+		}
+		b.WriteString(s.Styled(string(r)))
+	}
+	lines[top] = overlay(lines[top], left, b.String())
+}
+
+// cellColors returns the colors of the first n cells of the rendered line:
+// the mean of the foreground and background color of cells that set both,
+// like half blocks, else the one that is set, and nil for cells without
+// colors.
+func cellColors(line string, n int) []color.Color {
+	var fg, bg color.Color
+	cells := make([]color.Color, 0, n)
+	for i := 0; i < len(line) && len(cells) < n; {
+		if line[i] == ansi.ESC && i+1 < len(line) && line[i+1] == '[' {
+			end := strings.IndexFunc(line[i+2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+			if end < 0 {
+				break
+			}
+			if line[i+2+end] == 'm' {
+				fg, bg = applyCellSGR(line[i+2:i+2+end], fg, bg)
+			}
+			i += 2 + end + 1
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+
+		switch {
+		case fg != nil && bg != nil:
+			f, _ := colorful.MakeColor(fg)
+			b, _ := colorful.MakeColor(bg)
+			cells = append(cells, f.BlendRgb(b, 0.5))
+		case bg != nil:
+			cells = append(cells, bg)
+		default:
+			cells = append(cells, fg)
+		}
+	}
+	return cells
+}
+
+// applyCellSGR applies the parameters of an SGR sequence to the current
+// foreground and background color.
+func applyCellSGR(params string, fg, bg color.Color) (color.Color, color.Color) {
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		p, _ := strconv.Atoi(ps[i])
+		switch {
+		case p == 0:
+			fg, bg = nil, nil
+		case p == 39:
+			fg = nil
+		case p == 49:
+			bg = nil
+		case p >= 30 && p <= 37:
+			fg = ansi.BasicColor(p - 30)
+		case p >= 90 && p <= 97:
+			fg = ansi.BasicColor(p - 90 + 8)
+		case p >= 40 && p <= 47:
+			bg = ansi.BasicColor(p - 40)
+		case p >= 100 && p <= 107:
+			bg = ansi.BasicColor(p - 100 + 8)
+		case p == 38 || p == 48:
+			var c color.Color
+			if i+2 < len(ps) && ps[i+1] == "5" {
+				n, _ := strconv.Atoi(ps[i+2])
+				c = ansi.IndexedColor(n)
+				i += 2
+			} else if i+4 < len(ps) && ps[i+1] == "2" {
+				r, _ := strconv.Atoi(ps[i+2])
+				g, _ := strconv.Atoi(ps[i+3])
+				b, _ := strconv.Atoi(ps[i+4])
+				c = color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+				i += 4
+			}
+			if p == 38 {
+				fg = c
+			} else {
+				bg = c
+			}
+		}
+	}
+	return fg, bg
+}
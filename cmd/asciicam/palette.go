@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// uiPalette are the colors asciicam draws its marks over the picture in.
+type uiPalette struct {
+	name   string
+	motion string    // motion highlight, unless -motion-color is given
+	face   string    // boxes around faces
+	scan   string    // outlines of scanned codes
+	rec    string    // background of the REC indicator
+	hist   [3]string // red, green and blue histogram bars
+	nicks  []string  // chat nicks, picked by their hash
+}
+
+// uiPalettes are the values of -cb, the default palette first. The others
+// avoid the colors people with that kind of color blindness can't tell
+// apart: red and green for deuteranopia and protanopia, where the palette
+// sticks to the blue-yellow axis of Okabe and Ito's colors, and blue and
+// green or yellow and violet for tritanopia, which keeps to red and cyan.
+// Protanopia also sees red as dark, so its marks avoid red entirely.
+var uiPalettes = []uiPalette{
+	{
+		name: "off", motion: "#ff3030", face: "#30ff30", scan: "#ffd700", rec: "#cc0000",
+		hist:  [3]string{"#ff4040", "#40ff40", "#4080ff"},
+		nicks: []string{"#ff5f5f", "#5fafff", "#5fd75f", "#ffaf00", "#d787ff", "#00d7d7", "#ff87af", "#d7d700"},
+	},
+	{
+		name: "deuteranopia", motion: "#ffb000", face: "#56b4e9", scan: "#f0e442", rec: "#d55e00",
+		hist:  [3]string{"#d55e00", "#f0e442", "#0072b2"},
+		nicks: []string{"#e69f00", "#56b4e9", "#f0e442", "#cc79a7", "#d55e00", "#009e73", "#bbbbbb", "#6f8fff"},
+	},
+	{
+		name: "protanopia", motion: "#ffb000", face: "#56b4e9", scan: "#f0e442", rec: "#0072b2",
+		hist:  [3]string{"#e69f00", "#f0e442", "#0072b2"},
+		nicks: []string{"#e69f00", "#56b4e9", "#f0e442", "#cc79a7", "#009e73", "#bbbbbb", "#6f8fff", "#ffffff"},
+	},
+	{
+		name: "tritanopia", motion: "#ff2d6f", face: "#00c8c8", scan: "#ff80c0", rec: "#cc0000",
+		hist:  [3]string{"#ff4d4d", "#40d0c0", "#304a9a"},
+		nicks: []string{"#ff4d4d", "#00c8c8", "#ff80c0", "#e0e0e0", "#008b8b", "#b35900", "#a0a0a0", "#ff9f9f"},
+	},
+}
+
+// uiPaletteNames returns the names of the uiPalettes.
+func uiPaletteNames() []string {
+	names := make([]string, len(uiPalettes))
+	for i, p := range uiPalettes {
+		names[i] = p.name
+	}
+	return names
+}
+
+// parsePalette returns the palette of a -cb value.
+func parsePalette(name string) (*uiPalette, error) {
+	for i, p := range uiPalettes {
+		if p.name == name {
+			return &uiPalettes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid color blindness palette %q", name)
+}
+
+// nickColor returns the color of a chat nick, the same for every message.
+func (p *uiPalette) nickColor(nick string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nick))
+	return p.nicks[h.Sum32()%uint32(len(p.nicks))]
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/term"
+)
+
+// graphicsPassthrough returns the multiplexer whose passthrough graphics
+// sequences have to be wrapped in, none if they reach the terminal as they
+// are, and a warning if they won't show at all.
+//
+// tmux 3.4 and later draw sixel images themselves when the outer terminal
+// supports them, which they report in the client's terminal features.
+// Otherwise the images have to pass through tmux, which it only allows
+// with allow-passthrough on; tmux before 3.3 has no such option and always
+// allows it. Whether the outer terminal shows them can't be told from
+// inside tmux, which answers the terminal queries itself.
+func graphicsPassthrough(ctx context.Context) (term.Multiplexer, string) {
+	switch term.DetectMultiplexer() {
+	case term.Tmux:
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "tmux", "display-message", "-p", "#{client_termfeatures}").Output()
+		if err == nil && slices.Contains(strings.Split(strings.TrimSpace(string(out)), ","), "sixel") {
+			return term.NoMultiplexer, ""
+		}
+		out, err = exec.CommandContext(ctx, "tmux", "show-options", "-gv", "allow-passthrough").Output()
+		if err == nil && strings.TrimSpace(string(out)) == "off" {
+			return term.Tmux, "tmux drops sixel graphics, allow them with: tmux set -g allow-passthrough on"
+		}
+		return term.Tmux, ""
+	case term.Screen:
+		return term.NoMultiplexer, "screen can't pass sixel graphics through, use tmux"
+	}
+	return term.NoMultiplexer, ""
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/face"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// frameMsg carries a rendered frame. buf holds the captured frame and has
+// to be returned to pool once the model is done with it.
+type frameMsg struct {
+	buf       *frameBuf
+	pool      *framePool
+	frame     string
+	crop      image.Rectangle
+	pip, hist []string
+	motion    float64         // share of moving pixels, 0 without motion detection
+	faces     []face.Face     // detected faces in frame coordinates, see -faces
+	face      image.Rectangle // largest face before the filters, empty if none
+	bright    float64         // average brightness from 0 to 1, see -ambient
+	spot      motionSpot      // motion in the whole frame, see -follow-motion
+	// filterTook and renderTook are the time the frame spent in the filter
+	// and the render stage
+	filterTook, renderTook time.Duration
+}
+
+// sourceDoneMsg reports that the frame source stopped delivering frames.
+type sourceDoneMsg struct {
+	err error
+}
+
+// frameSettings are the model settings frames are processed with. The
+// model publishes a new snapshot whenever they change.
+type frameSettings struct {
+	width, height    uint
+	profile          termenv.Profile
+	cellAspect       float64 // see model.cellAspect
+	renderer         int
+	charset          int
+	color            color.RGBA
+	zoom, panX, panY float64
+	screen           bool
+	keyed            bool
+	keyColor         colorful.Color
+	bgSample         image.Image
+	threshold        float64
+	filters          string
+	mirror           bool    // mirror on top of filters, see model.mirrorFlip
+	ambient          bool    // measure the brightness for -ambient
+	gamma            float64 // gamma of the -ambient theme, 0 or 1 for none
+	calibrating      bool
+	dim              bool
+	pip              bool
+	split            bool // raw picture next to the processed one, see -split
+	histMode         int
+	palette          *uiPalette
+	motion           int // motionOff, motionTint or motionOutline
+	detectMotion     bool
+	motionThreshold  int
+	motionColor      color.RGBA
+	detectFaces      bool
+	wholeFrame       bool // detect faces outside the crop too
+	followMotion     bool // detect motion in the whole frame for -follow-motion
+	hideFaces        bool
+	privacyStyle     int // privacyPixelate or privacyBlock
+	whiteBalance     bool
+	wbPatch          [4]float64 // reference x, y, width and height relative to the picture, zero for all of it
+	night            nightSettings
+	fast             bool // render raw YUYV frames directly
+}
+
+// nightSettings configure the low-light boost, see -night.
+type nightSettings struct {
+	on      bool
+	gain    float64 // 0 for automatic gain
+	denoise float64
+	tint    filter.NightTint
+}
+
+// nightTints are the names of the night tints, in the order of the
+// filter.NightTint values.
+var nightTints = []string{"color", "green", "gray"}
+
+// Motion highlighting modes, see -motion.
+const (
+	motionOff = iota
+	motionTint
+	motionOutline
+)
+
+// Ways of hiding faces, see -privacy-style.
+const (
+	privacyPixelate = iota
+	privacyBlock
+)
+
+// privacyStyles are the names of the ways of hiding faces.
+var privacyStyles = []string{"pixelate", "block"}
+
+// privacyHold is the number of frames a face stays hidden for after it
+// was detected.
+const privacyHold = 8
+
+// motionModes are the names of the motion highlighting modes.
+var motionModes = []string{"off", "tint", "outline"}
+
+// scaledFrames recycles the scaled frames handed from the filter to the
+// render stage and used for rendering paused frames.
+var scaledFrames = sync.Pool{New: func() any { return new(filter.Scaler) }}
+
+// filteredFrame is a frame on its way from the filter to the render stage.
+type filteredFrame struct {
+	buf       *frameBuf
+	settings  *frameSettings
+	scaled    *filter.Scaler // nil for raw frames
+	crop      image.Rectangle
+	pip, hist []string
+	split     []string // left half of the split view
+	motion    float64
+	faces     []face.Face
+	face      image.Rectangle
+	bright    float64
+	spot      motionSpot
+	took      time.Duration // time in the filter stage
+}
+
+// runPipeline captures width×height frames from src and processes them in
+// three stages, capture, filter and render, that run concurrently and are
+// connected by bounded channels. Rendered frames are sent to prog. With a
+// smooth interval, crossfades between the captured frames are added for
+// sources slower than it. The source is restarted after stall without a
+// frame, if not 0. It returns when the source fails or ends.
+func runPipeline(src source.Source, width, height uint, interval, smooth, stall time.Duration, settings *atomic.Pointer[frameSettings], prog *tea.Program) {
+	_, raw := src.(source.YUYVSource)
+	pool := newFramePool(int(width), int(height), raw)
+
+	captured := make(chan *frameBuf, 1)
+	filtered := make(chan *filteredFrame, 1)
+
+	frames := (<-chan *frameBuf)(captured)
+	if smooth > 0 {
+		smoothed := make(chan *frameBuf, 1)
+		ip := &interpolator{interval: smooth, pool: pool}
+		go ip.run(captured, smoothed)
+		frames = smoothed
+	}
+
+	// filter: convert, crop, scale and key
+	go func() {
+		defer close(filtered)
+		var f frameFilter
+		defer f.closeEffects()
+		var splitScale filter.Scaler
+		for buf := range frames {
+			began := time.Now()
+			s := settings.Load()
+			var left uint
+			if s.split {
+				c := *s
+				left, s = c.splitView(), &c
+			}
+			ff := &filteredFrame{buf: buf, settings: s}
+			if buf.raw && !s.fast {
+				start := time.Now()
+				source.YUYVToRGBA(buf.img, buf.yuyv)
+				stats.add(stageConvert, time.Since(start))
+				buf.raw = false
+			}
+			if buf.raw {
+				ff.crop, _, _ = filter.CropRect(buf.img.Rect, s.zoom, s.panX, s.panY)
+			} else {
+				ff.scaled = scaledFrames.Get().(*filter.Scaler)
+				ff.crop, ff.pip, ff.hist = f.filter(buf.img, s, ff.scaled)
+				if left > 0 {
+					ff.split = renderSplit(&splitScale, buf.img, ff.crop, left, s.height, s.profile)
+				}
+				if s.detectMotion {
+					ff.motion = f.motion.Level()
+				}
+				ff.faces, ff.face, ff.bright, ff.spot = f.faces, f.face, f.bright, f.spot
+			}
+			ff.took = time.Since(began)
+			filtered <- ff
+		}
+	}()
+
+	// render: convert to terminal output
+	go func() {
+		for ff := range filtered {
+			msg := frameMsg{buf: ff.buf, pool: pool, crop: ff.crop, pip: ff.pip, hist: ff.hist, motion: ff.motion, faces: ff.faces, face: ff.face, bright: ff.bright, spot: ff.spot, filterTook: ff.took}
+			start := time.Now()
+			if ff.scaled != nil {
+				msg.frame = renderFrame(ff.settings, ff.scaled.Image())
+				scaledFrames.Put(ff.scaled)
+			} else {
+				msg.frame = renderYUYV(ff.settings, ff.buf.yuyv, ff.buf.img.Rect.Dx(), ff.crop)
+			}
+			if ff.split != nil {
+				msg.frame = joinSplit(ff.split, msg.frame)
+			}
+			msg.renderTook = time.Since(start)
+			stats.add(stageRender, msg.renderTook)
+			prog.Send(msg)
+		}
+	}()
+
+	wd := &stallWatchdog{timeout: stall, send: prog.Send}
+	err := capture(src, pool, captured, interval, func() bool { return settings.Load().fast }, wd)
+	prog.Send(sourceDoneMsg{err})
+}
+
+// dimFilter dims the frame behind the help overlay.
+var dimFilter = filter.Func(func(img *image.RGBA) { filter.Dim(img, 0.35) })
+
+// frameFilter prepares frames for rendering. It keeps the Lab plane of the
+// scaled background and the preview scaler between frames.
+type frameFilter struct {
+	bg          []filter.Lab // bgSample scaled like the current frame
+	bgScale     filter.Scaler
+	bgSample    image.Image
+	bgCrop      image.Rectangle
+	bgRect      image.Rectangle // size the background is scaled to
+	pipScale    filter.Scaler
+	filters     string       // spec effects was parsed from
+	effects     filter.Chain // user filters
+	mirror      bool         // mirror before the effects, see frameSettings.mirror
+	chain       filter.Chain // filters applied to the current frame
+	motion      filter.Motion
+	wb          filter.WhiteBalance
+	night       filter.Night
+	detector    *face.Detector
+	faces       []face.Face                    // faces in the last frame, as shown after the filters
+	face        image.Rectangle                // largest face in the last frame before the filters
+	hidden      [privacyHold][]image.Rectangle // faces of the recent frames, for -privacy
+	frames      int                            // frames faces were detected in
+	bright      float64                        // brightness of the last frame before the filters
+	follow      filter.Motion                  // motion in the whole frame, see detectSpot
+	followScale filter.Scaler
+	spot        motionSpot
+}
+
+// closeEffects closes the user filters that hold resources, like the
+// programs of exec filters.
+func (f *frameFilter) closeEffects() {
+	for _, e := range f.effects {
+		if c, ok := e.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+	f.effects = nil
+}
+
+// filter crops img to the zoomed in part, scales it into scaled and
+// applies the greenscreen and the filter chain. It returns the crop and the
+// rendered preview and histogram lines.
+func (f *frameFilter) filter(img *image.RGBA, s *frameSettings, scaled *filter.Scaler) (image.Rectangle, []string, []string) {
+	// crop to the zoomed in part of the frame
+	crop, _, _ := filter.CropRect(img.Bounds(), s.zoom, s.panX, s.panY)
+
+	if f.effects == nil || f.filters != s.filters {
+		// validated on startup
+		f.closeEffects()
+		f.effects, _ = filter.Parse(s.filters)
+		f.filters = s.filters
+	}
+	f.mirror = s.mirror
+
+	// faces are hidden before anything else sees the frame
+	f.faces, f.face = nil, image.Rectangle{}
+	if s.detectFaces {
+		area := crop
+		if s.wholeFrame {
+			area = img.Bounds()
+		}
+		f.detectFaces(img, area, crop)
+	}
+	if s.hideFaces {
+		f.hideFaces(img, s.privacyStyle)
+	}
+	if s.followMotion {
+		f.spot = f.detectSpot(img, s.motionThreshold)
+	}
+
+	// preview of the whole frame, unprocessed but for hidden faces
+	var pip []string
+	if s.pip {
+		pip = renderPiP(&f.pipScale, img, s.width/4, s.cellAspect, s.profile)
+	}
+
+	var hist []string
+	if s.histMode > 0 {
+		hist = renderHistogram(computeHistogram(img, crop, 2), s.histMode == 2, s.profile, s.palette)
+	}
+
+	// resize for further processing, each renderer packs a different
+	// amount of pixels into a terminal cell
+	r := render.Modes()[s.renderer]
+	imgW, imgH := s.width*r.CellW, s.height*r.CellH
+	start := time.Now()
+	out := scaled.Scale(img, crop, imgW, imgH)
+	stats.add(stageResize, time.Since(start))
+	start = time.Now()
+
+	if s.ambient {
+		f.bright = meanBrightness(out)
+	}
+
+	// motion of the unprocessed picture, highlighted after the filters
+	if s.detectMotion {
+		f.motion.Threshold = s.motionThreshold
+		f.motion.Detect(out)
+	}
+
+	// virtual green screen, then the user filters
+	f.chain = f.chain[:0]
+	switch {
+	case s.calibrating || !s.screen:
+	case s.keyed:
+		f.chain = append(f.chain, filter.Key{Color: s.keyColor, Dist: s.threshold})
+	case s.bgSample != nil:
+		if f.bg == nil || f.bgSample != s.bgSample || f.bgCrop != crop || f.bgRect != out.Rect {
+			f.bgSample = s.bgSample
+			f.bgCrop = crop
+			f.bgRect = out.Rect
+			f.bg = filter.LabPlane(f.bg, f.bgScale.Scale(s.bgSample, crop, imgW, imgH))
+		}
+		f.chain = append(f.chain, filter.Background{Plane: f.bg, Dist: s.threshold})
+	}
+	// after the greenscreen, which compares with uncorrected frames
+	if s.whiteBalance {
+		p := s.wbPatch
+		f.wb.Patch = image.Rect(
+			int(p[0]*float64(imgW)), int(p[1]*float64(imgH)),
+			int((p[0]+p[2])*float64(imgW)), int((p[1]+p[3])*float64(imgH)),
+		)
+		f.chain = append(f.chain, &f.wb)
+	}
+	if s.night.on {
+		f.night.Gain, f.night.Denoise, f.night.Tint = s.night.gain, s.night.denoise, s.night.tint
+		f.chain = append(f.chain, &f.night)
+	}
+	if s.gamma > 0 && s.gamma != 1 {
+		f.chain = append(f.chain, filter.Gamma{Gamma: s.gamma})
+	}
+	if s.mirror {
+		f.chain = append(f.chain, filter.Mirror{})
+	}
+	f.chain = append(f.chain, f.effects...)
+	switch s.motion {
+	case motionTint:
+		f.chain = append(f.chain, filter.Func(func(img *image.RGBA) { f.motion.Tint(img, s.motionColor, 0.5) }))
+	case motionOutline:
+		f.chain = append(f.chain, filter.Func(func(img *image.RGBA) { f.motion.Outline(img, s.motionColor) }))
+	}
+
+	// dim the frame behind the help overlay
+	if s.dim {
+		f.chain = append(f.chain, dimFilter)
+	}
+	f.chain.Apply(out)
+	stats.add(stageFilter, time.Since(start))
+
+	return crop, pip, hist
+}
+
+// detectFaces finds the faces in the area part of img. The faces shown are
+// moved like the picture in crop by the mirror and flip filters, which run
+// later.
+func (f *frameFilter) detectFaces(img *image.RGBA, area, crop image.Rectangle) {
+	if f.detector == nil {
+		// the embedded cascade is known to load
+		f.detector, _ = face.NewDetector()
+	}
+	start := time.Now()
+	faces := f.detector.Detect(img, area)
+	stats.add(stageFaces, time.Since(start))
+
+	f.hidden[f.frames%len(f.hidden)] = f.hidden[f.frames%len(f.hidden)][:0]
+	for _, fc := range faces {
+		f.hidden[f.frames%len(f.hidden)] = append(f.hidden[f.frames%len(f.hidden)], fc.Rect)
+	}
+	f.frames++
+
+	for i, fc := range faces {
+		if fc.Rect.Dx()*fc.Rect.Dy() > f.face.Dx()*f.face.Dy() {
+			f.face = fc.Rect
+		}
+		r := fc.Rect
+		if f.mirror {
+			r.Min.X, r.Max.X = crop.Min.X+crop.Max.X-r.Max.X, crop.Min.X+crop.Max.X-r.Min.X
+		}
+		for _, e := range f.effects {
+			switch e.(type) {
+			case filter.Mirror:
+				r.Min.X, r.Max.X = crop.Min.X+crop.Max.X-r.Max.X, crop.Min.X+crop.Max.X-r.Min.X
+			case filter.Flip:
+				r.Min.Y, r.Max.Y = crop.Min.Y+crop.Max.Y-r.Max.Y, crop.Min.Y+crop.Max.Y-r.Min.Y
+			}
+		}
+		faces[i].Rect = r
+	}
+	f.faces = faces
+}
+
+// hideFaces pixelates or masks the faces of img found in the last
+// privacyHold frames, so a face the detector misses for a frame or two
+// stays hidden. The face squares are enlarged to cover hair and chin.
+func (f *frameFilter) hideFaces(img *image.RGBA, style int) {
+	for _, rects := range f.hidden {
+		for _, r := range rects {
+			d := r.Dx() / 5
+			r = image.Rect(r.Min.X-d, r.Min.Y-2*d, r.Max.X+d, r.Max.Y+d)
+			switch style {
+			case privacyPixelate:
+				filter.Pixelate{Rect: r, Size: max(2, r.Dx()/6)}.Apply(img)
+			case privacyBlock:
+				filter.Fill{Rect: r, Color: color.RGBA{A: 255}}.Apply(img)
+			}
+		}
+	}
+}
+
+// renderFrame converts a filtered frame to terminal output.
+func renderFrame(s *frameSettings, img *image.RGBA) string {
+	r := render.Modes()[s.renderer].New(s.renderOptions())
+	return string(bytes.TrimSuffix(r.Render(img, int(s.width), int(s.height)), []byte("\n")))
+}
+
+// renderOptions returns the render options of s.
+func (s *frameSettings) renderOptions() render.Options {
+	return render.Options{Profile: s.profile, Pixels: render.Charsets[s.charset].Pixels, Color: s.color}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/muesli/termenv"
+)
+
+// qrQuiet is the light margin around QR codes in modules. The standard
+// asks for 4, but scanners read codes with 2 fine and it saves space.
+const qrQuiet = 2
+
+// qrBlocks encodes text as a QR code drawn with half blocks, two modules
+// per cell. Light modules are the filled parts, so the code reads right
+// on dark terminals; drawQR paints the colors to be sure.
+func qrBlocks(text string) ([]string, error) {
+	code, err := qr.Encode(text, qr.M, qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	b := code.Bounds()
+	n := b.Dx() + 2*qrQuiet
+	light := func(x, y int) bool {
+		x, y = x-qrQuiet, y-qrQuiet
+		if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+			return true
+		}
+		return color.GrayModel.Convert(code.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y >= 128
+	}
+
+	var block []string
+	for y := 0; y < n; y += 2 {
+		var l strings.Builder
+		for x := range n {
+			top, bottom := light(x, y), y+1 < n && light(x, y+1)
+			switch {
+			case top && bottom:
+				l.WriteString("█")
+			case top:
+				l.WriteString("▀")
+			case bottom:
+				l.WriteString("▄")
+			default:
+				l.WriteString(" ")
+			}
+		}
+		block = append(block, l.String())
+	}
+	return block, nil
+}
+
+// drawQR overlays the QR code of -overlay-qr in its corner, in white on
+// black unless the terminal has no colors. A cut off code doesn't scan,
+// so it is left out while the output is too small for it.
+func (m *model) drawQR(lines []string) {
+	w := utf8.RuneCountInString(m.qr[0])
+	if top, _, bottom := m.cornerAt(lines, m.qrPos, w, len(m.qr)); bottom-top < len(m.qr) || int(m.width) < w {
+		return
+	}
+	block := m.qr
+	if m.profile != termenv.Ascii {
+		style := termenv.Style{}.Foreground(m.profile.Color("#ffffff")).Background(m.profile.Color("#000000"))
+		block = make([]string, len(m.qr))
+		for i, l := range m.qr {
+			block[i] = style.Styled(l)
+		}
+	}
+	m.drawCorner(lines, m.qrPos, block)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// rampSets are the named candidate characters of -chars.
+var rampSets = map[string]func() []rune{
+	"ascii":  func() []rune { return runeRange(0x20, 0x7e) },
+	"latin":  func() []rune { return append(runeRange(0x20, 0x7e), runeRange(0xa1, 0xff)...) },
+	"blocks": func() []rune { return append([]rune{' '}, runeRange(0x2580, 0x259f)...) },
+	"shapes": func() []rune { return append([]rune{' '}, runeRange(0x25a0, 0x25ff)...) },
+}
+
+// rampSetNames returns the names of the rampSets.
+func rampSetNames() []string {
+	names := make([]string, 0, len(rampSets))
+	for name := range rampSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runeRange returns the runes from lo to hi.
+func runeRange(lo, hi rune) []rune {
+	rs := make([]rune, 0, hi-lo+1)
+	for r := lo; r <= hi; r++ {
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// rampFlags are the command line options of the ramp command.
+type rampFlags struct {
+	chars   *string
+	steps   *int
+	size    *float64
+	name    *string
+	verbose *bool
+}
+
+// newRampFlags declares the options of the ramp command.
+func newRampFlags() (*flag.FlagSet, *rampFlags) {
+	fs := flag.NewFlagSet("ramp", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: asciicam ramp [flags] <font.ttf or .otf>")
+		fs.PrintDefaults()
+	}
+	return fs, &rampFlags{
+		chars:   fs.String("chars", "ascii", "Candidate characters: "+strings.Join(rampSetNames(), ", ")+", or the characters themselves"),
+		steps:   fs.Int("steps", 16, "Characters in the ramp, 0 for every distinct density"),
+		size:    fs.Float64("size", 48, "Font size in pixels the glyphs are measured at"),
+		name:    fs.String("name", "", "Name of the charset, by default that of the font file"),
+		verbose: fs.Bool("v", false, "List the density of every character on stderr"),
+	}
+}
+
+// glyphInk is a character with the share of its cell covered by ink.
+type glyphInk struct {
+	r       rune
+	density float64
+}
+
+// runRamp measures how much of a terminal cell the glyphs of a font cover
+// and prints the characters sorted by it as a charset for the config file.
+// The hard-coded ramps are ordered for a typical font; fonts differ enough
+// for neighbors of a ramp to swap places, which shows as banding.
+func runRamp(args []string) error {
+	fs, o := newRampFlags()
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("no font given")
+	}
+	if *o.steps == 1 || *o.steps < 0 {
+		return fmt.Errorf("invalid number of steps %d", *o.steps)
+	}
+	if *o.size < 4 {
+		return fmt.Errorf("invalid font size %v", *o.size)
+	}
+	path := fs.Arg(0)
+	name := *o.name
+	if name == "" {
+		name = strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	candidates := []rune(*o.chars)
+	if set, ok := rampSets[*o.chars]; ok {
+		candidates = set()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse font: %w", err)
+	}
+	inks, err := measureGlyphs(f, *o.size, candidates)
+	if err != nil {
+		return err
+	}
+	ramp := pickRamp(inks, *o.steps)
+	if len(ramp) < 2 {
+		return errors.New("the font has less than two distinct glyphs of the candidates")
+	}
+
+	if *o.verbose {
+		tw := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "char\tdensity\tin ramp")
+		for _, g := range inks {
+			in := ""
+			if slices.Contains(ramp, g) {
+				in = "*"
+			}
+			fmt.Fprintf(tw, "%q\t%.4f\t%s\n", g.r, g.density, in)
+		}
+		_ = tw.Flush()
+	}
+	var pixels strings.Builder
+	for _, g := range ramp {
+		pixels.WriteRune(g.r)
+	}
+	return toml.NewEncoder(os.Stdout).Encode(map[string]map[string]string{"charsets": {name: pixels.String()}})
+}
+
+// measureGlyphs draws every candidate the font has a glyph for into a
+// terminal cell of the font at size pixels: the advance of "M" wide and a
+// line high. It returns the candidates sorted by the share of the cell
+// they cover, from empty to full.
+func measureGlyphs(f *sfnt.Font, size float64, candidates []rune) ([]glyphInk, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+	adv, ok := face.GlyphAdvance('M')
+	if !ok {
+		return nil, errors.New("the font has no M to measure the cell width by")
+	}
+	m := face.Metrics()
+	cell := image.Rect(0, 0, adv.Ceil(), (m.Ascent + m.Descent).Ceil())
+	if cell.Empty() {
+		return nil, errors.New("the font has an empty cell")
+	}
+
+	var buf sfnt.Buffer
+	img := image.NewAlpha(cell)
+	d := &font.Drawer{Dst: img, Src: image.Opaque, Face: face, Dot: fixed.Point26_6{Y: m.Ascent}}
+	seen := make(map[rune]bool)
+	var inks []glyphInk
+	for _, r := range candidates {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		// glyph 0 is the replacement box of missing characters
+		if i, err := f.GlyphIndex(&buf, r); err != nil || i == 0 && r != ' ' {
+			continue
+		}
+		clear(img.Pix)
+		d.Dot.X = 0
+		d.DrawString(string(r))
+		sum := 0
+		for _, a := range img.Pix {
+			sum += int(a)
+		}
+		inks = append(inks, glyphInk{r, float64(sum) / float64(len(img.Pix)*255)})
+	}
+	sort.SliceStable(inks, func(i, j int) bool { return inks[i].density < inks[j].density })
+	return inks, nil
+}
+
+// rampEpsilon is the least difference in density between neighbors of a
+// ramp; closer glyphs look the same and only one of them is kept.
+const rampEpsilon = 0.002
+
+// pickRamp returns steps glyphs of the sorted inks whose densities are
+// spread evenly from the emptiest to the fullest glyph, or every glyph of
+// a distinct density for 0 steps.
+func pickRamp(inks []glyphInk, steps int) []glyphInk {
+	var distinct []glyphInk
+	for _, g := range inks {
+		if len(distinct) == 0 || g.density-distinct[len(distinct)-1].density >= rampEpsilon {
+			distinct = append(distinct, g)
+		}
+	}
+	if steps == 0 || len(distinct) <= steps {
+		return distinct
+	}
+
+	lo, hi := distinct[0].density, distinct[len(distinct)-1].density
+	ramp := make([]glyphInk, 0, steps)
+	next := 0 // first glyph that is still free
+	for i := range steps {
+		target := lo + (hi-lo)*float64(i)/float64(steps-1)
+		// closest free glyph, leaving enough for the remaining steps
+		last := len(distinct) - (steps - i)
+		best := next
+		for j := next; j <= last; j++ {
+			if math.Abs(distinct[j].density-target) < math.Abs(distinct[best].density-target) {
+				best = j
+			}
+		}
+		ramp = append(ramp, distinct[best])
+		next = best + 1
+	}
+	return ramp
+}
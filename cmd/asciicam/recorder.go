@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// recorder writes a session to a file.
+type recorder interface {
+	// WriteFrame records the raw camera frame and its rendered output.
+	WriteFrame(raw *image.RGBA, rendered string, t time.Time) error
+	Close() error
+}
+
+// newRecorder creates a recording of the given format (cast, gif or mp4)
+// in dir and returns it together with the file path.
+func newRecorder(dir, format string, width, height uint) (recorder, string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, "", fmt.Errorf("failed to create recording dir: %w", err)
+	}
+	path := filepath.Join(dir, "asciicam-"+time.Now().Format("20060102-150405")+"."+format)
+
+	switch format {
+	case "cast":
+		r, err := newCastRecorder(path, width, height)
+		return r, path, err
+	case "gif":
+		return &gifRecorder{path: path}, path, nil
+	case "mp4":
+		return &mp4Recorder{path: path}, path, nil
+	default:
+		return nil, "", fmt.Errorf("unknown recording format %q", format)
+	}
+}
+
+// castRecorder writes the rendered output as an asciicast v2 file.
+// Event times are relative to the first frame.
+type castRecorder struct {
+	f       *os.File
+	start   time.Time
+	started bool
+}
+
+func newCastRecorder(path string, width, height uint) (*castRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": time.Now().Unix(),
+		"env":       map[string]string{"TERM": os.Getenv("TERM")},
+	})
+	if _, err := fmt.Fprintf(f, "%s\n", header); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &castRecorder{f: f}, nil
+}
+
+func (r *castRecorder) WriteFrame(_ *image.RGBA, rendered string, t time.Time) error {
+	if !r.started {
+		r.start, r.started = t, true
+	}
+	out := "\x1b[H" + strings.ReplaceAll(rendered, "\n", "\r\n")
+	event, _ := json.Marshal([]any{t.Sub(r.start).Seconds(), "o", out})
+	_, err := fmt.Fprintf(r.f, "%s\n", event)
+	return err
+}
+
+func (r *castRecorder) Close() error {
+	return r.f.Close()
+}
+
+// gifRecorder collects the raw frames and encodes them as an animated GIF
+// when closed.
+type gifRecorder struct {
+	path string
+	anim gif.GIF
+	last time.Time
+}
+
+func (r *gifRecorder) WriteFrame(raw *image.RGBA, _ string, t time.Time) error {
+	// delay of the previous frame in 100ths of a second
+	if n := len(r.anim.Delay); n > 0 {
+		r.anim.Delay[n-1] = max(2, int(t.Sub(r.last)/(10*time.Millisecond)))
+	}
+	r.last = t
+
+	img := image.NewPaletted(raw.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(img, img.Bounds(), raw, raw.Bounds().Min)
+	r.anim.Image = append(r.anim.Image, img)
+	r.anim.Delay = append(r.anim.Delay, 4)
+	return nil
+}
+
+func (r *gifRecorder) Close() error {
+	if len(r.anim.Image) == 0 {
+		return nil
+	}
+
+	f, err := os.Create(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	if err := gif.EncodeAll(f, &r.anim); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	return f.Close()
+}
+
+// mp4Recorder pipes the raw frames into ffmpeg. ffmpeg is started with the
+// first frame, when the frame size is known.
+type mp4Recorder struct {
+	path  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func (r *mp4Recorder) WriteFrame(raw *image.RGBA, _ string, _ time.Time) error {
+	b := raw.Bounds()
+	if r.cmd == nil {
+		r.cmd = exec.Command("ffmpeg", "-loglevel", "error", "-y",
+			"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", b.Dx(), b.Dy()),
+			"-use_wallclock_as_timestamps", "1", "-i", "-",
+			"-c:v", "libx264", "-pix_fmt", "yuv420p", "-vsync", "vfr", r.path)
+		stdin, err := r.cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		if err := r.cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start ffmpeg: %w", err)
+		}
+		r.stdin = stdin
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := raw.PixOffset(b.Min.X, y)
+		if _, err := r.stdin.Write(raw.Pix[i : i+b.Dx()*4]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mp4Recorder) Close() error {
+	if r.cmd == nil {
+		return nil
+	}
+	_ = r.stdin.Close()
+	return r.cmd.Wait()
+}
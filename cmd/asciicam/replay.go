@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// replayFlags are the command line options of the replay command.
+type replayFlags struct {
+	speed   *float64
+	maxIdle *time.Duration
+}
+
+// newReplayFlags declares the options of the replay command.
+func newReplayFlags() (*flag.FlagSet, *replayFlags) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: asciicam replay [flags] <recording.cast>")
+		fs.PrintDefaults()
+	}
+	return fs, &replayFlags{
+		speed:   fs.Float64("speed", 1, "Playback speed factor"),
+		maxIdle: fs.Duration("max-idle", 2*time.Second, "Shorten pauses longer than this, 0 to keep them"),
+	}
+}
+
+// runReplay plays an asciicast v2 recording back to the terminal with its
+// original timing.
+func runReplay(ctx context.Context, args []string) error {
+	fs, o := newReplayFlags()
+	speed, maxIdle := o.speed, o.maxIdle
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *speed <= 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16<<20) // one event holds a whole frame
+	var header struct {
+		Version int `json:"version"`
+	}
+	if !sc.Scan() {
+		return errors.New("empty recording")
+	}
+	if err := json.Unmarshal(sc.Bytes(), &header); err != nil || header.Version != 2 {
+		return errors.New("not an asciicast v2 recording")
+	}
+
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+
+	start := time.Now()
+	var last, skipped float64 // seconds, in recording time
+	for sc.Scan() {
+		var event [3]any
+		if err := json.Unmarshal(sc.Bytes(), &event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		t, _ := event[0].(float64)
+		kind, _ := event[1].(string)
+		data, _ := event[2].(string)
+		if kind != "o" {
+			continue
+		}
+		if idle := *maxIdle; idle > 0 && t-last > idle.Seconds() {
+			skipped += t - last - idle.Seconds()
+		}
+		last = t
+
+		at := start.Add(time.Duration((t - skipped) / *speed * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(at)):
+		}
+		if _, err := os.Stdout.WriteString(data); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
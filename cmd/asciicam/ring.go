@@ -0,0 +1,96 @@
+package main
+
+import (
+	"image"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// ringFrames is the number of recent frames kept for stepping, about two
+// seconds at 30 fps.
+const ringFrames = 60
+
+// prerollFPS is the frame rate the ring is sized for to hold the pre-roll
+// of motion triggered recordings.
+const prerollFPS = 30
+
+// frameRing keeps the most recent frames for stepping through them while
+// paused and as pre-roll of motion triggered recordings.
+type frameRing struct {
+	frames []ringFrame
+	next   int
+	count  int
+}
+
+// ringFrame is a stored frame. Frames pushed as raw YUYV are converted to
+// RGBA when they are first requested.
+type ringFrame struct {
+	img   *image.RGBA
+	yuyv  []byte
+	stale bool // img has to be converted from yuyv
+	at    time.Time
+	frame string // rendered output
+}
+
+func newFrameRing(size int) *frameRing {
+	return &frameRing{frames: make([]ringFrame, size)}
+}
+
+// Push copies img, captured at at and rendered as frame, over the oldest
+// frame and returns the copy. The frame buffers are allocated once and
+// reused as long as the size stays the same.
+func (r *frameRing) Push(img *image.RGBA, at time.Time, frame string) *image.RGBA {
+	f := r.slot(img.Rect, at, frame)
+	copy(f.img.Pix, img.Pix)
+	return f.img
+}
+
+// PushYUYV copies a raw YUYV frame of the given size over the oldest frame.
+func (r *frameRing) PushYUYV(yuyv []byte, rect image.Rectangle, at time.Time, frame string) {
+	f := r.slot(rect, at, frame)
+	f.yuyv = append(f.yuyv[:0], yuyv...)
+	f.stale = true
+}
+
+// slot advances the ring and returns the slot of the new frame.
+func (r *frameRing) slot(rect image.Rectangle, at time.Time, frame string) *ringFrame {
+	f := &r.frames[r.next]
+	if f.img == nil || f.img.Rect != rect {
+		f.img = image.NewRGBA(rect)
+	}
+	f.stale = false
+	f.at, f.frame = at, frame
+
+	r.next = (r.next + 1) % len(r.frames)
+	r.count = min(r.count+1, len(r.frames))
+	return f
+}
+
+// Len returns the number of stored frames.
+func (r *frameRing) Len() int {
+	return r.count
+}
+
+// At returns the frame back steps before the newest one.
+func (r *frameRing) At(back int) *image.RGBA {
+	if back < 0 || back >= r.count {
+		return nil
+	}
+	f := &r.frames[(r.next-1-back+2*len(r.frames))%len(r.frames)]
+	if f.stale {
+		source.YUYVToRGBA(f.img, f.yuyv)
+		f.stale = false
+	}
+	return f.img
+}
+
+// Info returns the capture time and the rendered output of the frame back
+// steps before the newest one.
+func (r *frameRing) Info(back int) (time.Time, string) {
+	if back < 0 || back >= r.count {
+		return time.Time{}, ""
+	}
+	f := &r.frames[(r.next-1-back+2*len(r.frames))%len(r.frames)]
+	return f.at, f.frame
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// frameHub hands rendered frames to the clients streaming them.
+type frameHub struct {
+	mu     sync.Mutex
+	subs   map[chan *rpc.Frame]struct{}
+	number uint64
+	latest *rpc.Frame
+}
+
+// subscribe returns a channel receiving the frames published from now on
+// and a function that ends the subscription.
+func (h *frameHub) subscribe() (<-chan *rpc.Frame, func()) {
+	ch := make(chan *rpc.Frame, 1)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan *rpc.Frame]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// publish sends a frame to the subscribers, dropping it for those still
+// busy with the previous one.
+func (h *frameHub) publish(text string, width, height uint, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f := &rpc.Frame{Text: text, Width: uint32(width), Height: uint32(height), TimeUnixNano: at.UnixNano(), Number: h.number}
+	h.number++
+	h.latest = f
+	for ch := range h.subs {
+		select {
+		case ch <- f:
+		default:
+		}
+	}
+}
+
+// last returns the newest frame, nil before the first one.
+func (h *frameHub) last() *rpc.Frame {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latest
+}
+
+// settingsMsg asks the model for its settings after applying update, if
+// not nil. The model answers on reply.
+type settingsMsg struct {
+	update *rpc.UpdateSettingsRequest
+	reply  chan settingsReply
+}
+
+type settingsReply struct {
+	settings *rpc.Settings
+	err      error
+}
+
+// applySettings applies the settings set in u, all or none of them, and
+// returns the resulting settings.
+func (m *model) applySettings(u *rpc.UpdateSettingsRequest) (*rpc.Settings, error) {
+	if u != nil {
+		mode, charset := m.renderer, m.charset
+		var err error
+		if u.Mode != nil {
+			if mode, err = render.Index(*u.Mode); err != nil {
+				return nil, err
+			}
+		}
+		if u.Charset != nil {
+			if charset, err = render.CharsetIndex(*u.Charset); err != nil {
+				return nil, err
+			}
+		}
+		if u.Width != nil && *u.Width < minWidth || u.Height != nil && *u.Height < minHeight {
+			return nil, errors.New("output size too small")
+		}
+		if u.Width != nil && *u.Width > maxWidth || u.Height != nil && *u.Height > maxHeight {
+			return nil, fmt.Errorf("output size too large, at most %dx%d", maxWidth, maxHeight)
+		}
+		if u.Threshold != nil && *u.Threshold < 0 {
+			return nil, errors.New("invalid threshold")
+		}
+
+		m.renderer, m.charset = mode, charset
+		if u.Width != nil {
+			m.width, m.autoWidth = uint(*u.Width), false
+		}
+		if u.Height != nil {
+			m.height, m.autoHeight = uint(*u.Height), false
+		}
+		if u.Threshold != nil {
+			m.threshold = *u.Threshold
+		}
+		if u.Greenscreen != nil {
+			m.screen = *u.Greenscreen
+		}
+	}
+
+	s := &rpc.Settings{
+		Width:       uint32(m.width),
+		Height:      uint32(m.height),
+		Threshold:   m.threshold,
+		Mode:        render.Modes()[m.renderer].Name,
+		Charset:     render.Charsets[m.charset].Name,
+		Greenscreen: m.screen,
+	}
+	for _, mode := range render.Modes() {
+		s.Modes = append(s.Modes, mode.Name)
+	}
+	for _, cs := range render.Charsets {
+		s.Charsets = append(s.Charsets, cs.Name)
+	}
+	return s, nil
+}
+
+// rpcServer implements the gRPC service on top of the running program.
+type rpcServer struct {
+	rpc.UnimplementedAsciicamServer
+	prog   *tea.Program
+	frames *frameHub
+	done   <-chan struct{} // closed when the program ends
+}
+
+func (s *rpcServer) StreamFrames(_ *rpc.StreamFramesRequest, stream grpc.ServerStreamingServer[rpc.Frame]) error {
+	frames, stop := s.frames.subscribe()
+	defer stop()
+	for {
+		select {
+		case f := <-frames:
+			if err := stream.Send(f); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+func (s *rpcServer) GetSettings(ctx context.Context, _ *rpc.GetSettingsRequest) (*rpc.Settings, error) {
+	return s.settings(ctx, nil)
+}
+
+func (s *rpcServer) UpdateSettings(ctx context.Context, u *rpc.UpdateSettingsRequest) (*rpc.Settings, error) {
+	return s.settings(ctx, u)
+}
+
+// settings has the model apply u and returns the resulting settings.
+func (s *rpcServer) settings(ctx context.Context, u *rpc.UpdateSettingsRequest) (*rpc.Settings, error) {
+	reply := make(chan settingsReply, 1)
+	go s.prog.Send(settingsMsg{update: u, reply: reply})
+	select {
+	case r := <-reply:
+		if r.err != nil {
+			return nil, status.Error(codes.InvalidArgument, r.err.Error())
+		}
+		return r.settings, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.done:
+		return nil, status.Error(codes.Unavailable, "asciicam is shutting down")
+	}
+}
+
+// serveRPC serves the gRPC service on addr until the returned function is
+// called.
+func serveRPC(addr string, prog *tea.Program, frames *frameHub) (func(), error) {
+	lis, err := listen(addr, "grpc")
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	srv := grpc.NewServer()
+	rpc.RegisterAsciicamServer(srv, &rpcServer{prog: prog, frames: frames, done: done})
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			slog.Error("gRPC server failed", "err", err)
+		}
+	}()
+	slog.Info("serving gRPC", "addr", lis.Addr())
+	return func() {
+		close(done)
+		srv.GracefulStop()
+	}, nil
+}
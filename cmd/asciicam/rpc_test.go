@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// settingsModel answers the settings messages of the servers with m, as
+// the TUI does, without rendering anything.
+type settingsModel struct{ m *model }
+
+func (s settingsModel) Init() tea.Cmd { return nil }
+
+func (s settingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case settingsMsg:
+		st, err := s.m.applySettings(msg.update)
+		msg.reply <- settingsReply{st, err}
+	case apiSettingsMsg:
+		st, err := s.m.applyAPISettings(msg.update)
+		msg.reply <- apiSettingsReply{st, err}
+	}
+	return s, nil
+}
+
+func (s settingsModel) View() string { return "" }
+
+// runSettings runs a program answering the settings messages with m until
+// the test ends.
+func runSettings(t *testing.T, m *model) *tea.Program {
+	t.Helper()
+	p := tea.NewProgram(settingsModel{m}, tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = p.Run()
+	}()
+	t.Cleanup(func() {
+		p.Quit()
+		<-done
+	})
+	return p
+}
+
+func TestSettingsRefusesOversize(t *testing.T) {
+	m := &model{width: 80, height: 24}
+	done := make(chan struct{})
+	defer close(done)
+	srv := &rpcServer{prog: runSettings(t, m), done: done}
+
+	for _, u := range []*rpc.UpdateSettingsRequest{
+		{Width: ptr(uint32(maxWidth + 1))},
+		{Height: ptr(uint32(maxHeight + 1))},
+		{Width: ptr(uint32(4000000000)), Height: ptr(uint32(40))},
+	} {
+		_, err := srv.UpdateSettings(context.Background(), u)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("update %v: got %v, want InvalidArgument", u, err)
+		}
+		if m.width != 80 || m.height != 24 {
+			t.Errorf("update %v: size changed to %dx%d", u, m.width, m.height)
+		}
+	}
+
+	s, err := srv.UpdateSettings(context.Background(), &rpc.UpdateSettingsRequest{Width: ptr(uint32(maxWidth)), Height: ptr(uint32(maxHeight))})
+	if err != nil || s.Width != maxWidth || s.Height != maxHeight {
+		t.Errorf("largest size: got %v, %v", s, err)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	mqrcode "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/muesli/termenv"
+)
+
+const (
+	scanInterval = 250 * time.Millisecond // least time between scans
+	scanHold     = time.Second            // time a code stays marked after it was last seen
+)
+
+// scanCode is a code found in a frame.
+type scanCode struct {
+	format string
+	text   string
+	rect   image.Rectangle // in raw frame coordinates
+	seen   time.Time
+}
+
+// scanMsg carries the codes found in a frame, see -scan.
+type scanMsg struct {
+	codes []scanCode
+}
+
+// scanner decodes QR codes and barcodes in the frames, see -scan. The
+// model hands it a copy of the newest frame whenever the last scan is done
+// and scanInterval has passed; the codes found come back as a scanMsg.
+type scanner struct {
+	frames chan *image.RGBA
+	send   func(tea.Msg)
+	busy   bool // a frame is being scanned
+	last   time.Time
+
+	codes []scanCode // codes marked on the picture
+	found []string   // payloads found so far, in order
+}
+
+func newScanner() *scanner {
+	return &scanner{frames: make(chan *image.RGBA, 1)}
+}
+
+// scanReaders are the decoders for single codes tried on every frame,
+// after the QR code reader that finds several at once.
+var scanReaders = []gozxing.Reader{
+	oned.NewMultiFormatUPCEANReader(nil),
+	oned.NewCode128Reader(),
+	oned.NewCode39Reader(),
+	oned.NewCode93Reader(),
+	oned.NewITFReader(),
+	oned.NewCodaBarReader(),
+	datamatrix.NewDataMatrixReader(),
+	aztec.NewAztecReader(),
+}
+
+// run decodes the frames handed to it until the channel is closed.
+func (s *scanner) run() {
+	qr := mqrcode.NewQRCodeMultiReader()
+	for img := range s.frames {
+		bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+		if err != nil {
+			s.send(scanMsg{})
+			continue
+		}
+		var results []*gozxing.Result
+		results, _ = qr.DecodeMultipleWithoutHint(bmp)
+		for _, r := range scanReaders {
+			if res, err := r.DecodeWithoutHints(bmp); err == nil {
+				results = append(results, res)
+			}
+			r.Reset()
+		}
+
+		codes := make([]scanCode, 0, len(results))
+		for _, res := range results {
+			codes = append(codes, scanCode{
+				format: res.GetBarcodeFormat().String(),
+				text:   res.GetText(),
+				rect:   codeRect(res, img.Rect),
+			})
+		}
+		s.send(scanMsg{codes: codes})
+	}
+}
+
+// codeRect returns the rectangle around the points a code was found at.
+// The points of QR codes are the centers of their finder patterns, so
+// their rectangle is grown to the edges. Barcodes are found along a line,
+// so their rectangle is widened to a tenth of the frame height.
+func codeRect(res *gozxing.Result, frame image.Rectangle) image.Rectangle {
+	points := res.GetResultPoints()
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+	x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		x0, x1 = min(x0, p.GetX()), max(x1, p.GetX())
+		y0, y1 = min(y0, p.GetY()), max(y1, p.GetY())
+	}
+	r := image.Rect(int(x0), int(y0), int(math.Ceil(x1)), int(math.Ceil(y1)))
+	if res.GetBarcodeFormat() == gozxing.BarcodeFormat_QR_CODE {
+		r = r.Inset(-r.Dx() / 5)
+	}
+	if pad := frame.Dy()/10 - r.Dy(); pad > 0 {
+		r.Min.Y -= pad / 2
+		r.Max.Y += pad - pad/2
+	}
+	return r.Intersect(frame)
+}
+
+// scanFrame hands the current frame to the scanner if it is idle and due.
+func (m *model) scanFrame(now time.Time) {
+	s := m.scan
+	if s.busy || now.Sub(s.last) < scanInterval {
+		return
+	}
+	raw := m.rawFrame()
+	if raw == nil {
+		return
+	}
+	img := image.NewRGBA(raw.Rect)
+	copy(img.Pix, raw.Pix)
+	s.busy, s.last = true, now
+	s.frames <- img
+}
+
+// scanned takes over the codes found in a frame. New payloads are shown,
+// logged, copied to the clipboard and printed on exit.
+func (m *model) scanned(msg scanMsg, now time.Time) {
+	s := m.scan
+	s.busy = false
+	codes := s.codes[:0]
+	for _, c := range s.codes {
+		if now.Sub(c.seen) < scanHold {
+			codes = append(codes, c)
+		}
+	}
+	for _, c := range msg.codes {
+		c.seen = now
+		i := slices.IndexFunc(codes, func(o scanCode) bool { return o.text == c.text })
+		if i < 0 {
+			codes = append(codes, c)
+		} else {
+			codes[i] = c
+		}
+		if !slices.Contains(s.found, c.text) {
+			s.found = append(s.found, c.text)
+			m.clipboard = c.text
+			slog.Info("scanned code", "format", c.format, "text", c.text)
+			m.setNotice(fmt.Sprintf("%s: %s (copied)", c.format, cleanChat(c.text)))
+		}
+	}
+	s.codes = codes
+}
+
+// drawCodes outlines the codes found on the picture.
+func (m *model) drawCodes(lines []string) {
+	_, _, bottom := m.cornerAt(lines, "top-left", 0, 0)
+	style := termenv.Style{}.Foreground(m.profile.Color(m.palette.scan))
+	mirrored := m.mirrored()
+	for _, code := range m.scan.codes {
+		r := code.rect
+		if mirrored {
+			// codes are found in the frame before the filters
+			w := m.crop.Min.X + m.crop.Max.X
+			r.Min.X, r.Max.X = w-r.Max.X, w-r.Min.X
+		}
+		c := m.cells(r)
+		if c.Dx() < 2 || c.Dy() < 1 {
+			continue
+		}
+		top := "┏" + strings.Repeat("━", c.Dx()-2) + "┓"
+		end := "┗" + strings.Repeat("━", c.Dx()-2) + "┛"
+		for y := c.Min.Y; y < min(c.Max.Y, bottom); y++ {
+			switch y {
+			case c.Min.Y:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled(top))
+			case c.Max.Y - 1:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled(end))
+			default:
+				lines[y] = overlay(lines[y], c.Min.X, style.Styled("┃"))
+				lines[y] = overlay(lines[y], c.Max.X-1, style.Styled("┃"))
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// snapshotSaved announces a snapshot, base is the common path prefix of
+// its files.
+func (m *model) snapshotSaved(base string) {
+	if m.mqtt != nil {
+		m.mqtt.snapshot(base)
+	}
+}
+
+// saveSnapshot writes the raw frame as PNG and the rendered frame as .ans
+// and .html into dir. It returns the common path prefix of the files.
+func saveSnapshot(dir string, raw image.Image, rendered string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+	base := filepath.Join(dir, "asciicam-"+time.Now().Format("20060102-150405.000"))
+	if err := writeFrameFiles(base, raw, rendered); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// saveLastFrame writes the frame on screen when asciicam exits to the
+// files of saveSnapshot at base, replacing the ones of the last run.
+func (m *model) saveLastFrame(base string) error {
+	raw := m.rawFrame()
+	if raw == nil || m.frame == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return fmt.Errorf("failed to create last frame dir: %w", err)
+	}
+	return writeFrameFiles(base, raw, m.frame)
+}
+
+// writeFrameFiles writes the raw frame to base.png and the rendered frame
+// to base.ans and base.html. Each file is written under a temporary name
+// first, so readers never see half of one.
+func writeFrameFiles(base string, raw image.Image, rendered string) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, raw); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	files := []struct {
+		ext  string
+		data []byte
+	}{
+		{".png", buf.Bytes()},
+		{".ans", []byte(rendered + "\n")},
+		{".html", []byte(ansiToHTML(rendered))},
+	}
+	for _, f := range files {
+		tmp := base + f.ext + ".tmp"
+		if err := os.WriteFile(tmp, f.data, 0o644); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if err := os.Rename(tmp, base+f.ext); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// ansiToHTML converts text with SGR color sequences into a standalone
+// HTML page.
+func ansiToHTML(s string) string {
+	return "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"></head>\n" +
+		"<body style=\"background:#000;color:#ccc\"><pre style=\"font-family:monospace;line-height:1\">" +
+		render.HTML(s) + "</pre></body></html>\n"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// sparkBlocks are the levels of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// frameTimes keeps the time between frames over a window of recent
+// seconds for the FPS graph.
+type frameTimes struct {
+	window time.Duration
+	at     []time.Time     // arrival of the frames, oldest first
+	gap    []time.Duration // time since the frame before each one
+}
+
+// add records a frame arriving at now, gap after the one before.
+func (f *frameTimes) add(now time.Time, gap time.Duration) {
+	i := 0
+	for i < len(f.at) && now.Sub(f.at[i]) > f.window {
+		i++
+	}
+	f.at = append(f.at[:0], f.at[i:]...)
+	f.gap = append(f.gap[:0], f.gap[i:]...)
+	f.at = append(f.at, now)
+	f.gap = append(f.gap, gap)
+}
+
+// sparkline draws the frame times of the window up to now in width cells,
+// each showing the longest gap in its part of the window, so single slow
+// frames stand out. Twice the median gap is drawn at full height, longer
+// gaps are clipped. Parts without frames are blank.
+func (f *frameTimes) sparkline(now time.Time, width int) string {
+	if len(f.gap) == 0 || width <= 0 {
+		return strings.Repeat(" ", width)
+	}
+	sorted := slices.Clone(f.gap)
+	slices.Sort(sorted)
+	top := 2 * sorted[len(sorted)/2]
+
+	cells := make([]time.Duration, width)
+	for i, at := range f.at {
+		age := now.Sub(at)
+		if age < 0 || age >= f.window {
+			continue
+		}
+		c := width - 1 - int(age*time.Duration(width)/f.window)
+		cells[c] = max(cells[c], f.gap[i])
+	}
+
+	var b strings.Builder
+	for _, d := range cells {
+		if d == 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		level := int(int64(d) * int64(len(sparkBlocks)) / max(1, int64(top)))
+		b.WriteRune(sparkBlocks[min(level, len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"image"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// splitSeparator is drawn between the halves of the split view.
+const splitSeparator = "│"
+
+// splitWidths returns the widths of the raw left and the processed right
+// half of the split view of an output width cells wide, leaving a column
+// for the separator.
+func splitWidths(width uint) (left, right uint) {
+	left = (width - 1) / 2
+	return left, width - 1 - left
+}
+
+// splitView narrows s to the processed right half of the split view and
+// returns the width of the raw left half, 0 without the split view.
+func (s *frameSettings) splitView() uint {
+	if !s.split {
+		return 0
+	}
+	left, right := splitWidths(s.width)
+	s.width = right
+	return left
+}
+
+// renderSplit renders the crop part of img, before the greenscreen and the
+// filters, as the w×h left half of the split view in ANSI blocks.
+func renderSplit(sc *filter.Scaler, img *image.RGBA, crop image.Rectangle, w, h uint, p termenv.Profile) []string {
+	small := sc.Scale(img, crop, w, 2*h)
+	out := render.ANSI{Options: render.Options{Profile: p}}.Render(small, int(w), int(h))
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+// joinSplit puts the rendered left half and the processed frame side by
+// side.
+func joinSplit(left []string, frame string) string {
+	lines := strings.Split(frame, "\n")
+	for i := range lines {
+		l := ""
+		if i < len(left) {
+			l = left[i]
+		}
+		lines[i] = l + splitSeparator + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showsSplit reports whether the split view is on. Sixel graphics can't be
+// put side by side with text, so it is off in the sixel mode.
+func (m *model) showsSplit() bool {
+	return m.split && render.Modes()[m.renderer].Name != "sixel"
+}
+
+// drawSplitLabels names the halves of the split view in the top row.
+func (m *model) drawSplitLabels(lines []string) {
+	left, right := splitWidths(max(m.width, minWidth))
+	raw, processed := " raw ", " processed "
+	if len(lines) == 0 || int(right) < ansi.StringWidth(processed) {
+		return
+	}
+	style := termenv.Style{}.Reverse()
+	lines[0] = overlay(lines[0], 0, style.Styled(raw))
+	lines[0] = overlay(lines[0], int(left)+1, style.Styled(processed))
+}
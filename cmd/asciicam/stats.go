@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// stage is a step of getting a frame from the camera onto the screen.
+type stage int
+
+const (
+	stageCapture stage = iota // reading the frame from the source
+	stageConvert              // converting YUYV to RGBA
+	stageResize               // cropping and scaling
+	stageFilter               // greenscreen and filter chain
+	stageFaces                // face detection
+	stageRender               // converting to terminal output
+	stageWrite                // writing to the terminal
+	stageLatency              // from capture until the rendered frame reaches the model
+	numStages
+)
+
+var stageNames = [numStages]string{"capture", "convert", "resize", "filter", "faces", "render", "write", "latency"}
+
+// statsWindow is the number of recent samples kept per stage.
+const statsWindow = 1024
+
+// stats collects the stage timings for -stats, nil if disabled.
+var stats *timings
+
+// timings keeps the most recent durations of each stage.
+type timings struct {
+	mu      sync.Mutex
+	samples [numStages][statsWindow]time.Duration
+	count   [numStages]int
+}
+
+// add records that stage s took d. It does nothing on a nil timings.
+func (t *timings) add(s stage, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.samples[s][t.count[s]%statsWindow] = d
+	t.count[s]++
+	t.mu.Unlock()
+}
+
+// print writes the p50 and p95 of every stage that was timed to w.
+func (t *timings) print(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "stage\tcount\tp50\tp95\t")
+	for s := range numStages {
+		n := t.count[s]
+		if n == 0 {
+			continue
+		}
+		d := slices.Clone(t.samples[s][:min(n, statsWindow)])
+		slices.Sort(d)
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t\n", stageNames[s], n, percentile(d, 50), percentile(d, 95))
+	}
+	_ = tw.Flush()
+}
+
+// percentile returns the p-th percentile of the sorted durations d.
+func percentile(d []time.Duration, p int) time.Duration {
+	return d[(len(d)-1)*p/100].Round(time.Microsecond)
+}
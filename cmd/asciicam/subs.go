@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// cue is a caption shown from start to end.
+type cue struct {
+	start, end time.Duration
+	lines      []string
+}
+
+// subtitles are timed captions, sorted by start, see -subs.
+type subtitles []cue
+
+// cueTiming matches the timing line of SubRip and WebVTT cues, whose hours
+// are optional in WebVTT, e.g. "00:01:02,500 --> 00:01:04,000".
+var cueTiming = regexp.MustCompile(`^((?:\d+:)?\d+:\d+[.,]\d+)\s+-->\s+((?:\d+:)?\d+:\d+[.,]\d+)`)
+
+// cueTags matches the formatting tags of cue text, like <i> or <c.yellow>.
+var cueTags = regexp.MustCompile(`</?[^>]*>`)
+
+// loadSubtitles reads an .srt or .vtt file.
+func loadSubtitles(path string) (subtitles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	subs, err := parseSubtitles(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return subs, nil
+}
+
+// parseSubtitles parses SubRip or WebVTT cues. Cues are separated by blank
+// lines and start with their timing line, which may follow an identifier;
+// other blocks, like the WebVTT header and notes, are skipped.
+func parseSubtitles(r io.Reader) (subtitles, error) {
+	var subs subtitles
+	cur := -1 // index of the cue whose text follows
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch m := cueTiming.FindStringSubmatch(line); {
+		case strings.TrimSpace(line) == "":
+			cur = -1
+		case m != nil:
+			start, err1 := parseCueTime(m[1])
+			end, err2 := parseCueTime(m[2])
+			if err1 != nil || err2 != nil || end < start {
+				return nil, fmt.Errorf("line %d: invalid cue timing %q", n, line)
+			}
+			subs = append(subs, cue{start: start, end: end})
+			cur = len(subs) - 1
+		case cur >= 0:
+			subs[cur].lines = append(subs[cur].lines, cueTags.ReplaceAllString(line, ""))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("no cues found")
+	}
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].start < subs[j].start })
+	return subs, nil
+}
+
+// parseCueTime parses a cue time like "01:02:03,500" or "02:03.500".
+func parseCueTime(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var d time.Duration
+	for i, p := range parts {
+		unit := time.Duration(1)
+		for range len(parts) - 1 - i {
+			unit *= 60
+		}
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v * float64(unit*time.Second))
+	}
+	return d, nil
+}
+
+// at returns the lines of the cues showing at t, nil if there are none.
+func (s subtitles) at(t time.Duration) []string {
+	var lines []string
+	for _, c := range s {
+		if c.start > t {
+			break
+		}
+		if t < c.end {
+			lines = append(lines, c.lines...)
+		}
+	}
+	return lines
+}
+
+// drawSubtitles draws the captions of the current time centered at the
+// bottom, on a dark strip across the picture.
+func (m *model) drawSubtitles(lines []string) {
+	text := m.subs.at(m.subsAt)
+	if len(text) == 0 {
+		return
+	}
+	w := int(m.width)
+	_, _, bottom := m.cornerAt(lines, "bottom-left", w, len(text))
+	top := max(0, bottom-len(text))
+	style := termenv.Style{}
+	if m.profile != termenv.Ascii {
+		style = style.Foreground(m.profile.Color("#ffffff")).Background(m.profile.Color("#000000"))
+	}
+	for i, l := range text {
+		if top+i >= bottom {
+			break
+		}
+		l = ansi.Truncate(l, w, "")
+		pad := w - ansi.StringWidth(l)
+		strip := strings.Repeat(" ", pad/2) + l + strings.Repeat(" ", pad-pad/2)
+		lines[top+i] = overlay(lines[top+i], 0, style.Styled(strip))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// systemdFDStart is the first file descriptor systemd passes sockets in.
+const systemdFDStart = 3
+
+var (
+	activationOnce sync.Once
+	activation     map[string]net.Listener // passed sockets by name
+	activationErr  error
+)
+
+// activatedSockets returns the sockets systemd passed by socket activation,
+// by their FileDescriptorName, "" for unnamed ones. The environment is
+// cleared so the commands asciicam starts don't take them for theirs.
+func activatedSockets() (map[string]net.Listener, error) {
+	activationOnce.Do(func() {
+		defer func() {
+			_ = os.Unsetenv("LISTEN_PID")
+			_ = os.Unsetenv("LISTEN_FDS")
+			_ = os.Unsetenv("LISTEN_FDNAMES")
+		}()
+		if pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID")); pid != os.Getpid() {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil || n < 0 {
+			activationErr = fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+			return
+		}
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		activation = make(map[string]net.Listener, n)
+		for i := range n {
+			name := ""
+			if i < len(names) && names[i] != "unknown" {
+				name = names[i]
+			}
+			f := os.NewFile(uintptr(systemdFDStart+i), name)
+			lis, err := net.FileListener(f)
+			_ = f.Close()
+			if err != nil {
+				activationErr = fmt.Errorf("invalid socket %d from systemd: %w", i, err)
+				return
+			}
+			activation[name] = lis
+		}
+	})
+	return activation, activationErr
+}
+
+// listen listens on the TCP address addr, or with "systemd" or
+// "systemd:<name>" takes the socket systemd passed with that
+// FileDescriptorName, def without a name. A single unnamed socket is taken
+// whatever the name.
+func listen(addr, def string) (net.Listener, error) {
+	name, ok := strings.CutPrefix(addr, "systemd")
+	if !ok || name != "" && !strings.HasPrefix(name, ":") {
+		return net.Listen("tcp", addr)
+	}
+	name = strings.TrimPrefix(name, ":")
+	if name == "" {
+		name = def
+	}
+	socks, err := activatedSockets()
+	if err != nil {
+		return nil, err
+	}
+	if lis, ok := socks[name]; ok {
+		return lis, nil
+	}
+	if lis, ok := socks[""]; ok && len(socks) == 1 {
+		return lis, nil
+	}
+	return nil, fmt.Errorf("no socket %q passed by systemd", name)
+}
+
+// notifier sends readiness and watchdog notifications to the service
+// manager, see sd_notify(3). The zero value and nil do nothing.
+type notifier struct {
+	conn     *net.UnixConn
+	watchdog time.Duration // time between watchdog pings, 0 for none
+	ready    bool
+	lastPing time.Time
+}
+
+// newNotifier connects to $NOTIFY_SOCKET, returning nil when asciicam is
+// not run by systemd.
+func newNotifier() *notifier {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+	if strings.HasPrefix(path, "@") {
+		path = "\x00" + path[1:] // abstract namespace
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		slog.Warn("failed to connect to the notify socket", "err", err)
+		return nil
+	}
+	n := &notifier{conn: conn}
+	usec, _ := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	pid, err := strconv.Atoi(os.Getenv("WATCHDOG_PID"))
+	if usec > 0 && (err != nil || pid == os.Getpid()) {
+		// ping twice per timeout, as sd_watchdog_enabled(3) advises
+		n.watchdog = time.Duration(usec) * time.Microsecond / 2
+	}
+	_ = os.Unsetenv("NOTIFY_SOCKET")
+	return n
+}
+
+// send writes a notification, e.g. "READY=1".
+func (n *notifier) send(state string) {
+	if n == nil {
+		return
+	}
+	if _, err := n.conn.Write([]byte(state)); err != nil {
+		slog.Debug("failed to notify systemd", "state", state, "err", err)
+	}
+}
+
+// frame reports a frame shown at now: the first one makes the service
+// ready, the later ones keep the watchdog from restarting it, so a stalled
+// camera or pipeline does.
+func (n *notifier) frame(now time.Time) {
+	if n == nil {
+		return
+	}
+	if !n.ready {
+		n.ready = true
+		n.lastPing = now
+		n.send("READY=1\nSTATUS=Streaming")
+		return
+	}
+	if n.watchdog > 0 && now.Sub(n.lastPing) >= n.watchdog {
+		n.lastPing = now
+		n.send("WATCHDOG=1")
+	}
+}
+
+// close tells systemd asciicam is stopping.
+func (n *notifier) close() {
+	if n == nil {
+		return
+	}
+	n.send("STOPPING=1")
+	_ = n.conn.Close()
+}
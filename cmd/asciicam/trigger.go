@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// motionTrigger records or takes snapshots while the picture moves, see
+// -motion-trigger.
+type motionTrigger struct {
+	level     float64       // share of moving pixels that triggers
+	snapshots bool          // take snapshots instead of recording
+	preroll   time.Duration // recent frames recordings start with
+	cooldown  time.Duration // time without motion before a recording stops, or between snapshots
+	moved     time.Time     // last frame at or above level
+	taken     time.Time     // last snapshot
+	recording bool          // the current recording was started by the trigger
+}
+
+// checkMotion starts and stops motion triggered recordings and takes
+// motion triggered snapshots after the frame captured at now.
+func (m *model) checkMotion(now time.Time) {
+	t := m.trigger
+	if t == nil {
+		return
+	}
+	if m.motionLevel < t.level {
+		if t.recording && now.Sub(t.moved) >= t.cooldown {
+			m.stopRecording()
+		}
+		return
+	}
+	t.moved = now
+
+	if t.snapshots {
+		if now.Sub(t.taken) < t.cooldown || m.raw == nil {
+			return
+		}
+		t.taken = now
+		base, err := saveSnapshot(m.snapshots, m.raw, m.frame)
+		if err != nil {
+			m.setNotice(err.Error())
+			return
+		}
+		m.setNotice("motion: saved " + base)
+		m.snapshotSaved(base)
+		if m.webhook != nil && m.webhook.motion {
+			m.postSnapshot(base, "motion")
+		}
+		return
+	}
+	if m.rec != nil {
+		return
+	}
+
+	rec, path, err := newRecorder(m.recordings, m.recordFormat, m.width, m.height)
+	if err != nil {
+		m.setNotice(err.Error())
+		return
+	}
+	// the frames before this one, oldest first
+	for back := m.ring.Len() - 1; back > 0; back-- {
+		at, frame := m.ring.Info(back)
+		if now.Sub(at) > t.preroll {
+			continue
+		}
+		if err := rec.WriteFrame(m.ring.At(back), frame, at); err != nil {
+			_ = rec.Close()
+			m.setNotice(fmt.Sprintf("recording failed: %v", err))
+			return
+		}
+	}
+	m.rec = rec
+	t.recording = true
+	m.setNotice("motion: recording to " + path)
+}
@@ -0,0 +1,1129 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/face"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/term"
+)
+
+// model is the Bubble Tea model of a camera session. Frames arrive
+// rendered from the pipeline; View only composes the last frame with the
+// overlays.
+type model struct {
+	source              string
+	camWidth, camHeight uint
+	profile             termenv.Profile
+	cellAspect          float64 // height of a terminal cell relative to its width
+	autoWidth           bool
+	autoHeight          bool
+	showFPS             bool
+	mouse               bool
+	keys                keymap
+	fpsPos              string       // corner of the FPS overlay
+	text                *textOverlay // nil without a text overlay
+	banner              []string     // rendered banner lines
+	bannerPos           string       // corner of the banner or "center"
+	bannerPaused        bool         // show the banner while paused
+	showBanner          bool
+	showClock           bool
+	clockPos            string           // corner of the clock overlay
+	clockFormat         string           // time layout of the clock
+	qr                  []string         // QR code lines of -overlay-qr, nil without
+	qrPos               string           // corner of the QR code
+	termW, termH        uint             // terminal size, 0 if unknown
+	mux                 term.Multiplexer // wraps graphics for the outer terminal
+	graphicsWarning     string           // why graphics won't show, if they won't
+
+	calib     calibState
+	calibAt   time.Time // end of the calibration countdown
+	sample    string
+	samples   int
+	snapshots string // directory for snapshots
+
+	recordings   string // directory for recordings
+	recordFormat string
+	rec          recorder // nil while not recording
+
+	screen   bool
+	bgSample image.Image
+	keyed    bool           // key against keyColor instead of the background sample
+	keyColor colorful.Color // chroma key reference color
+
+	width, height    uint
+	threshold        float64
+	filters          string // filter chain spec, see filter.Parse
+	mirrorFlip       bool   // mirror set over the API on top of the filters
+	charset          int
+	renderer         int
+	color            color.RGBA // single output color, unused if alpha is 0
+	zoom, panX, panY float64    // crop window, center is relative to the frame
+	showStatus       bool
+	showHelp         bool
+	controls         source.Controllable // nil if the source has no device controls
+	menu             []source.Control
+	exposure         exposureLock
+	ambient          ambientSwitch
+	idle             idleSaver
+	menuSel          int
+	showMenu         bool
+	notice           string
+	noticeUntil      time.Time
+	stalled          *stallMsg // why no frames arrive, nil while they do
+
+	ring            *frameRing
+	paused          bool
+	back            int             // frames behind the newest one while paused
+	raw             *image.RGBA     // last captured frame, nil after the fast path
+	crop            image.Rectangle // part of raw visible at the current zoom
+	frame           string
+	showPiP         bool
+	split           bool // see -split
+	booth           photoBooth
+	wizard          keyWizard
+	configPath      string   // config file the wizard saves the threshold to
+	pip             []string // rendered raw preview lines
+	histMode        int      // 0 off, 1 luminance, 2 luminance and rgb
+	hist            []string // rendered histogram lines
+	histPos         string   // corner of the histogram
+	palette         *uiPalette
+	motion          int // motion highlighting, see motionModes
+	motionThreshold int
+	motionColor     color.RGBA
+	motionLevel     float64        // share of moving pixels in the last frame
+	trigger         *motionTrigger // nil without motion triggers
+	showFaces       bool
+	faces           []face.Face // faces in the frame on screen
+	autoZoom        autoZoom
+	follow          motionFollow
+	hideFaces       bool // see -privacy
+	whiteBalance    bool
+	wbPatch         [4]float64
+	night           nightSettings
+	subs            subtitles      // nil without -subs
+	subsStart       time.Time      // capture time of the first frame
+	subsAt          time.Duration  // subtitle time of the frame on screen
+	mic             *micMeter      // nil without -mic
+	chat            *chatLog       // nil without -chat
+	scan            *scanner       // nil without -scan
+	ocr             *ocrReader     // nil without -ocr
+	clipboard       string         // text to copy to the clipboard with the next frame
+	mqtt            *mqttPublisher // nil without -mqtt
+	webhook         *webhookPoster // nil without -webhook
+	micPos          string         // corner of the meter without the status bar
+	privacyStyle    int
+	fps             []float64
+	lastFrame       time.Time
+	fpsGraph        *frameTimes                   // nil without the FPS graph
+	settings        atomic.Pointer[frameSettings] // published for the pipeline
+	frames          *frameHub                     // streams frames over gRPC, nil if off
+	meta            *metaOut                      // frame metadata of -meta-out, nil if off
+	notify          *notifier                     // nil if not run by systemd
+	adapt           adaptive
+
+	// filter renders frozen frames while paused
+	filter frameFilter
+
+	// err is set when the session ended because of an error
+	err error
+}
+
+func (m *model) Init() tea.Cmd {
+	return m.mqtt.nextHeartbeat()
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer m.publishSettings()
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		cmd := m.handleKey(msg.String())
+		// frozen frames are re-rendered to show the changed settings
+		if m.paused {
+			m.render(m.ring.At(m.back))
+		}
+		return m, cmd
+
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.sampleKeyColor(msg.X, msg.Y)
+		}
+
+	case tea.WindowSizeMsg:
+		m.termW, m.termH = uint(msg.Width), uint(msg.Height)
+		if m.autoWidth {
+			m.width = uint(msg.Width)
+		}
+		if m.autoHeight {
+			m.height = uint(msg.Height)
+		}
+		if m.paused {
+			m.render(m.ring.At(m.back))
+		}
+
+	case frameMsg:
+		defer msg.pool.Put(msg.buf)
+		m.notify.frame(time.Now())
+		if m.paused {
+			break
+		}
+		if err := m.processFrame(msg); err != nil {
+			m.err = err
+			return m, tea.Quit
+		}
+
+	case settingsMsg:
+		s, err := m.applySettings(msg.update)
+		msg.reply <- settingsReply{s, err}
+		if m.paused {
+			m.render(m.ring.At(m.back))
+		}
+
+	case apiSettingsMsg:
+		s, err := m.applyAPISettings(msg.update)
+		msg.reply <- apiSettingsReply{s, err}
+		if m.paused {
+			m.render(m.ring.At(m.back))
+		}
+
+	case micMsg:
+		m.mic.update(msg, time.Now())
+		if msg.err != nil {
+			slog.Warn("microphone stopped", "err", msg.err)
+			m.setNotice("microphone stopped")
+		}
+
+	case chatMsg:
+		switch {
+		case msg.err != nil:
+			m.setNotice("chat disconnected, reconnecting")
+		case msg.joined:
+			m.setNotice("joined chat")
+		default:
+			m.chat.add(msg)
+		}
+
+	case ocrMsg:
+		m.ocrRead(msg)
+
+	case scanMsg:
+		m.scanned(msg, time.Now())
+
+	case webhookMsg:
+		if msg.err != nil {
+			m.setNotice("webhook failed: " + msg.err.Error())
+		} else {
+			m.setNotice("posted " + msg.base)
+		}
+
+	case mqttHeartbeatMsg:
+		m.sendHeartbeat()
+		return m, m.mqtt.nextHeartbeat()
+
+	case stallMsg:
+		m.stalled = nil
+		if msg.stalled {
+			m.stalled = &msg
+		} else {
+			m.setNotice("camera is back")
+		}
+
+	case sourceDoneMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// handleKey applies a key press to the session settings.
+func (m *model) handleKey(k string) tea.Cmd {
+	// keys wake the screensaver without doing anything else
+	if m.idle.wake(time.Now()) && k != "ctrl+c" {
+		return nil
+	}
+	// any key dismisses the help overlay
+	if m.showHelp && k != "ctrl+c" {
+		m.showHelp = false
+		return nil
+	}
+	if m.calib == calibPrompt {
+		switch k {
+		case "enter":
+			m.startCalibration()
+			return nil
+		case "esc":
+			m.calib = calibOff
+			return nil
+		}
+	}
+	if m.wizard.state != wizardOff && k != "ctrl+c" {
+		m.handleWizardKey(k)
+		return nil
+	}
+	if m.showMenu && k != "ctrl+c" {
+		m.handleMenuKey(k)
+		return nil
+	}
+
+	action := m.keys[k]
+	// zooming and panning by hand takes over from auto-zoom, panning from
+	// following motion too
+	if strings.HasPrefix(action, "zoom-") || strings.HasPrefix(action, "pan-") {
+		m.stopAutoZoom()
+	}
+	if strings.HasPrefix(action, "pan-") {
+		m.stopFollow()
+	}
+	switch action {
+	case "quit":
+		return tea.Quit
+	case "threshold-down", "threshold-up":
+		if !m.screen {
+			break
+		}
+		if action == "threshold-down" {
+			m.threshold = math.Max(0, m.threshold-0.01)
+		} else {
+			m.threshold += 0.01
+		}
+		m.setNotice(fmt.Sprintf("threshold: %.2f", m.threshold))
+	case "charset-next", "charset-prev":
+		if action == "charset-next" {
+			m.charset = (m.charset + 1) % len(render.Charsets)
+		} else {
+			m.charset = (m.charset + len(render.Charsets) - 1) % len(render.Charsets)
+		}
+		m.setNotice("charset: " + render.Charsets[m.charset].Name)
+	case "mode-next", "mode-prev":
+		if action == "mode-next" {
+			m.renderer = (m.renderer + 1) % len(render.Modes())
+		} else {
+			m.renderer = (m.renderer + len(render.Modes()) - 1) % len(render.Modes())
+		}
+		m.setNotice("mode: " + render.Modes()[m.renderer].Name)
+		if render.Modes()[m.renderer].Name == "sixel" && m.graphicsWarning != "" {
+			m.setNotice(m.graphicsWarning)
+		}
+	case "zoom-in", "zoom-out":
+		if action == "zoom-out" {
+			m.zoom = math.Max(1, m.zoom/1.25)
+		} else {
+			m.zoom = math.Min(8, m.zoom*1.25)
+		}
+		if m.follow.on {
+			// following keeps the zoom set by hand
+			m.follow.zoom = m.zoom
+		}
+		m.setNotice(fmt.Sprintf("zoom: %.1fx", m.zoom))
+	case "camera-menu":
+		if m.controls == nil {
+			m.setNotice("camera controls not available")
+			break
+		}
+		m.menu = m.controls.Controls()
+		m.menuSel = 0
+		m.showMenu = true
+	case "snapshot":
+		raw := m.rawFrame()
+		if raw == nil {
+			break
+		}
+		base, err := saveSnapshot(m.snapshots, raw, m.frame)
+		if err != nil {
+			m.setNotice(err.Error())
+			break
+		}
+		m.setNotice("saved " + base)
+		m.snapshotSaved(base)
+	case "webhook":
+		if m.webhook == nil {
+			m.setNotice("no webhook, set -webhook")
+			break
+		}
+		raw := m.rawFrame()
+		if raw == nil {
+			break
+		}
+		base, err := saveSnapshot(m.snapshots, raw, m.frame)
+		if err != nil {
+			m.setNotice(err.Error())
+			break
+		}
+		m.setNotice("posting " + base)
+		m.snapshotSaved(base)
+		m.postSnapshot(base, "key")
+	case "ocr":
+		switch {
+		case m.ocr == nil:
+			m.setNotice("no OCR, set -ocr")
+		case m.ocr.text == "":
+			m.setNotice("no text read")
+		default:
+			m.clipboard = m.ocr.text
+			m.setNotice("copied text")
+		}
+	case "record":
+		if m.rec != nil {
+			m.stopRecording()
+			break
+		}
+		if err := m.startRecording(); err != nil {
+			m.setNotice(err.Error())
+		}
+	case "pause":
+		m.paused = !m.paused
+		m.back = 0
+		if m.paused {
+			m.setNotice("paused")
+		}
+	case "step-back", "step-forward":
+		if m.ring.Len() == 0 {
+			break
+		}
+		m.paused = true
+		if action == "step-back" {
+			m.back = min(m.back+1, m.ring.Len()-1)
+		} else {
+			m.back = max(m.back-1, 0)
+		}
+		m.setNotice(fmt.Sprintf("frame -%d", m.back))
+	case "fps":
+		m.showFPS = !m.showFPS
+	case "inset":
+		m.showPiP = !m.showPiP
+	case "split":
+		m.split = !m.split
+	case "booth":
+		m.startBooth()
+	case "histogram":
+		m.histMode = (m.histMode + 1) % 3
+	case "calibrate":
+		m.calib = calibPrompt
+	case "calibrate-wizard":
+		m.startWizard()
+	case "status":
+		m.showStatus = !m.showStatus
+	case "banner":
+		m.showBanner = !m.showBanner
+	case "night":
+		m.night.on = !m.night.on
+		m.setNotice(fmt.Sprintf("night: %v", m.night.on))
+	case "exposure-lock":
+		m.toggleExposure()
+	case "help":
+		m.showHelp = true
+	case "auto-zoom":
+		if m.autoZoom.on {
+			m.stopAutoZoom()
+			m.zoom, m.panX, m.panY = 1, 0.5, 0.5
+		} else {
+			m.stopFollow()
+			m.autoZoom.on, m.autoZoom.last = true, time.Time{}
+			m.setNotice("auto-zoom: on")
+		}
+	case "follow-motion":
+		m.toggleFollow()
+	case "pan-left":
+		m.panX -= 0.1 / m.zoom
+	case "pan-right":
+		m.panX += 0.1 / m.zoom
+	case "pan-up":
+		m.panY -= 0.1 / m.zoom
+	case "pan-down":
+		m.panY += 0.1 / m.zoom
+	}
+
+	// keep the crop window inside the frame
+	_, m.panX, m.panY = filter.CropRect(image.Rectangle{}, m.zoom, m.panX, m.panY)
+	return nil
+}
+
+// handleMenuKey navigates the camera control menu. Changed values are
+// written to the device immediately.
+func (m *model) handleMenuKey(k string) {
+	switch k {
+	case "m", "esc", "q":
+		m.showMenu = false
+	case "up":
+		m.menuSel = max(0, m.menuSel-1)
+	case "down":
+		m.menuSel = min(len(m.menu)-1, m.menuSel+1)
+	case "left", "right":
+		if len(m.menu) == 0 {
+			break
+		}
+		c := &m.menu[m.menuSel]
+		value := c.Value + c.Step
+		if k == "left" {
+			value = c.Value - c.Step
+		}
+		value = min(max(value, c.Min), c.Max)
+		if err := m.controls.SetControl(c.ID, value); err != nil {
+			m.setNotice(fmt.Sprintf("%s: %v", c.Name, err))
+			break
+		}
+		c.Value = value
+	}
+}
+
+// startRecording starts recording the frames from now on.
+func (m *model) startRecording() error {
+	rec, path, err := newRecorder(m.recordings, m.recordFormat, m.width, m.height)
+	if err != nil {
+		return err
+	}
+	m.rec = rec
+	m.setNotice("recording to " + path)
+	return nil
+}
+
+// stopRecording finishes the running recording, if any.
+func (m *model) stopRecording() {
+	if m.rec == nil {
+		return
+	}
+	if err := m.rec.Close(); err != nil {
+		m.setNotice(err.Error())
+	} else {
+		m.setNotice("recording saved")
+	}
+	m.rec = nil
+	if m.trigger != nil {
+		m.trigger.recording = false
+	}
+}
+
+// detectsMotion reports whether frames go through motion detection.
+func (m *model) detectsMotion() bool {
+	return m.motion != motionOff || m.trigger != nil || m.mqtt != nil && m.mqtt.level > 0 || m.idle.after > 0 || m.meta != nil
+}
+
+// setNotice shows a short-lived message in the top left corner.
+func (m *model) setNotice(s string) {
+	m.notice = s
+	m.noticeUntil = time.Now().Add(2 * time.Second)
+}
+
+// sampleKeyColor uses the color of the frame below the given cell as the
+// chroma key reference and enables keying.
+func (m *model) sampleKeyColor(x, y int) {
+	raw := m.rawFrame()
+	if raw == nil || m.width == 0 || m.height == 0 {
+		return
+	}
+	w := int(m.width)
+	if m.showsSplit() {
+		// either half shows the crop
+		left, right := splitWidths(max(m.width, minWidth))
+		w = int(left)
+		if x > int(left) {
+			x, w = x-int(left)-1, int(right)
+		}
+	}
+	px := m.crop.Min.X + (2*x+1)*m.crop.Dx()/(2*w)
+	py := m.crop.Min.Y + (2*y+1)*m.crop.Dy()/int(2*m.height)
+
+	c, _ := colorful.MakeColor(raw.At(px, py))
+	m.keyColor = c
+	m.keyed = true
+	m.screen = true
+	m.setNotice("key color: " + c.Hex())
+}
+
+// processFrame takes over a frame rendered by the pipeline.
+func (m *model) processFrame(msg frameMsg) error {
+	buf := msg.buf
+	if buf.raw {
+		// rendered on the fast path, converted only when needed
+		m.ring.PushYUYV(buf.yuyv, buf.img.Rect, buf.read, msg.frame)
+		m.raw = nil
+	} else {
+		// generate background sample data (still only really useful for
+		// webcam, but works for gst as well if you want)
+		if err := m.calibrate(buf.img); err != nil {
+			return err
+		}
+
+		// the ring keeps a copy, buf goes back to the capture stage
+		m.raw = m.ring.Push(buf.img, buf.read, msg.frame)
+	}
+	m.frame, m.crop, m.pip, m.hist = msg.frame, msg.crop, msg.pip, msg.hist
+	m.motionLevel, m.faces = msg.motion, msg.faces
+	m.checkMotion(buf.read)
+	m.idleFrame(buf.read)
+	if m.mqtt != nil {
+		m.mqtt.motion(m.motionLevel, buf.read)
+	}
+	m.followFace(msg.face, buf.img.Rect, buf.read)
+	m.followMotion(msg.spot, buf.read)
+	if m.scan != nil {
+		m.scanFrame(time.Now())
+	}
+	if m.ocr != nil {
+		m.ocrFrame(time.Now())
+	}
+	m.boothFrame(time.Now())
+	m.wizardFrame(time.Now())
+	m.exposureFrame(time.Now())
+	m.ambientFrame(msg.bright, buf.read)
+	if m.subs != nil {
+		if m.subsStart.IsZero() {
+			m.subsStart = buf.read
+		}
+		m.subsAt = buf.read.Sub(m.subsStart)
+	}
+
+	if m.rec != nil {
+		// frames still on the fast path when the recording started are
+		// converted for it
+		if err := m.rec.WriteFrame(m.rawFrame(), m.frame, buf.read); err != nil {
+			m.setNotice("recording failed: " + err.Error())
+			_ = m.rec.Close()
+			m.rec = nil
+		}
+	}
+
+	if m.frames != nil {
+		s := m.settings.Load()
+		m.frames.publish(m.frame, s.width, s.height, buf.read)
+	}
+
+	stats.add(stageLatency, time.Since(buf.read))
+	if m.adapt.observe(time.Since(buf.sent)) {
+		m.setNotice(fmt.Sprintf("quality: -%d", m.adapt.level))
+	}
+	m.updateFPS()
+	if m.meta != nil {
+		m.writeMeta(msg)
+	}
+	return nil
+}
+
+// updateFPS records the frame rate of the frame just rendered.
+func (m *model) updateFPS() {
+	now := time.Now()
+	for i := len(m.fps) - 1; i > 0; i-- {
+		m.fps[i] = m.fps[i-1]
+	}
+	if !m.lastFrame.IsZero() {
+		m.fps[0] = float64(time.Second / now.Sub(m.lastFrame))
+		if m.fpsGraph != nil {
+			m.fpsGraph.add(now, now.Sub(m.lastFrame))
+		}
+	}
+	m.lastFrame = now
+}
+
+// render converts a frozen frame into terminal output using the current
+// settings.
+func (m *model) render(img *image.RGBA) {
+	if img == nil {
+		return
+	}
+	s := m.frameSettings()
+	left := s.splitView()
+	m.raw = img
+	scaled := scaledFrames.Get().(*filter.Scaler)
+	defer scaledFrames.Put(scaled)
+	m.crop, m.pip, m.hist = m.filter.filter(img, &s, scaled)
+	m.faces = m.filter.faces
+	m.frame = renderFrame(&s, scaled.Image())
+	if left > 0 {
+		var sc filter.Scaler
+		m.frame = joinSplit(renderSplit(&sc, img, m.crop, left, s.height, s.profile), m.frame)
+	}
+}
+
+// minWidth and minHeight are the smallest output size that leaves room for
+// a recognizable picture and the overlays.
+const (
+	minWidth  = 20
+	minHeight = 6
+)
+
+// maxWidth and maxHeight are the largest output size the APIs accept,
+// beyond any terminal, so clients can't make the frames too large to
+// allocate.
+const (
+	maxWidth  = 500
+	maxHeight = 250
+)
+
+// needSize returns the terminal size the output needs.
+func (m *model) needSize() (uint, uint) {
+	w, h := uint(minWidth), uint(minHeight)
+	if !m.autoWidth {
+		w = max(w, m.width)
+	}
+	if !m.autoHeight {
+		h = max(h, m.height)
+	}
+	return w, h
+}
+
+// tooSmall reports whether the terminal is known to be too small for the
+// output, which would wrap into garbage.
+func (m *model) tooSmall() bool {
+	w, h := m.needSize()
+	return m.termW > 0 && (m.termW < w || m.termH < h)
+}
+
+// tooSmallView asks to enlarge the terminal, wrapped to its width.
+func (m *model) tooSmallView() string {
+	w, h := m.needSize()
+	msg := fmt.Sprintf("Terminal too small, resize to at least %dx%d", w, h)
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(msg) {
+		if line != "" && len(line)+1+len(word) > int(m.termW) {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	lines = append(lines, line)
+	for i, l := range lines {
+		lines[i] = l[:min(len(l), int(m.termW))]
+	}
+	return strings.Join(lines[:min(len(lines), max(1, int(m.termH)))], "\n")
+}
+
+// frameSettings returns the current settings frames are processed with.
+func (m *model) frameSettings() frameSettings {
+	s := frameSettings{
+		width:           max(m.width, minWidth),
+		height:          max(m.height, minHeight),
+		profile:         m.profile,
+		cellAspect:      m.cellAspect,
+		renderer:        m.renderer,
+		charset:         m.charset,
+		color:           m.color,
+		zoom:            m.zoom,
+		panX:            m.panX,
+		panY:            m.panY,
+		screen:          m.screen,
+		keyed:           m.keyed,
+		keyColor:        m.keyColor,
+		bgSample:        m.bgSample,
+		threshold:       m.threshold,
+		filters:         m.filters,
+		mirror:          m.mirrorFlip,
+		calibrating:     m.calib != calibOff,
+		dim:             m.showHelp || m.idle.dims(),
+		pip:             m.showPiP,
+		split:           m.showsSplit(),
+		histMode:        m.histMode,
+		palette:         m.palette,
+		motion:          m.motion,
+		detectMotion:    m.detectsMotion(),
+		motionThreshold: m.motionThreshold,
+		motionColor:     m.motionColor,
+		detectFaces:     m.showFaces || m.autoZoom.on || m.hideFaces,
+		wholeFrame:      m.autoZoom.on || m.hideFaces,
+		followMotion:    m.follow.on,
+		hideFaces:       m.hideFaces,
+		privacyStyle:    m.privacyStyle,
+		whiteBalance:    m.whiteBalance,
+		wbPatch:         m.wbPatch,
+		night:           m.night,
+		ambient:         m.ambient.on,
+		gamma:           m.ambient.gamma(),
+	}
+	m.adapt.apply(&s)
+	s.fast = m.fastPath(s.renderer)
+	return s
+}
+
+// publishSettings hands changed settings to the pipeline.
+func (m *model) publishSettings() {
+	s := m.frameSettings()
+	if cur := m.settings.Load(); cur == nil || *cur != s {
+		m.settings.Store(&s)
+	}
+}
+
+// renderPiP renders img with ANSI half-blocks into an inset that is width
+// cells wide and keeps the aspect ratio of the frame on cells aspect times
+// as high as wide.
+func renderPiP(s *filter.Scaler, img *image.RGBA, width uint, aspect float64, p termenv.Profile) []string {
+	b := img.Bounds()
+	if width < 4 || b.Dx() == 0 {
+		return nil
+	}
+	// half-blocks hold two pixel rows per cell
+	height := max(2, uint(2*float64(width)*float64(b.Dy())/(float64(b.Dx())*aspect))) &^ 1
+	small := s.Scale(img, b, width, height)
+	out := render.ANSI{Options: render.Options{Profile: p}}.Render(small, int(width), int(height/2))
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+// saveSample writes img as the i-th background sample into dir.
+func saveSample(dir string, i int, img image.Image) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create sample dir: %w", err)
+	}
+	f, err := os.Create(fmt.Sprintf("%s/%d.png", dir, i))
+	if err != nil {
+		return fmt.Errorf("failed to create sample file: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to encode sample frame: %w", err)
+	}
+	return f.Close()
+}
+
+// averageFPS returns the mean over the recent frame rates.
+func (m *model) averageFPS() float64 {
+	var fpsa float64
+	for _, f := range m.fps {
+		fpsa += f
+	}
+	return fpsa / float64(len(m.fps))
+}
+
+func (m *model) View() string {
+	text, graphics := m.frameView()
+	return text + m.escapes(graphics)
+}
+
+// escapes returns what is written after the text of a frame: its graphics,
+// wrapped for the multiplexer, and a pending copy to the clipboard, which
+// terminals allow over SSH too.
+func (m *model) escapes(graphics string) string {
+	graphics = m.mux.Passthrough(graphics)
+	if m.clipboard != "" {
+		graphics += ansi.SetSystemClipboard(m.clipboard)
+		m.clipboard = ""
+	}
+	return graphics
+}
+
+// frameView renders the screen: the text lines of the frame with their
+// overlays, and the graphics sequence drawn after them, if any.
+func (m *model) frameView() (text, graphics string) {
+	if m.tooSmall() {
+		return m.tooSmallView(), ""
+	}
+	if m.frame == "" {
+		return "", ""
+	}
+	if m.idle.covers() {
+		return m.idleView(), ""
+	}
+	// sixel graphics are drawn after the text and its overlays
+	text, graphics = render.SplitGraphics(m.frame)
+	lines := strings.Split(text, "\n")
+
+	// status bar in the last row, recording indicator in the top right
+	// corner without it
+	if m.showStatus {
+		lines[len(lines)-1] = m.statusView()
+	} else if m.rec != nil {
+		m.drawCorner(lines, "top-right", []string{m.recView()})
+	}
+
+	if m.showsSplit() {
+		m.drawSplitLabels(lines)
+	}
+	if m.showFaces {
+		m.drawFaces(lines)
+	}
+	if m.scan != nil {
+		m.drawCodes(lines)
+	}
+	if len(m.hist) > 0 {
+		m.drawCorner(lines, m.histPos, m.hist)
+	}
+	if m.showPiP && len(m.pip) > 0 {
+		m.drawCorner(lines, "bottom-right", m.pip)
+	}
+	if m.showFPS {
+		text := fmt.Sprintf(" %.0f fps ", m.averageFPS())
+		if m.fpsGraph != nil {
+			text += m.fpsGraph.sparkline(time.Now(), fpsGraphWidth) + " "
+		}
+		fps := termenv.String(text).Reverse().String()
+		m.drawCorner(lines, m.fpsPos, []string{fps})
+	}
+	if m.showClock {
+		clock := termenv.String(" " + time.Now().Format(m.clockFormat) + " ").Reverse().String()
+		m.drawCorner(lines, m.clockPos, []string{clock})
+	}
+	if len(m.qr) > 0 {
+		m.drawQR(lines)
+	}
+	if m.mic != nil && !m.showStatus {
+		m.drawCorner(lines, m.micPos, []string{termenv.String(" " + m.mic.view() + " ").Reverse().String()})
+	}
+	if m.text != nil {
+		m.text.draw(m, lines)
+	}
+	if m.showBanner || m.bannerPaused && m.paused {
+		m.drawBanner(lines)
+	}
+	if m.chat != nil {
+		m.drawChat(lines)
+	}
+	if m.ocr != nil {
+		m.drawOCR(lines)
+	}
+	if m.subs != nil {
+		m.drawSubtitles(lines)
+	}
+
+	// show short-lived notices in the top left corner
+	if m.notice != "" && time.Now().Before(m.noticeUntil) {
+		lines[0] = overlay(lines[0], 0, termenv.String(" "+m.notice+" ").Reverse().String())
+	}
+
+	m.boothView(lines)
+	if m.stalled != nil {
+		m.stallView(lines)
+	}
+	if m.showHelp {
+		m.helpView(lines)
+	}
+	if m.showMenu {
+		m.menuView(lines)
+	}
+	m.calibView(lines)
+	m.wizardView(lines)
+
+	return strings.Join(lines, "\n"), graphics
+}
+
+// fpsGraphWidth is the width of the FPS graph in cells.
+const fpsGraphWidth = 20
+
+// corners are the positions drawCorner accepts.
+var corners = []string{"top-left", "top-right", "bottom-left", "bottom-right"}
+
+// drawCorner overlays block in a corner of lines ("top-left", "top-right",
+// "bottom-left" or "bottom-right"), keeping the status bar row free.
+func (m *model) drawCorner(lines []string, corner string, block []string) {
+	top, left, bottom := m.cornerAt(lines, corner, ansi.StringWidth(block[0]), len(block))
+	for i, l := range block {
+		if top+i >= bottom {
+			break
+		}
+		lines[top+i] = overlay(lines[top+i], left, l)
+	}
+}
+
+// drawBanner overlays the banner at its position, padded to a rectangle
+// so it covers the picture behind it.
+func (m *model) drawBanner(lines []string) {
+	if len(m.banner) == 0 {
+		return
+	}
+	block := padBlock(m.banner)
+	if m.bannerPos != "center" {
+		m.drawCorner(lines, m.bannerPos, block)
+		return
+	}
+	m.drawCentered(lines, block)
+}
+
+// padBlock pads the lines of block to the width of the widest one.
+func padBlock(block []string) []string {
+	w := 0
+	for _, l := range block {
+		w = max(w, ansi.StringWidth(l))
+	}
+	padded := make([]string, len(block))
+	for i, l := range block {
+		padded[i] = l + strings.Repeat(" ", w-ansi.StringWidth(l))
+	}
+	return padded
+}
+
+// drawCentered overlays block in the middle of lines.
+func (m *model) drawCentered(lines []string, block []string) {
+	if len(block) == 0 {
+		return
+	}
+	top := max(0, (len(lines)-len(block))/2)
+	left := max(0, (int(m.width)-ansi.StringWidth(block[0]))/2)
+	for i, l := range block {
+		if top+i >= len(lines) {
+			break
+		}
+		lines[top+i] = overlay(lines[top+i], left, l)
+	}
+}
+
+// cornerAt returns the first row and column of a w×h block in a corner of
+// lines and the row below the last one that can be drawn on.
+func (m *model) cornerAt(lines []string, corner string, w, h int) (top, left, bottom int) {
+	bottom = len(lines)
+	if m.showStatus {
+		bottom--
+	}
+	if strings.HasPrefix(corner, "bottom") {
+		top = max(0, bottom-h)
+	}
+	if strings.HasSuffix(corner, "right") {
+		left = max(0, int(m.width)-w)
+	}
+	return top, left, bottom
+}
+
+// statusView renders the status bar in inverse video across the full width.
+func (m *model) statusView() string {
+	gs := "off"
+	if m.keyed {
+		gs = fmt.Sprintf("key %s (%.2f)", m.keyColor.Hex(), m.threshold)
+	} else if m.screen {
+		gs = fmt.Sprintf("on (%.2f)", m.threshold)
+	}
+	if m.detectsMotion() {
+		gs += fmt.Sprintf(" | motion %.0f%%", 100*m.motionLevel)
+	}
+	if m.paused {
+		gs += fmt.Sprintf(" | paused -%d", m.back)
+	}
+	if m.mic != nil {
+		gs += " | " + m.mic.view()
+	}
+	mode := render.Modes()[m.renderer].Name
+	if m.adapt.level > 0 {
+		mode += fmt.Sprintf(" (quality -%d)", m.adapt.level)
+	}
+	status := fmt.Sprintf(" %s | %dx%d -> %dx%d | %s | %.0f fps | greenscreen %s",
+		m.source, m.camWidth, m.camHeight, m.width, m.height, mode, m.averageFPS(), gs)
+
+	width := int(m.width)
+	var rec string
+	if m.rec != nil && width > 5 {
+		rec = m.recView()
+		width -= 5
+	}
+	return rec + termenv.String(fmt.Sprintf("%-*.*s", width, width, status)).Reverse().String()
+}
+
+// recView renders the recording indicator, red but for -cb.
+func (m *model) recView() string {
+	return termenv.String(" REC ").Foreground(m.profile.Color("#ffffff")).Background(m.profile.Color(m.palette.rec)).Bold().String()
+}
+
+// stallView draws the box saying the source stalled.
+func (m *model) stallView(lines []string) {
+	text := []string{"No frames from the camera"}
+	switch {
+	case m.stalled.err != nil:
+		text = append(text, "reconnecting failed: "+m.stalled.err.Error(), "retrying...")
+	case m.stalled.restarted:
+		text = append(text, "reconnecting...")
+	}
+	drawBox(lines, int(m.width), text)
+}
+
+// helpView draws the help box centered over lines.
+func (m *model) helpView(lines []string) {
+	help := []string{"Keys", ""}
+	help = append(help, columns(m.keys.helpLines(), 2)...)
+	if m.mouse {
+		help = append(help, fmt.Sprintf("%-8s %s", "click", "sample chroma key color"))
+	}
+	help = append(help, "", "Settings", "",
+		fmt.Sprintf("%-12s %s", "source", m.source),
+		fmt.Sprintf("%-12s %s", "mode", render.Modes()[m.renderer].Name),
+		fmt.Sprintf("%-12s %s", "charset", render.Charsets[m.charset].Name),
+		fmt.Sprintf("%-12s %.1fx", "zoom", m.zoom),
+		fmt.Sprintf("%-12s %t (%.2f)", "greenscreen", m.screen, m.threshold),
+		"", "press any key to close")
+
+	drawBox(lines, int(m.width), help)
+}
+
+// menuView draws the camera control menu centered over lines.
+func (m *model) menuView(lines []string) {
+	menu := []string{"Camera controls", ""}
+	if len(m.menu) == 0 {
+		menu = append(menu, "no controls found")
+	}
+	for i, c := range m.menu {
+		cursor := " "
+		if i == m.menuSel {
+			cursor = ">"
+		}
+		menu = append(menu, fmt.Sprintf("%s %-28.28s %6d [%d..%d]", cursor, c.Name, c.Value, c.Min, c.Max))
+	}
+	menu = append(menu, "", "up/down select, left/right adjust, m close")
+
+	drawBox(lines, int(m.width), menu)
+}
+
+// columns arranges lines in n columns, filling them top to bottom.
+func columns(lines []string, n int) []string {
+	rows := (len(lines) + n - 1) / n
+	width := 0
+	for _, l := range lines {
+		width = max(width, utf8.RuneCountInString(l))
+	}
+
+	out := make([]string, rows)
+	for i, l := range lines {
+		if i >= rows {
+			out[i%rows] += "   "
+		}
+		out[i%rows] += fmt.Sprintf("%-*s", width, l)
+	}
+	return out
+}
+
+// drawBox draws text inside a frame centered over lines.
+func drawBox(lines []string, width int, text []string) {
+	inner := 0
+	for _, l := range text {
+		inner = max(inner, utf8.RuneCountInString(l))
+	}
+	inner = min(inner+2, width-2)
+
+	box := []string{"┌" + strings.Repeat("─", inner) + "┐"}
+	for _, l := range text {
+		r := []rune(" " + l)
+		if len(r) > inner {
+			r = r[:inner]
+		}
+		box = append(box, "│"+string(r)+strings.Repeat(" ", inner-len(r))+"│")
+	}
+	box = append(box, "└"+strings.Repeat("─", inner)+"┘")
+
+	top := max(0, (len(lines)-len(box))/2)
+	left := max(0, (width-inner-2)/2)
+	for i, l := range box {
+		if top+i >= len(lines) {
+			break
+		}
+		lines[top+i] = overlay(lines[top+i], left, l)
+	}
+}
+
+// overlay replaces the cells of line starting at column col with s.
+func overlay(line string, col int, s string) string {
+	return ansi.Truncate(line, col, "") + s + ansi.TruncateLeft(line, col+ansi.StringWidth(s), "")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set with
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)"
+//
+// Commit and date default to the VCS information Go embeds in binaries
+// built from a checkout.
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo returns the version, commit and build date of the binary.
+func buildInfo() (string, string, string) {
+	c, d := commit, date
+	if info, ok := debug.ReadBuildInfo(); ok && c == "" {
+		dirty := false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				c = s.Value
+			case "vcs.time":
+				d = s.Value
+			case "vcs.modified":
+				dirty = s.Value == "true"
+			}
+		}
+		if dirty && c != "" {
+			c += "-dirty"
+		}
+	}
+	return version, c, d
+}
+
+// versionString describes the build in one line.
+func versionString() string {
+	v, c, d := buildInfo()
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return fmt.Sprintf("asciicam %s (commit %s, built %s, %s %s/%s)", v, c, d, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// apiVersion is the build information of /api/version.
+type apiVersion struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Go      string `json:"go"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+}
+
+// versionHandler serves /api/version: the build information as JSON.
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	v, c, d := buildInfo()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(apiVersion{v, c, d, runtime.Version(), runtime.GOOS, runtime.GOARCH})
+}
+
+// withServerHeader names the build in the Server header of every response
+// of h, as asciicam/<version>.
+func withServerHeader(h http.Handler) http.Handler {
+	v, _, _ := buildInfo()
+	server := "asciicam/" + v
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", server)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/render"
+)
+
+// viewerPage is the web viewer served at / of -api.
+//
+//go:embed viewer.html
+var viewerPage []byte
+
+// viewWriteTimeout is the time a viewer gets to take a frame before it is
+// disconnected.
+const viewWriteTimeout = 10 * time.Second
+
+// viewerHandler serves the web viewer, a page showing the frames of
+// /api/view live.
+type viewerHandler struct{}
+
+func (viewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(viewerPage)
+}
+
+// viewUpgrader accepts the WebSockets of viewers on the same origin.
+var viewUpgrader = websocket.Upgrader{WriteBufferSize: 64 << 10}
+
+// viewHandler serves /api/view: a WebSocket sending every frame from now on
+// as HTML, one text message per frame, for the web viewer. With ?audio=1
+// it also sends the -api-audio stream, one binary message per Opus packet,
+// which browsers decode with WebCodecs.
+type viewHandler struct {
+	frames *frameHub
+	audio  *audioStream // nil without -api-audio
+	done   <-chan struct{}
+}
+
+func (h *viewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := viewUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader answered with the error
+		return
+	}
+	defer c.Close()
+	slog.Debug("viewer connected", "addr", r.RemoteAddr)
+
+	// viewers send nothing, but reading handles their pings and closing
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := c.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	frames, stop := h.frames.subscribe()
+	defer stop()
+	// pages stays nil, never ready, without audio
+	var pages <-chan []byte
+	if h.audio != nil && r.URL.Query().Get("audio") != "" {
+		var stopAudio func()
+		if _, pages, stopAudio, err = h.audio.subscribe(); err != nil {
+			slog.Debug("no audio for viewer", "err", err)
+		} else {
+			defer stopAudio()
+		}
+	}
+	send := func(kind int, msg []byte) bool {
+		_ = c.SetWriteDeadline(time.Now().Add(viewWriteTimeout))
+		if err := c.WriteMessage(kind, msg); err != nil {
+			slog.Debug("viewer gone", "err", err)
+			return false
+		}
+		return true
+	}
+	if f := h.frames.last(); f != nil && !send(websocket.TextMessage, []byte(render.HTML(f.Text))) {
+		return
+	}
+	var partial []byte // packet continued on the next page
+	for {
+		select {
+		case f := <-frames:
+			if !send(websocket.TextMessage, []byte(render.HTML(f.Text))) {
+				return
+			}
+		case page, ok := <-pages:
+			if !ok {
+				pages = nil
+				continue
+			}
+			var packets [][]byte
+			packets, partial = oggPackets(page, partial)
+			for _, p := range packets {
+				if len(p) > 0 && !send(websocket.BinaryMessage, p) {
+					return
+				}
+			}
+		case <-closed:
+			return
+		case <-h.done:
+			_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestViewSendsFramesAndAudio(t *testing.T) {
+	frames := &frameHub{}
+	audio := &audioStream{subs: make(map[chan []byte]struct{})}
+	done := make(chan struct{})
+	defer close(done)
+	srv := httptest.NewServer(&viewHandler{frames: frames, audio: audio, done: done})
+	defer srv.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/view?audio=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	// the handler subscribes after the upgrade
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		audio.mu.Lock()
+		n := len(audio.subs)
+		audio.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the viewer didn't subscribe to the audio")
+		}
+	}
+
+	frames.publish("\x1b[31mhi\x1b[0m", 2, 1, time.Now())
+	kind, msg, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != websocket.TextMessage || !strings.Contains(string(msg), "hi</span>") {
+		t.Errorf("frame message %d %q", kind, msg)
+	}
+
+	page := oggPage(false, []byte{3, 2}, []byte("abcde"))
+	page[6] = 1 // granule position of a data page
+	_ = audio.read(bufio.NewReader(bytes.NewReader(page)))
+	for _, want := range []string{"abc", "de"} {
+		kind, msg, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind != websocket.BinaryMessage || string(msg) != want {
+			t.Errorf("audio message %d %q, want the packet %q", kind, msg, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/source"
+)
+
+// stallMsg reports the source stalling or, with stalled false, delivering
+// frames again. restarted is set when the source was restarted, err when
+// that failed.
+type stallMsg struct {
+	stalled   bool
+	restarted bool
+	err       error
+}
+
+// stallWatchdog restarts sources that stop delivering frames without
+// failing, which some UVC cameras do; their reads just keep timing out.
+type stallWatchdog struct {
+	timeout time.Duration // time without a frame before a restart, 0 for never
+	send    func(tea.Msg)
+	last    time.Time // newest frame or restart
+	stalled bool
+}
+
+// frame records a frame read at now.
+func (w *stallWatchdog) frame(now time.Time) {
+	w.last = now
+	if w.stalled {
+		w.stalled = false
+		slog.Info("source delivers frames again")
+		w.send(stallMsg{})
+	}
+}
+
+// idle is called after a read of src that timed out at now. It restarts
+// src once no frame arrived for the timeout, and again after every further
+// timeout without one.
+func (w *stallWatchdog) idle(src source.Source, now time.Time) {
+	if w.last.IsZero() {
+		w.last = now
+	}
+	if w.timeout == 0 || now.Sub(w.last) < w.timeout {
+		return
+	}
+	w.last = now
+	w.stalled = true
+	r, ok := src.(source.Restarter)
+	if !ok {
+		slog.Warn("source stalled", "timeout", w.timeout)
+		w.send(stallMsg{stalled: true})
+		return
+	}
+	slog.Warn("source stalled, restarting", "timeout", w.timeout)
+	err := r.Restart()
+	if err != nil {
+		slog.Warn("failed to restart source", "err", err)
+	}
+	w.send(stallMsg{stalled: true, restarted: true, err: err})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// webhookKinds are the values of -webhook-kind.
+var webhookKinds = []string{"auto", "slack", "discord", "generic"}
+
+// webhookFiles are the snapshot files -webhook-files can attach.
+var webhookFiles = []string{"png", "ans"}
+
+const (
+	webhookTries    = 3                // attempts per snapshot
+	webhookBackoff  = time.Second      // wait before the first retry, doubling after
+	webhookMaxWait  = 30 * time.Second // longest Retry-After honored
+	webhookTimeout  = 30 * time.Second // time per attempt
+	slackTextLength = 3900             // characters of a Slack message text
+)
+
+// webhookMsg reports how posting a snapshot to the webhook went.
+type webhookMsg struct {
+	base string
+	err  error
+}
+
+// webhookPoster posts snapshots to a chat or HTTP webhook, see -webhook.
+// Slack's incoming webhooks only take text, so they get the .ans snapshot
+// without colors as a code block. Discord gets the files as attachments,
+// other webhooks a multipart form with the files and their description.
+//
+// Posts run in the background and are retried on network errors, 429 and
+// 5xx responses. At most one post is started per interval, more are
+// dropped.
+type webhookPoster struct {
+	ctx      context.Context
+	url      string
+	kind     string
+	files    []string      // snapshot files attached, see webhookFiles
+	motion   bool          // post the snapshots taken on motion
+	interval time.Duration // least time between posts
+	client   *http.Client
+	send     func(tea.Msg) // reports the outcome of posts
+	last     time.Time     // start of the last post
+}
+
+// newWebhookPoster checks the webhook URL, its kind, and the comma
+// separated list of files to attach.
+func newWebhookPoster(ctx context.Context, rawURL, kind, files string) (*webhookPoster, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", rawURL)
+	}
+	if !slices.Contains(webhookKinds, kind) {
+		return nil, fmt.Errorf("invalid webhook kind %q", kind)
+	}
+	if kind == "auto" {
+		kind = webhookKind(u)
+	}
+	w := &webhookPoster{ctx: ctx, url: rawURL, kind: kind, client: &http.Client{Timeout: webhookTimeout}}
+	for f := range strings.SplitSeq(files, ",") {
+		if f = strings.TrimSpace(f); !slices.Contains(webhookFiles, f) {
+			return nil, fmt.Errorf("invalid webhook file %q", f)
+		}
+		if !slices.Contains(w.files, f) {
+			w.files = append(w.files, f)
+		}
+	}
+	return w, nil
+}
+
+// webhookKind guesses the kind of webhook from its URL.
+func webhookKind(u *url.URL) string {
+	switch host := strings.ToLower(u.Hostname()); {
+	case host == "hooks.slack.com":
+		return "slack"
+	case (host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")) &&
+		strings.HasPrefix(u.Path, "/api/webhooks/"):
+		return "discord"
+	}
+	return "generic"
+}
+
+// postSnapshot posts the snapshot with the base path to the webhook, if
+// there is one, unless the last post was too recent. reason says what took
+// it, e.g. "motion".
+func (m *model) postSnapshot(base, reason string) {
+	w := m.webhook
+	if w == nil {
+		return
+	}
+	now := time.Now()
+	if wait := w.interval - now.Sub(w.last); !w.last.IsZero() && wait > 0 {
+		m.setNotice(fmt.Sprintf("webhook: next post in %s", wait.Round(time.Second)))
+		return
+	}
+	w.last = now
+	go func() {
+		err := w.post(base, reason, now)
+		if err != nil {
+			slog.Warn("failed to post snapshot to webhook", "snapshot", base, "err", err)
+		}
+		w.send(webhookMsg{base: base, err: err})
+	}()
+}
+
+// post sends the snapshot taken at t, retrying failures that may pass.
+func (w *webhookPoster) post(base, reason string, t time.Time) error {
+	body, contentType, err := w.payload(base, reason, t)
+	if err != nil {
+		return err
+	}
+	wait := webhookBackoff
+	for try := 1; ; try++ {
+		retryAfter, err := w.attempt(body, contentType)
+		if err == nil || try == webhookTries || errors.Is(err, errWebhookRejected) {
+			return err
+		}
+		slog.Debug("retrying webhook", "try", try, "err", err)
+		if retryAfter > 0 {
+			wait = min(retryAfter, webhookMaxWait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// errWebhookRejected marks responses that retrying won't change.
+var errWebhookRejected = errors.New("rejected")
+
+// attempt makes one attempt at posting body. It returns the wait the
+// webhook asked for before trying again, if any.
+func (w *webhookPoster) attempt(body []byte, contentType string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 == 2 {
+		return 0, nil
+	}
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		secs, _ := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+		return time.Duration(secs * float64(time.Second)), err
+	case resp.StatusCode >= 500:
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w: %w", errWebhookRejected, err)
+}
+
+// payload builds the request body for the kind of webhook and returns it
+// with its content type.
+func (w *webhookPoster) payload(base, reason string, t time.Time) ([]byte, string, error) {
+	text := fmt.Sprintf("asciicam snapshot (%s) at %s", reason, t.Format(time.DateTime))
+
+	if w.kind == "slack" {
+		if slices.Contains(w.files, "ans") {
+			ans, err := os.ReadFile(base + ".ans")
+			if err != nil {
+				return nil, "", err
+			}
+			frame := strings.TrimRight(ansi.Strip(string(ans)), "\n")
+			if r := []rune(frame); len(r) > slackTextLength {
+				frame = string(r[:slackTextLength])
+			}
+			text += "\n```\n" + frame + "\n```"
+		}
+		b, err := json.Marshal(map[string]string{"text": text})
+		return b, "application/json", err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if w.kind == "discord" {
+		b, err := json.Marshal(map[string]string{"content": text})
+		if err != nil {
+			return nil, "", err
+		}
+		_ = mw.WriteField("payload_json", string(b))
+	} else {
+		_ = mw.WriteField("text", text)
+		_ = mw.WriteField("reason", reason)
+		_ = mw.WriteField("time", t.Format(time.RFC3339))
+	}
+	for i, ext := range w.files {
+		data, err := os.ReadFile(base + "." + ext)
+		if err != nil {
+			return nil, "", err
+		}
+		field := ext
+		if w.kind == "discord" {
+			field = fmt.Sprintf("files[%d]", i)
+		}
+		fw, err := mw.CreateFormFile(field, filepath.Base(base)+"."+ext)
+		if err != nil {
+			return nil, "", err
+		}
+		_, _ = fw.Write(data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
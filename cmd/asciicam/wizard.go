@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/ownerofglory/go-asciicam-demo/asciicam/filter"
+)
+
+const (
+	wizardCountdown = 3 * time.Second        // time to step out of the frame
+	wizardEnter     = 5 * time.Second        // time to step into it
+	wizardCheckGap  = 500 * time.Millisecond // time between the background frames
+	wizardScoreW    = 160                    // size the frames are scored at
+	wizardScoreH    = 120
+)
+
+// wizardThresholds are the candidates the wizard sweeps.
+var wizardThresholds = []float64{0.04, 0.06, 0.09, 0.13, 0.18, 0.24, 0.32, 0.42}
+
+// wizardState is the step of the greenscreen wizard.
+type wizardState int
+
+const (
+	wizardOff wizardState = iota
+	wizardBackground
+	wizardCheck
+	wizardSubject
+	wizardPick
+)
+
+// wizardCandidate is a threshold of the sweep with its scores.
+type wizardCandidate struct {
+	threshold float64
+	leaks     float64 // share of the background that stays
+	holes     float64 // share of the subject keyed out inside its outline
+}
+
+// score is the sum of the errors, lower is better.
+func (c wizardCandidate) score() float64 {
+	return c.leaks + c.holes
+}
+
+// keyWizard finds the greenscreen threshold: it takes a frame of the
+// background as the reference, a second one to see how much of the
+// background the noise lets through, and one with the subject in it to see
+// how much of the subject is keyed out. It sweeps wizardThresholds over
+// these, previews the masks and picks the one with the fewest errors.
+type keyWizard struct {
+	state      wizardState
+	next       time.Time // end of the countdown or time of the next frame
+	ref, check *image.RGBA
+	subject    *image.RGBA
+	candidates []wizardCandidate
+	sel        int
+}
+
+// startWizard begins the guided greenscreen calibration.
+func (m *model) startWizard() {
+	if m.paused {
+		m.setNotice("resume to calibrate")
+		return
+	}
+	m.wizard = keyWizard{state: wizardBackground, next: time.Now().Add(wizardCountdown)}
+}
+
+// wizardFrame advances the wizard with the frame just shown.
+func (m *model) wizardFrame(now time.Time) {
+	w := &m.wizard
+	if w.state == wizardOff || w.state == wizardPick || now.Before(w.next) {
+		return
+	}
+	raw := m.rawFrame()
+	if raw == nil {
+		return
+	}
+	// raw is a ring buffer frame that is reused
+	img := image.NewRGBA(raw.Rect)
+	copy(img.Pix, raw.Pix)
+	switch w.state {
+	case wizardBackground:
+		w.ref, w.state, w.next = img, wizardCheck, now.Add(wizardCheckGap)
+	case wizardCheck:
+		w.check, w.state, w.next = img, wizardSubject, now.Add(wizardEnter)
+	case wizardSubject:
+		w.subject, w.state = img, wizardPick
+		w.candidates, w.sel = sweepThresholds(w.ref, w.check, w.subject)
+	}
+}
+
+// sweepThresholds scores every threshold of wizardThresholds on the frames
+// and returns the candidates and the index of the best one.
+func sweepThresholds(ref, check, subject *image.RGBA) ([]wizardCandidate, int) {
+	var s filter.Scaler
+	bg := filter.LabPlane(nil, s.Scale(ref, ref.Rect, wizardScoreW, wizardScoreH))
+	noise := filter.KeyDistances(nil, s.Scale(check, check.Rect, wizardScoreW, wizardScoreH), bg)
+	dist := filter.KeyDistances(nil, s.Scale(subject, subject.Rect, wizardScoreW, wizardScoreH), bg)
+
+	candidates := make([]wizardCandidate, len(wizardThresholds))
+	best := 0
+	for i, t := range wizardThresholds {
+		c := wizardCandidate{threshold: t}
+		for _, d := range noise {
+			if d >= t {
+				c.leaks++
+			}
+		}
+		c.leaks /= float64(len(noise))
+		c.holes = maskHoles(dist, wizardScoreW, wizardScoreH, t)
+		candidates[i] = c
+		if c.score() < candidates[best].score() {
+			best = i
+		}
+	}
+	return candidates, best
+}
+
+// maskHoles returns the share of the keyed out pixels of the w×h distances
+// at threshold t that the subject encloses, having kept pixels to their
+// left and right and above and below, of the subject's area.
+func maskHoles(dist []float64, w, h int, t float64) float64 {
+	kept := func(x, y int) bool { return dist[y*w+x] >= t }
+	// extent of the kept pixels in every row and column
+	rowMin, rowMax := make([]int, h), make([]int, h)
+	colMin, colMax := make([]int, w), make([]int, w)
+	for y := range h {
+		rowMin[y], rowMax[y] = w, -1
+	}
+	for x := range w {
+		colMin[x], colMax[x] = h, -1
+	}
+	area := 0
+	for y := range h {
+		for x := range w {
+			if kept(x, y) {
+				area++
+				rowMin[y], rowMax[y] = min(rowMin[y], x), max(rowMax[y], x)
+				colMin[x], colMax[x] = min(colMin[x], y), max(colMax[x], y)
+			}
+		}
+	}
+	holes := 0
+	for y := range h {
+		for x := rowMin[y] + 1; x < rowMax[y]; x++ {
+			if !kept(x, y) && y > colMin[x] && y < colMax[x] {
+				holes++
+			}
+		}
+	}
+	if area+holes == 0 {
+		return 0
+	}
+	return float64(holes) / float64(area+holes)
+}
+
+// handleWizardKey picks a candidate. Enter uses it and saves it, Esc
+// cancels.
+func (m *model) handleWizardKey(k string) {
+	w := &m.wizard
+	if w.state != wizardPick {
+		if k == "esc" {
+			w.state = wizardOff
+		}
+		return
+	}
+	switch k {
+	case "left", "h":
+		w.sel = max(0, w.sel-1)
+	case "right", "l":
+		w.sel = min(len(w.candidates)-1, w.sel+1)
+	case "esc":
+		w.state = wizardOff
+	case "enter":
+		m.useWizardThreshold()
+	}
+}
+
+// useWizardThreshold switches to the greenscreen with the reference
+// background and the chosen threshold, and saves both for the next run:
+// the background as the sample and the threshold in the config file.
+func (m *model) useWizardThreshold() {
+	w := &m.wizard
+	t := w.candidates[w.sel].threshold
+	m.bgSample, m.threshold = w.ref, t
+	m.screen, m.keyed = true, false
+	w.state = wizardOff
+
+	if err := saveSample(m.sample, bgSampleIndex, w.ref); err != nil {
+		m.setNotice(err.Error())
+		return
+	}
+	if err := setConfigOption(m.configPath, "threshold", strconv.FormatFloat(t, 'f', 2, 64)); err != nil {
+		m.setNotice(fmt.Sprintf("threshold %.2f, failed to save it: %v", t, err))
+		return
+	}
+	m.setNotice(fmt.Sprintf("threshold %.2f saved to %s", t, m.configPath))
+}
+
+// wizardView draws the current step of the wizard.
+func (m *model) wizardView(lines []string) {
+	w := &m.wizard
+	const title = "Greenscreen wizard"
+	switch w.state {
+	case wizardBackground:
+		left := time.Until(w.next).Seconds()
+		drawBox(lines, int(m.width), []string{title, "", fmt.Sprintf("Step out of the frame ... %.0f", max(0, left)+0.5)})
+	case wizardCheck:
+		drawBox(lines, int(m.width), []string{title, "", "Stay out of the frame"})
+	case wizardSubject:
+		left := time.Until(w.next).Seconds()
+		drawBox(lines, int(m.width), []string{title, "", fmt.Sprintf("Step into the frame ... %.0f", max(0, left)+0.5)})
+	case wizardPick:
+		m.drawCentered(lines, m.wizardGrid(len(lines)))
+	}
+}
+
+// wizardGrid renders the masks of the candidates on the subject frame in
+// braille, four per row, with their threshold and errors, and the help
+// line below.
+func (m *model) wizardGrid(height int) []string {
+	w := &m.wizard
+	const cols = 4
+	rows := (len(w.candidates) + cols - 1) / cols
+	// tiles are separated by a column and have a label line
+	tw := max(4, (int(max(m.width, minWidth))-(cols-1))/cols)
+	th := max(1, (height-1)/rows-1)
+	// braille pixels are about square, keep the aspect of the frame
+	b := w.subject.Rect
+	pw, ph := 2*tw, 2*tw*b.Dy()/b.Dx()
+	if ph > 4*th {
+		pw, ph = 4*th*b.Dx()/b.Dy(), 4*th
+	}
+	pw, ph = max(2, pw&^1), max(4, ph&^3)
+
+	var s filter.Scaler
+	bg := filter.LabPlane(nil, s.Scale(w.ref, w.ref.Rect, uint(pw), uint(ph)))
+	dist := filter.KeyDistances(nil, s.Scale(w.subject, b, uint(pw), uint(ph)), bg)
+
+	var grid []string
+	for r := range rows {
+		tiles := make([][]string, 0, cols)
+		for i := r * cols; i < min(len(w.candidates), (r+1)*cols); i++ {
+			c := w.candidates[i]
+			label := fmt.Sprintf("%.2f %2.0f%%", c.threshold, 100*c.score())
+			label = ansi.Truncate(label, tw, "")
+			label += strings.Repeat(" ", tw-ansi.StringWidth(label))
+			if i == w.sel {
+				label = termenv.String(label).Reverse().String()
+			}
+			tile := []string{label}
+			for _, l := range brailleMask(dist, pw, ph, c.threshold) {
+				tile = append(tile, l+strings.Repeat(" ", tw-pw/2))
+			}
+			tiles = append(tiles, tile)
+		}
+		for y := range len(tiles[0]) {
+			var row []string
+			for _, t := range tiles {
+				row = append(row, t[y])
+			}
+			grid = append(grid, strings.Join(row, " "))
+		}
+	}
+	help := "←/→ choose  Enter use and save  Esc cancel"
+	return padBlock(append(grid, ansi.Truncate(help, int(max(m.width, minWidth)), "")))
+}
+
+// brailleMask draws the pixels of the w×h distances at or above threshold
+// t, the ones the greenscreen keeps, as braille dots.
+func brailleMask(dist []float64, w, h int, t float64) []string {
+	dots := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+	lines := make([]string, 0, h/4)
+	for cy := 0; cy+4 <= h; cy += 4 {
+		var b strings.Builder
+		for cx := 0; cx+2 <= w; cx += 2 {
+			r := rune(0x2800)
+			for dy := range 4 {
+				for dx := range 2 {
+					if dist[(cy+dy)*w+cx+dx] >= t {
+						r |= dots[dy][dx]
+					}
+				}
+			}
+			b.WriteRune(r)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
@@ -0,0 +1,17 @@
+#!/usr/bin/env python3
+# Posterized picture, use with -filter-exec examples/filters/posterize.py
+import struct
+import sys
+
+stdin, stdout = sys.stdin.buffer, sys.stdout.buffer
+while True:
+    header = stdin.read(16)
+    if len(header) < 16:
+        break
+    magic, width, height, frame = struct.unpack(">4sIII", header)
+    pixels = bytearray(stdin.read(width * height * 3))
+    for i, v in enumerate(pixels):
+        pixels[i] = v & 0xC0 | 0x20
+    stdout.write(header)
+    stdout.write(pixels)
+    stdout.flush()
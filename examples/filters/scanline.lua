@@ -0,0 +1,8 @@
+-- A bright line rolling down the picture like on an old CRT
+function frame(f)
+	local y = frame_number % f:height()
+	for x = 0, f:width() - 1 do
+		local r, g, b = f:get(x, y)
+		f:set(x, y, r + 80, g + 80, b + 80)
+	end
+end
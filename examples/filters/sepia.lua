@@ -0,0 +1,5 @@
+-- Sepia toned picture, use with -filters script=examples/filters/sepia.lua
+function pixel(r, g, b, a, x, y)
+	local l = 0.299 * r + 0.587 * g + 0.114 * b
+	return l * 1.07, l * 0.74, l * 0.43
+end
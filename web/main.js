@@ -0,0 +1,65 @@
+// Browser front end of asciicam: captures the camera with getUserMedia and
+// renders every frame with the Go renderers compiled to WebAssembly.
+
+const camWidth = 320, camHeight = 180;
+// terminal cells are about twice as high as wide
+const cellAspect = 2;
+
+const $ = (id) => document.getElementById(id);
+
+async function loadGo() {
+	const go = new Go();
+	const wasm = await WebAssembly.instantiateStreaming(fetch("asciicam.wasm"), go.importObject);
+	go.run(wasm.instance);
+	for (const [select, names] of [[$("mode"), asciicamModes], [$("charset"), asciicamCharsets]]) {
+		for (const name of names) {
+			select.add(new Option(name, name));
+		}
+	}
+	$("mode").value = "ansi";
+}
+
+async function start() {
+	const video = document.createElement("video");
+	video.srcObject = await navigator.mediaDevices.getUserMedia({
+		video: { width: camWidth, height: camHeight },
+	});
+	video.muted = true;
+	await video.play();
+
+	const canvas = document.createElement("canvas");
+	canvas.width = camWidth;
+	canvas.height = camHeight;
+	const ctx = canvas.getContext("2d", { willReadFrequently: true });
+
+	const frame = () => {
+		ctx.drawImage(video, 0, 0, camWidth, camHeight);
+		const pixels = ctx.getImageData(0, 0, camWidth, camHeight).data;
+		const cols = Math.max(10, Number($("cols").value) || 120);
+		const rows = Math.round(cols * camHeight / camWidth / cellAspect);
+		const out = asciicamRender(pixels, camWidth, camHeight, cols, rows, {
+			mode: $("mode").value,
+			charset: $("charset").value,
+			filters: $("filters").value,
+			color: $("color").checked,
+		});
+		if (out instanceof Error) {
+			$("error").textContent = out.message;
+		} else {
+			$("error").textContent = "";
+			$("screen").innerHTML = out;
+		}
+		requestAnimationFrame(frame);
+	};
+	requestAnimationFrame(frame);
+}
+
+$("start").addEventListener("click", () => {
+	$("start").disabled = true;
+	start().catch((err) => {
+		$("error").textContent = err.message;
+		$("start").disabled = false;
+	});
+});
+
+loadGo().catch((err) => { $("error").textContent = "failed to load asciicam.wasm: " + err.message; });